@@ -1,11 +1,14 @@
 package action
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/google/disk_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/util"
 
 	"bosh-google-cpi/registry"
 )
@@ -14,23 +17,39 @@ type AttachDisk struct {
 	diskService    disk.Service
 	vmService      instance.Service
 	registryClient registry.Client
+	waitForDevice  bool
+	timeout        time.Duration
 }
 
 func NewAttachDisk(
 	diskService disk.Service,
 	vmService instance.Service,
 	registryClient registry.Client,
+	waitForDevice bool,
+	timeout time.Duration,
 ) AttachDisk {
 	return AttachDisk{
 		diskService:    diskService,
 		vmService:      vmService,
 		registryClient: registryClient,
+		waitForDevice:  waitForDevice,
+		timeout:        timeout,
 	}
 }
 
-func (ad AttachDisk) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
+// Run attaches the disk, with the device name and mode of the optional
+// properties if set, and records the device in the VM agent settings.
+func (ad AttachDisk) Run(vmCID VMCID, diskCID DiskCID, props ...AttachDiskProperties) (interface{}, error) {
+	var attachProps AttachDiskProperties
+	if len(props) > 0 {
+		attachProps = props[0]
+	}
+	if err := attachProps.Validate(); err != nil {
+		return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
+	}
+
 	// Find the disk
-	disk, found, err := ad.diskService.Find(string(diskCID), "")
+	d, found, err := ad.diskService.Find(string(diskCID), "")
 	if err != nil {
 		return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
 	}
@@ -38,8 +57,19 @@ func (ad AttachDisk) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
 		return nil, api.NewDiskNotFoundError(string(diskCID), false)
 	}
 
+	// Read-only disks can be shared, as long as no VM writes to them
+	mode := attachProps.Mode
+	if mode == "" {
+		mode = disk.ModeReadWrite
+	}
+	if mode == disk.ModeReadOnly {
+		if err = ad.validateReadOnlyUsers(vmCID, d); err != nil {
+			return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
+		}
+	}
+
 	// Atach the Disk to the VM
-	deviceName, devicePath, err := ad.vmService.AttachDisk(string(vmCID), disk.SelfLink)
+	deviceName, devicePath, err := ad.vmService.AttachDisk(string(vmCID), d.SelfLink, mode, attachProps.DeviceName)
 	if err != nil {
 		if _, ok := err.(api.CloudError); ok {
 			return nil, err
@@ -47,6 +77,17 @@ func (ad AttachDisk) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
 		return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
 	}
 
+	// Make sure the device shows on the VM before the agent looks for it
+	if ad.waitForDevice {
+		devicePath, err = ad.vmService.WaitForAttachedDisk(string(vmCID), d.SelfLink, deviceName, ad.timeout)
+		if err != nil {
+			if _, ok := err.(api.CloudError); ok {
+				return nil, err
+			}
+			return nil, bosherr.WrapErrorf(err, "Attaching disk '%s' to vm '%s'", diskCID, vmCID)
+		}
+	}
+
 	// Read VM agent settings
 	agentSettings, err := ad.registryClient.Fetch(string(vmCID))
 	if err != nil {
@@ -61,3 +102,30 @@ func (ad AttachDisk) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
 
 	return nil, nil
 }
+
+// validateReadOnlyUsers checks that none of the other VMs using the disk
+// have it attached READ_WRITE.
+func (ad AttachDisk) validateReadOnlyUsers(vmCID VMCID, d disk.Disk) error {
+	for _, user := range d.Users {
+		userID := util.ResourceSplitter(user)
+		if userID == string(vmCID) {
+			continue
+		}
+
+		vm, found, err := ad.vmService.Find(userID, d.Zone)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		for _, attachedDisk := range vm.Disks {
+			if attachedDisk.Source == d.SelfLink && attachedDisk.Mode == disk.ModeReadWrite {
+				return bosherr.Errorf("Disk '%s' is attached READ_WRITE to vm '%s'", d.Name, userID)
+			}
+		}
+	}
+
+	return nil
+}
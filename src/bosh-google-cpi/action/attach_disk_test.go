@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -17,6 +18,8 @@ import (
 	"bosh-google-cpi/google/disk_service"
 
 	"bosh-google-cpi/registry"
+
+	"google.golang.org/api/compute/v1"
 )
 
 var _ = Describe("AttachDisk", func() {
@@ -35,7 +38,7 @@ var _ = Describe("AttachDisk", func() {
 		diskService = &diskfakes.FakeDiskService{}
 		vmService = &instancefakes.FakeInstanceService{}
 		registryClient = &registryfakes.FakeClient{}
-		attachDisk = NewAttachDisk(diskService, vmService, registryClient)
+		attachDisk = NewAttachDisk(diskService, vmService, registryClient, false, 0)
 	})
 
 	Describe("Run", func() {
@@ -66,6 +69,105 @@ var _ = Describe("AttachDisk", func() {
 			Expect(registryClient.FetchCalled).To(BeTrue())
 			Expect(registryClient.UpdateCalled).To(BeTrue())
 			Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
+			Expect(vmService.AttachDiskMode).To(Equal("READ_WRITE"))
+		})
+
+		It("attaches the disk with the custom device name", func() {
+			_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{DeviceName: "fake-device-name"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.AttachDiskCalled).To(BeTrue())
+			Expect(vmService.AttachDiskName).To(Equal("fake-device-name"))
+			Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
+		})
+
+		It("returns an error if the custom device name is invalid", func() {
+			_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{DeviceName: "Fake_Device"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Device name "Fake_Device" is invalid`))
+			Expect(vmService.AttachDiskCalled).To(BeFalse())
+		})
+
+		It("does not wait for the device by default", func() {
+			_, err = attachDisk.Run("fake-vm-id", "fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.WaitForAttachedDiskCalled).To(BeFalse())
+		})
+
+		Context("when waiting for the device", func() {
+			BeforeEach(func() {
+				attachDisk = NewAttachDisk(diskService, vmService, registryClient, true, 5*time.Minute)
+				vmService.WaitForAttachedDiskDevicePath = "fake-verified-device-path"
+			})
+
+			It("records the device path seen on the vm once the disk shows up", func() {
+				_, err = attachDisk.Run("fake-vm-id", "fake-disk-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.WaitForAttachedDiskCalled).To(BeTrue())
+				Expect(vmService.WaitForAttachedDiskTimeout).To(Equal(5 * time.Minute))
+				Expect(registryClient.UpdateSettings.Disks.Persistent["fake-disk-id"].Path).To(Equal("fake-verified-device-path"))
+			})
+
+			It("returns an error if the disk does not show up on the vm", func() {
+				vmService.WaitForAttachedDiskErr = errors.New("fake-wait-error")
+
+				_, err = attachDisk.Run("fake-vm-id", "fake-disk-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-wait-error"))
+				Expect(registryClient.UpdateCalled).To(BeFalse())
+			})
+		})
+
+		It("returns an error if the mode is not supported", func() {
+			_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{Mode: "fake-mode"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported disk mode 'fake-mode'"))
+			Expect(diskService.FindCalled).To(BeFalse())
+			Expect(vmService.AttachDiskCalled).To(BeFalse())
+		})
+
+		Context("when attaching the disk READ_ONLY", func() {
+			BeforeEach(func() {
+				diskService.FindDisk = disk.Disk{
+					Name:     "fake-disk-id",
+					SelfLink: "fake-self-link",
+					Zone:     "fake-zone",
+					Users:    []string{"https://fake/instances/fake-other-vm-id"},
+				}
+				vmService.FindInstances = map[string]*compute.Instance{
+					"fake-other-vm-id": {
+						Name:  "fake-other-vm-id",
+						Disks: []*compute.AttachedDisk{{Source: "fake-self-link", Mode: "READ_ONLY"}},
+					},
+				}
+			})
+
+			It("attaches the disk read-only alongside other read-only attachments", func() {
+				_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{Mode: "READ_ONLY"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.FindCalled).To(BeTrue())
+				Expect(vmService.AttachDiskCalled).To(BeTrue())
+				Expect(vmService.AttachDiskMode).To(Equal("READ_ONLY"))
+				Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
+			})
+
+			It("attaches the same disk READ_WRITE when asked to, to populate it", func() {
+				vmService.FindInstances = nil
+				diskService.FindDisk.Users = nil
+
+				_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{Mode: "READ_WRITE"})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.AttachDiskMode).To(Equal("READ_WRITE"))
+			})
+
+			It("returns an error if another vm has the disk attached READ_WRITE", func() {
+				vmService.FindInstances["fake-other-vm-id"].Disks[0].Mode = "READ_WRITE"
+
+				_, err = attachDisk.Run("fake-vm-id", "fake-disk-id", AttachDiskProperties{Mode: "READ_ONLY"})
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Disk 'fake-disk-id' is attached READ_WRITE to vm 'fake-other-vm-id'"))
+				Expect(vmService.AttachDiskCalled).To(BeFalse())
+				Expect(registryClient.FetchCalled).To(BeFalse())
+			})
 		})
 
 		It("returns an error if diskService find call returns an error", func() {
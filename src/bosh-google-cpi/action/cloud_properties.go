@@ -2,17 +2,62 @@ package action
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
 
+	bogcconfig "bosh-google-cpi/google/config"
+	"bosh-google-cpi/google/disk_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/util"
 )
 
+var deviceNameRe = regexp.MustCompile("^[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?$")
+
+// Metadata SSH keys are a username followed by a public key, on one line.
+var sshKeyRe = regexp.MustCompile(`^[^:\s]+:\S+ [^\n]+$`)
+
+// AttachDiskProperties are the optional properties of attach_disk
+type AttachDiskProperties struct {
+	DeviceName string `json:"device_name,omitempty"`
+
+	// Mode is READ_WRITE, the default, or READ_ONLY. Disks attached
+	// READ_ONLY can be attached to several VMs at once.
+	Mode string `json:"mode,omitempty"`
+}
+
+func (p AttachDiskProperties) Validate() error {
+	if p.DeviceName != "" && !deviceNameRe.MatchString(p.DeviceName) {
+		return fmt.Errorf("Device name %q is invalid. Must match regular expression %q", p.DeviceName, deviceNameRe.String())
+	}
+	switch p.Mode {
+	case "", disk.ModeReadWrite, disk.ModeReadOnly:
+	default:
+		return fmt.Errorf("Unsupported disk mode '%s', must be '%s' or '%s'", p.Mode, disk.ModeReadWrite, disk.ModeReadOnly)
+	}
+	return nil
+}
+
 type DiskCloudProperties struct {
 	DiskType string `json:"type,omitempty"`
 	Zone     string `json:"zone,omitempty"`
+
+	// Snapshot CID the disk is restored from
+	Snapshot string `json:"snapshot,omitempty"`
+
+	Description string `json:"description,omitempty"`
 }
 
 type Environment map[string]interface{}
 
+type ImageCloudProperties struct {
+	Family      string          `json:"family,omitempty"`
+	Labels      instance.Labels `json:"labels,omitempty"`
+	Description string          `json:"description,omitempty"`
+
+	// Stop the VM while its boot disk is imaged, starting it again afterwards
+	StopVM bool `json:"stop_vm,omitempty"`
+}
+
 type NetworkCloudProperties struct {
 	NetworkName         string        `json:"network_name,omitempty"`
 	NetworkProjectID    string        `json:"xpn_host_project_id,omitempty"`
@@ -20,12 +65,22 @@ type NetworkCloudProperties struct {
 	Tags                instance.Tags `json:"tags,omitempty"`
 	EphemeralExternalIP bool          `json:"ephemeral_external_ip,omitempty"`
 	IPForwarding        bool          `json:"ip_forwarding,omitempty"`
+	ReservedIP          string        `json:"ip,omitempty"`
+	NetworkTier         string        `json:"network_tier,omitempty"`
+
+	// Name of an INTERNAL address reserved outside of the CPI, used as the
+	// instance private IP and left reserved when the instance is deleted
+	InternalAddressName string `json:"internal_address_name,omitempty"`
 }
 
 type SnapshotMetadata struct {
-	Deployment string      `json:"deployment,omitempty"`
-	Job        string      `json:"job,omitempty"`
-	Index      json.Number `json:"index,omitempty"`
+	Deployment   string      `json:"deployment,omitempty"`
+	Job          string      `json:"job,omitempty"`
+	Index        json.Number `json:"index,omitempty"`
+	DirectorName string      `json:"director_name,omitempty"`
+
+	// Tags set in the deployment manifest
+	CustomTags map[string]string `json:"custom_tags,omitempty"`
 }
 
 type StemcellCloudProperties struct {
@@ -37,16 +92,30 @@ type StemcellCloudProperties struct {
 	// URL of an existing image (Image.SelfLink)
 	ImageURL   string `json:"image_url,omitempty"`
 	SourceSha1 string `json:"raw_disk_sha1,omitempty"`
+
+	GuestOsFeatures []string `json:"guest_os_features,omitempty"`
+	Licenses        []string `json:"licenses,omitempty"`
+
+	// The stemcell ships the GPU driver, so accelerators can be attached
+	GPUDriver bool `json:"gpu_driver,omitempty"`
+
+	// Image family the stemcell image is created in
+	Family string `json:"family,omitempty"`
 }
 
 type VMCloudProperties struct {
 	Zone                string           `json:"zone,omitempty"`
+	Region              string           `json:"region,omitempty"`
 	Name                string           `json:"name,omitempty"`
 	MachineType         string           `json:"machine_type,omitempty"`
 	CPU                 int              `json:"cpu,omitempty"`
 	RAM                 int              `json:"ram,omitempty"`
 	RootDiskSizeGb      int              `json:"root_disk_size_gb,omitempty"`
 	RootDiskType        string           `json:"root_disk_type,omitempty"`
+	RootDeviceName      string           `json:"root_device_name,omitempty"`
+	DiskInterface       string           `json:"disk_interface,omitempty"`
+	EphemeralDisk       *EphemeralDisk   `json:"ephemeral_disk,omitempty"`
+	ImageFamily         string           `json:"image_family,omitempty"`
 	AutomaticRestart    bool             `json:"automatic_restart,omitempty"`
 	OnHostMaintenance   string           `json:"on_host_maintenance,omitempty"`
 	Preemptible         bool             `json:"preemptible,omitempty"`
@@ -59,9 +128,67 @@ type VMCloudProperties struct {
 	EphemeralExternalIP *bool            `json:"ephemeral_external_ip,omitempty"`
 	IPForwarding        *bool            `json:"ip_forwarding,omitempty"`
 	Accelerators        []Accelerator    `json:"accelerators,omitempty"`
+	ForceGPU            bool             `json:"force_gpu,omitempty"`
+	StartupScript       interface{}      `json:"startup_script,omitempty"`
+	Description         string           `json:"description,omitempty"`
+
+	EnableGuestAttributes bool `json:"enable_guest_attributes,omitempty"`
+
+	// OS Login, or the metadata SSH keys when it is disabled
+	EnableOSLogin *bool    `json:"enable_oslogin,omitempty"`
+	SSHKeys       []string `json:"ssh_keys,omitempty"`
+
+	// Cloud KMS keys the root disk is encrypted with, and the stemcell
+	// image it is created from is encrypted with
+	KmsKeyName            string `json:"kms_key_name,omitempty"`
+	SourceImageKmsKeyName string `json:"source_image_kms_key_name,omitempty"`
+
+	SourceInstanceTemplate string `json:"source_instance_template,omitempty"`
+
+	// RebootMethod overrides the configured reboot method for the VM
+	RebootMethod string `json:"reboot_method,omitempty"`
 }
 
 func (n VMCloudProperties) Validate() error {
+	if n.RootDeviceName != "" {
+		if !deviceNameRe.MatchString(n.RootDeviceName) {
+			return fmt.Errorf("Root device name %q is invalid. Must match regular expression %q", n.RootDeviceName, deviceNameRe.String())
+		}
+		if n.EphemeralDisk != nil && n.RootDeviceName == instance.EphemeralDiskDeviceName {
+			return fmt.Errorf("Root device name %q is already used by the ephemeral disk", n.RootDeviceName)
+		}
+	}
+
+	switch n.DiskInterface {
+	case "", instance.DiskInterfaceSCSI:
+	case instance.DiskInterfaceNVME:
+		return fmt.Errorf("Disk interface %q is not supported by the root disk. The root disk is a persistent disk, which must use %q", n.DiskInterface, instance.DiskInterfaceSCSI)
+	default:
+		return fmt.Errorf("Disk interface %q is invalid. Must be %q", n.DiskInterface, instance.DiskInterfaceSCSI)
+	}
+
+	if n.KmsKeyName != "" && !util.IsKmsKeyName(n.KmsKeyName) {
+		return fmt.Errorf("KMS key name %q is invalid. Must be projects/<project>/locations/<location>/keyRings/<key-ring>/cryptoKeys/<key>", n.KmsKeyName)
+	}
+
+	if n.SourceImageKmsKeyName != "" && !util.IsKmsKeyName(n.SourceImageKmsKeyName) {
+		return fmt.Errorf("Source image KMS key name %q is invalid. Must be projects/<project>/locations/<location>/keyRings/<key-ring>/cryptoKeys/<key>", n.SourceImageKmsKeyName)
+	}
+
+	if !bogcconfig.IsRebootMethod(n.RebootMethod) {
+		return fmt.Errorf("Reboot method %q is invalid. Must be %q, %q, %q or %q", n.RebootMethod, bogcconfig.RebootMethodReset, bogcconfig.RebootMethodHard, bogcconfig.RebootMethodStopStart, bogcconfig.RebootMethodSoft)
+	}
+
+	if len(n.SSHKeys) > 0 && n.EnableOSLogin != nil && *n.EnableOSLogin {
+		return fmt.Errorf("SSH keys can not be set when OS Login is enabled, as OS Login ignores the metadata SSH keys")
+	}
+
+	for _, key := range n.SSHKeys {
+		if !sshKeyRe.MatchString(key) {
+			return fmt.Errorf("SSH key %q is invalid. Must be '<username>:<public key>'", key)
+		}
+	}
+
 	if err := n.Tags.Validate(); err != nil {
 		return err
 	}
@@ -73,9 +200,16 @@ func (n VMCloudProperties) Validate() error {
 	return nil
 }
 
+// EphemeralDisk is the size, in MiB, and type of a separate ephemeral disk
+type EphemeralDisk struct {
+	Size int    `json:"size,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
 type VMServiceScopes []string
 type VMServiceAccount string
 type VMMetadata map[string]string
+type DiskMetadata map[string]string
 type Accelerator struct {
 	AcceleratorType string `json:"type,omitempty"`
 	Count           int64  `json:"count,omitempty"`
@@ -14,8 +14,10 @@ import (
 	"bosh-google-cpi/google/client"
 	"bosh-google-cpi/google/disk_service"
 	"bosh-google-cpi/google/disk_type_service"
+	"bosh-google-cpi/google/firewall_service"
 	"bosh-google-cpi/google/image_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/instance_template_service"
 	"bosh-google-cpi/google/machine_type_service"
 	"bosh-google-cpi/google/network_service"
 	"bosh-google-cpi/google/operation_service"
@@ -23,6 +25,7 @@ import (
 	"bosh-google-cpi/google/snapshot_service"
 	"bosh-google-cpi/google/subnetwork_service"
 	"bosh-google-cpi/google/target_pool_service"
+	"bosh-google-cpi/google/zone_service"
 
 	"bosh-google-cpi/google/accelerator_type_service"
 	"bosh-google-cpi/registry"
@@ -62,25 +65,42 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 		return nil, bosherr.WrapErrorf(err, "Building goog client")
 	}
 
+	timeouts := operation.Timeouts{
+		Operation: googleClient.OperationTimeout(),
+		Create:    googleClient.CreateTimeout(),
+		Delete:    googleClient.DeleteTimeout(),
+		Attach:    googleClient.AttachTimeout(),
+		Snapshot:  googleClient.SnapshotTimeout(),
+	}
+
 	operationService := operation.NewGoogleOperationService(
 		googleClient.Project(),
 		googleClient.ComputeService(),
 		googleClient.ComputeBetaService(),
 		f.logger,
+		googleClient.OperationPollInterval(),
+		googleClient.StuckOperationThreshold(),
+		googleClient.AbortStuckOperations(),
+		timeouts,
 	)
 
 	addressService := address.NewGoogleAddressService(
 		googleClient.Project(),
 		googleClient.ComputeService(),
+		operationService,
 		f.logger,
 	)
 
 	diskService := disk.NewGoogleDiskService(
 		googleClient.Project(),
 		googleClient.ComputeService(),
+		googleClient.ComputeBetaService(),
 		operationService,
 		f.uuidGen,
 		f.logger,
+		googleClient.DryRun(),
+		googleClient.FeatureAPIVersion(bogcconfig.FeatureDisks),
+		timeouts.For(operation.OperationTypeInsert),
 	)
 
 	diskTypeService := disktype.NewGoogleDiskTypeService(
@@ -90,12 +110,15 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 	)
 
 	imageService := image.NewGoogleImageService(
-		googleClient.Project(),
+		googleClient.ImageProject(),
 		googleClient.ComputeService(),
 		googleClient.StorageService(),
 		operationService,
 		f.uuidGen,
 		f.logger,
+		googleClient.StemcellBucket(),
+		googleClient.UniformBucketLevelAccess(),
+		googleClient.UploadProgressInterval(),
 	)
 
 	backendServiceService := backendservice.NewGoogleBackendServiceService(
@@ -117,8 +140,20 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 		f.logger,
 	)
 
-	projectService := project.NewGoogleProjectService(
+	instanceTemplateService := instancetemplate.NewGoogleInstanceTemplateService(
+		googleClient.Project(),
+		googleClient.ComputeService(),
+		f.logger,
+	)
+
+	zoneService := zone.NewGoogleZoneService(
 		googleClient.Project(),
+		googleClient.ComputeService(),
+		f.logger,
+	)
+
+	projectService := project.NewGoogleProjectService(
+		googleClient.NetworkProject(),
 	)
 
 	networkService := network.NewGoogleNetworkService(
@@ -127,6 +162,13 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 		f.logger,
 	)
 
+	firewallService := firewall.NewGoogleFirewallService(
+		googleClient.NetworkProject(),
+		googleClient.ComputeService(),
+		operationService,
+		f.logger,
+	)
+
 	// Choose the correct registry.Client based on the
 	// value of ClientOptions.UseGCEMetadata
 	var registryClient registry.Client
@@ -143,12 +185,20 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 			f.logger,
 		)
 	}
+	if googleClient.DryRun() {
+		registryClient = registry.NewDryRunClient(f.logger)
+	}
+
 	snapshotService := snapshot.NewGoogleSnapshotService(
 		googleClient.Project(),
 		googleClient.ComputeService(),
+		googleClient.ComputeBetaService(),
 		operationService,
 		f.uuidGen,
 		f.logger,
+		googleClient.SnapshotGuestFlush(),
+		googleClient.SnapshotKmsKeyName(),
+		timeouts.For(operation.OperationTypeCreateSnapshot),
 	)
 
 	subnetworkService := subnetwork.NewGoogleSubnetworkService(
@@ -176,27 +226,42 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 		targetPoolService,
 		f.uuidGen,
 		f.logger,
+		googleClient.DryRun(),
+		googleClient.GracefulShutdownTimeout(),
+		googleClient.UseBetaAPI(),
+		googleClient.MetadataKeyPrefix(),
+		googleClient.FeatureAPIVersion(bogcconfig.FeatureInstances),
 	)
 
+	description := ResourceDescription{
+		Default:      googleClient.DefaultDescription(),
+		DirectorUUID: googleClient.DirectorUUID(),
+	}
+
 	actions := map[string]Action{
 		// Disk management
 		"create_disk": NewCreateDisk(
 			diskService,
 			diskTypeService,
+			snapshotService,
 			vmService,
+			description,
 		),
 		"delete_disk": NewDeleteDisk(diskService),
-		"attach_disk": NewAttachDisk(diskService, vmService, registryClient),
+		"attach_disk": NewAttachDisk(diskService, vmService, registryClient, googleClient.WaitForAttachedDisk(), timeouts.For(operation.OperationTypeAttachDisk)),
 		"detach_disk": NewDetachDisk(vmService, registryClient),
 		"has_disk":    NewHasDisk(diskService),
 
+		"set_disk_metadata": NewSetDiskMetadata(diskService),
+
 		// Snapshot management
 		"snapshot_disk":   NewSnapshotDisk(snapshotService, diskService),
 		"delete_snapshot": NewDeleteSnapshot(snapshotService),
 
 		// Stemcell management
-		"create_stemcell": NewCreateStemcell(imageService),
-		"delete_stemcell": NewDeleteStemcell(imageService),
+		"create_stemcell":      NewCreateStemcell(imageService, googleClient.DirectorUUID(), googleClient.StemcellDeprecationState(), description),
+		"delete_stemcell":      NewDeleteStemcell(imageService),
+		"create_image_from_vm": NewCreateImageFromVM(vmService, imageService, description),
 
 		// VM management
 		"create_vm": NewCreateVM(
@@ -206,19 +271,33 @@ func (f ConcreteFactory) Create(method string, ctx map[string]interface{}) (Acti
 			imageService,
 			machineTypeService,
 			acceleratorTypeService,
+			instanceTemplateService,
+			zoneService,
 			registryClient,
 			f.cfg.Cloud.Properties.Registry,
 			f.cfg.Cloud.Properties.Agent,
 			googleClient.DefaultRootDiskSizeGb(),
 			googleClient.DefaultRootDiskType(),
+			googleClient.DefaultServiceScopes(),
+			googleClient.DefaultNetworkTier(),
+			googleClient.DefaultTags(),
+			googleClient.DisableExternalIP(),
+			description,
 		),
 		"configure_networks": NewConfigureNetworks(vmService, registryClient),
-		"delete_vm":          NewDeleteVM(vmService, registryClient),
-		"reboot_vm":          NewRebootVM(vmService),
-		"set_vm_metadata":    NewSetVMMetadata(vmService),
+		"delete_vm":          NewDeleteVM(vmService, diskService, firewallService, registryClient, googleClient.DeploymentIsolation()),
+		"reboot_vm":          NewRebootVM(vmService, googleClient.StopStartOnReboot(), googleClient.RebootWaitForRunning(), googleClient.RebootGracePeriod(), timeouts.For(operation.OperationTypeReset)),
+		"stop_vm":            NewStopVM(vmService),
+		"start_vm":           NewStartVM(vmService),
+		"set_vm_metadata":    NewSetVMMetadata(vmService, firewallService, googleClient.DeploymentIsolation()),
 		"has_vm":             NewHasVM(vmService),
 		"get_disks":          NewGetDisks(vmService),
 
+		// Maintenance
+		"list_managed_resources": NewListManagedResources(vmService, diskService),
+		"delete_old_snapshots":   NewDeleteOldSnapshots(snapshotService),
+		"move_disk":              NewMoveDisk(diskService, diskTypeService, snapshotService),
+
 		// Others:
 		"info": NewInfo(),
 		"ping": NewPing(),
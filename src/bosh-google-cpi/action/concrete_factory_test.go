@@ -16,9 +16,10 @@ import (
 	"bosh-google-cpi/google/client"
 	"bosh-google-cpi/google/disk_service"
 	"bosh-google-cpi/google/disk_type_service"
+	"bosh-google-cpi/google/firewall_service"
 	"bosh-google-cpi/google/image_service"
-	"bosh-google-cpi/google/instance_group_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/instance_template_service"
 	"bosh-google-cpi/google/machine_type_service"
 	"bosh-google-cpi/google/network_service"
 	"bosh-google-cpi/google/operation_service"
@@ -26,6 +27,7 @@ import (
 	"bosh-google-cpi/google/snapshot_service"
 	"bosh-google-cpi/google/subnetwork_service"
 	"bosh-google-cpi/google/target_pool_service"
+	"bosh-google-cpi/google/zone_service"
 
 	"bosh-google-cpi/google/accelerator_type_service"
 	"bosh-google-cpi/registry"
@@ -56,21 +58,23 @@ var _ = Describe("ConcreteFactory", func() {
 	)
 
 	var (
-		operationService       operation.GoogleOperationService
-		addressService         address.Service
-		diskService            disk.Service
-		diskTypeService        disktype.Service
-		imageService           image.Service
-		instanceGroupService   instancegroup.Service
-		backendServiceService  backendservice.Service
-		machineTypeService     machinetype.Service
-		acceleratorTypeService acceleratortype.Service
-		networkService         network.Service
-		snapshotService        snapshot.Service
-		subnetworkService      subnetwork.Service
-		registryClient         registry.Client
-		targetPoolService      targetpool.Service
-		vmService              instance.Service
+		operationService        operation.GoogleOperationService
+		addressService          address.Service
+		diskService             disk.Service
+		diskTypeService         disktype.Service
+		firewallService         firewall.Service
+		imageService            image.Service
+		backendServiceService   backendservice.Service
+		machineTypeService      machinetype.Service
+		acceleratorTypeService  acceleratortype.Service
+		instanceTemplateService instancetemplate.Service
+		networkService          network.Service
+		snapshotService         snapshot.Service
+		subnetworkService       subnetwork.Service
+		registryClient          registry.Client
+		targetPoolService       targetpool.Service
+		zoneService             zone.Service
+		vmService               instance.Service
 	)
 
 	BeforeEach(func() {
@@ -99,20 +103,35 @@ var _ = Describe("ConcreteFactory", func() {
 			googleClient.ComputeService(),
 			googleClient.ComputeBetaService(),
 			logger,
+			googleClient.OperationPollInterval(),
+			googleClient.StuckOperationThreshold(),
+			googleClient.AbortStuckOperations(),
+			operation.Timeouts{
+				Operation: googleClient.OperationTimeout(),
+				Create:    googleClient.CreateTimeout(),
+				Delete:    googleClient.DeleteTimeout(),
+				Attach:    googleClient.AttachTimeout(),
+				Snapshot:  googleClient.SnapshotTimeout(),
+			},
 		)
 
 		addressService = address.NewGoogleAddressService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
+			operationService,
 			logger,
 		)
 
 		diskService = disk.NewGoogleDiskService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
+			googleClient.ComputeBetaService(),
 			operationService,
 			uuidGen,
 			logger,
+			false,
+			"",
+			operation.Timeout,
 		)
 
 		diskTypeService = disktype.NewGoogleDiskTypeService(
@@ -128,6 +147,9 @@ var _ = Describe("ConcreteFactory", func() {
 			operationService,
 			uuidGen,
 			logger,
+			"",
+			false,
+			googleClient.UploadProgressInterval(),
 		)
 
 		backendServiceService = backendservice.NewGoogleBackendServiceService(
@@ -137,20 +159,25 @@ var _ = Describe("ConcreteFactory", func() {
 			logger,
 		)
 
-		instanceGroupService = instancegroup.NewGoogleInstanceGroupService(
+		machineTypeService = machinetype.NewGoogleMachineTypeService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
-			operationService,
 			logger,
 		)
 
-		machineTypeService = machinetype.NewGoogleMachineTypeService(
+		acceleratorTypeService = acceleratortype.NewGoogleAcceleratorTypeService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
 			logger,
 		)
 
-		acceleratorTypeService = acceleratortype.NewGoogleAcceleratorTypeService(
+		instanceTemplateService = instancetemplate.NewGoogleInstanceTemplateService(
+			ctx["project"].(string),
+			googleClient.ComputeService(),
+			logger,
+		)
+
+		zoneService = zone.NewGoogleZoneService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
 			logger,
@@ -166,6 +193,13 @@ var _ = Describe("ConcreteFactory", func() {
 			logger,
 		)
 
+		firewallService = firewall.NewGoogleFirewallService(
+			ctx["project"].(string),
+			googleClient.ComputeService(),
+			operationService,
+			logger,
+		)
+
 		registryClient = registry.NewHTTPClient(
 			cfg.Cloud.Properties.Registry,
 			logger,
@@ -174,9 +208,13 @@ var _ = Describe("ConcreteFactory", func() {
 		snapshotService = snapshot.NewGoogleSnapshotService(
 			ctx["project"].(string),
 			googleClient.ComputeService(),
+			googleClient.ComputeBetaService(),
 			operationService,
 			uuidGen,
 			logger,
+			false,
+			"",
+			operation.Timeout,
 		)
 
 		subnetworkService = subnetwork.NewGoogleSubnetworkService(
@@ -204,6 +242,11 @@ var _ = Describe("ConcreteFactory", func() {
 			targetPoolService,
 			uuidGen,
 			logger,
+			false,
+			0,
+			false,
+			"",
+			"",
 		)
 	})
 
@@ -219,7 +262,9 @@ var _ = Describe("ConcreteFactory", func() {
 		Expect(action).To(Equal(NewCreateDisk(
 			diskService,
 			diskTypeService,
+			snapshotService,
 			vmService,
+			ResourceDescription{},
 		)))
 	})
 
@@ -232,7 +277,7 @@ var _ = Describe("ConcreteFactory", func() {
 	It("attach_disk", func() {
 		action, err := factory.Create("attach_disk", ctx)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewAttachDisk(diskService, vmService, registryClient)))
+		Expect(action).To(Equal(NewAttachDisk(diskService, vmService, registryClient, false, operation.Timeout)))
 	})
 
 	It("detach_disk", func() {
@@ -256,7 +301,7 @@ var _ = Describe("ConcreteFactory", func() {
 	It("create_stemcell", func() {
 		action, err := factory.Create("create_stemcell", ctx)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewCreateStemcell(imageService)))
+		Expect(action).To(Equal(NewCreateStemcell(imageService, "", "", ResourceDescription{})))
 	})
 
 	It("delete_stemcell", func() {
@@ -265,6 +310,30 @@ var _ = Describe("ConcreteFactory", func() {
 		Expect(action).To(Equal(NewDeleteStemcell(imageService)))
 	})
 
+	It("list_managed_resources", func() {
+		action, err := factory.Create("list_managed_resources", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewListManagedResources(vmService, diskService)))
+	})
+
+	It("delete_old_snapshots", func() {
+		action, err := factory.Create("delete_old_snapshots", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewDeleteOldSnapshots(snapshotService)))
+	})
+
+	It("move_disk", func() {
+		action, err := factory.Create("move_disk", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewMoveDisk(diskService, diskTypeService, snapshotService)))
+	})
+
+	It("create_image_from_vm", func() {
+		action, err := factory.Create("create_image_from_vm", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewCreateImageFromVM(vmService, imageService, ResourceDescription{})))
+	})
+
 	It("create_vm", func() {
 		action, err := factory.Create("create_vm", ctx)
 		Expect(err).ToNot(HaveOccurred())
@@ -275,11 +344,18 @@ var _ = Describe("ConcreteFactory", func() {
 			imageService,
 			machineTypeService,
 			acceleratorTypeService,
+			instanceTemplateService,
+			zoneService,
 			registryClient,
 			cfg.Cloud.Properties.Registry,
 			cfg.Cloud.Properties.Agent,
 			ctx["default_root_disk_size_gb"].(int),
 			ctx["default_root_disk_type"].(string),
+			[]string(nil),
+			"",
+			[]string(nil),
+			false,
+			ResourceDescription{},
 		)))
 	})
 
@@ -292,19 +368,37 @@ var _ = Describe("ConcreteFactory", func() {
 	It("delete_vm", func() {
 		action, err := factory.Create("delete_vm", ctx)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewDeleteVM(vmService, registryClient)))
+		Expect(action).To(Equal(NewDeleteVM(vmService, diskService, firewallService, registryClient, false)))
 	})
 
 	It("reboot_vm", func() {
 		action, err := factory.Create("reboot_vm", ctx)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewRebootVM(vmService)))
+		Expect(action).To(Equal(NewRebootVM(vmService, false, false, 0, operation.Timeout)))
+	})
+
+	It("stop_vm", func() {
+		action, err := factory.Create("stop_vm", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewStopVM(vmService)))
+	})
+
+	It("start_vm", func() {
+		action, err := factory.Create("start_vm", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewStartVM(vmService)))
+	})
+
+	It("set_disk_metadata", func() {
+		action, err := factory.Create("set_disk_metadata", ctx)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(action).To(Equal(NewSetDiskMetadata(diskService)))
 	})
 
 	It("set_vm_metadata", func() {
 		action, err := factory.Create("set_vm_metadata", ctx)
 		Expect(err).ToNot(HaveOccurred())
-		Expect(action).To(Equal(NewSetVMMetadata(vmService)))
+		Expect(action).To(Equal(NewSetVMMetadata(vmService, firewallService, false)))
 	})
 
 	It("has_vm", func() {
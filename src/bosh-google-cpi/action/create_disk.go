@@ -7,31 +7,47 @@ import (
 	"bosh-google-cpi/google/disk_service"
 	"bosh-google-cpi/google/disk_type_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/snapshot_service"
 	"bosh-google-cpi/util"
 )
 
 type CreateDisk struct {
 	diskService     disk.Service
 	diskTypeService disktype.Service
+	snapshotService snapshot.Service
 	vmService       instance.Service
+	description     ResourceDescription
 }
 
 func NewCreateDisk(
 	diskService disk.Service,
 	diskTypeService disktype.Service,
+	snapshotService snapshot.Service,
 	vmService instance.Service,
+	description ResourceDescription,
 ) CreateDisk {
 	return CreateDisk{
 		diskService:     diskService,
 		diskTypeService: diskTypeService,
+		snapshotService: snapshotService,
 		vmService:       vmService,
+		description:     description,
 	}
 }
 
+// Run creates a disk of size MiB. GCE provisions whole GiB, so the size is
+// rounded up to the next GiB.
 func (cd CreateDisk) Run(size int, cloudProps DiskCloudProperties, vmCID VMCID) (DiskCID, error) {
 	var zone, diskType string
+	if size <= 0 {
+		return "", bosherr.Errorf("Creating disk: Invalid disk size %d MiB, it must be positive", size)
+	}
+	sizeGb := util.ConvertMib2Gib(size)
+
 	zone = cloudProps.Zone
+	var deployment string
 	// Find the VM (if provided) so we can create the disk in the same zone
+	// and describe it with the deployment set_vm_metadata labelled the VM with
 	if vmCID != "" {
 		vm, found, err := cd.vmService.Find(string(vmCID), "")
 		if err != nil {
@@ -42,6 +58,12 @@ func (cd CreateDisk) Run(size int, cloudProps DiskCloudProperties, vmCID VMCID)
 		}
 
 		zone = vm.Zone
+		deployment = vm.Labels["deployment"]
+	}
+
+	description, err := cd.description.Expand(cloudProps.Description, deployment)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating disk")
 	}
 
 	// Find the Disk Type (if provided)
@@ -57,11 +79,37 @@ func (cd CreateDisk) Run(size int, cloudProps DiskCloudProperties, vmCID VMCID)
 		diskType = dt.SelfLink
 	}
 
-	// Create the Disk
-	disk, err := cd.diskService.Create(util.ConvertMib2Gib(size), diskType, zone)
+	// Create the Disk, restoring it from the snapshot (if provided)
+	var disk string
+	if cloudProps.Snapshot != "" {
+		var snapshotLink string
+		if snapshotLink, err = cd.findSnapshotLink(cloudProps.Snapshot, sizeGb); err != nil {
+			return "", bosherr.WrapError(err, "Creating disk")
+		}
+		disk, err = cd.diskService.CreateFromSnapshot(snapshotLink, sizeGb, diskType, zone, nil, description)
+	} else {
+		disk, err = cd.diskService.Create(sizeGb, diskType, zone, nil, description)
+	}
 	if err != nil {
 		return "", bosherr.WrapError(err, "Creating disk")
 	}
 
 	return DiskCID(disk), nil
 }
+
+// findSnapshotLink finds a snapshot disks of sizeGb can be restored from.
+// Snapshots are global, so disks can be restored from them in any zone.
+func (cd CreateDisk) findSnapshotLink(snapshotID string, sizeGb int) (string, error) {
+	s, found, err := cd.snapshotService.Find(snapshotID)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", bosherr.Errorf("Snapshot '%s' does not exist", snapshotID)
+	}
+	if int64(sizeGb) < s.DiskSizeGb {
+		return "", bosherr.Errorf("Disk size %d GB is smaller than the %d GB of snapshot '%s'", sizeGb, s.DiskSizeGb, snapshotID)
+	}
+
+	return s.SelfLink, nil
+}
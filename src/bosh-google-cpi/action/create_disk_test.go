@@ -11,9 +11,11 @@ import (
 	diskfakes "bosh-google-cpi/google/disk_service/fakes"
 	disktypefakes "bosh-google-cpi/google/disk_type_service/fakes"
 	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+	snapshotfakes "bosh-google-cpi/google/snapshot_service/fakes"
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/google/disk_type_service"
+	"bosh-google-cpi/google/snapshot_service"
 
 	"google.golang.org/api/compute/v1"
 )
@@ -27,6 +29,7 @@ var _ = Describe("CreateDisk", func() {
 
 		diskService     *diskfakes.FakeDiskService
 		diskTypeService *disktypefakes.FakeDiskTypeService
+		snapshotService *snapshotfakes.FakeSnapshotService
 		vmService       *instancefakes.FakeInstanceService
 
 		createDisk CreateDisk
@@ -35,8 +38,9 @@ var _ = Describe("CreateDisk", func() {
 	BeforeEach(func() {
 		diskService = &diskfakes.FakeDiskService{}
 		diskTypeService = &disktypefakes.FakeDiskTypeService{}
+		snapshotService = &snapshotfakes.FakeSnapshotService{}
 		vmService = &instancefakes.FakeInstanceService{}
-		createDisk = NewCreateDisk(diskService, diskTypeService, vmService)
+		createDisk = NewCreateDisk(diskService, diskTypeService, snapshotService, vmService, ResourceDescription{})
 	})
 
 	Describe("Run", func() {
@@ -55,9 +59,33 @@ var _ = Describe("CreateDisk", func() {
 			Expect(diskService.CreateSize).To(Equal(32))
 			Expect(diskService.CreateDiskType).To(BeEmpty())
 			Expect(diskService.CreateZone).To(Equal("fake-default-zone"))
+			Expect(diskService.CreateLabels).To(BeEmpty())
 			Expect(diskCID).To(Equal(DiskCID("fake-disk-id")))
 		})
 
+		It("creates the disk with the default description", func() {
+			createDisk = NewCreateDisk(diskService, diskTypeService, snapshotService, vmService, ResourceDescription{Default: "fake-default-description"})
+
+			_, err = createDisk.Run(32768, cloudProps, vmCID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.CreateDescription).To(Equal("fake-default-description"))
+		})
+
+		It("rounds sizes up to the next GB", func() {
+			_, err = createDisk.Run(500, cloudProps, vmCID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.CreateSize).To(Equal(1))
+		})
+
+		It("returns an error if the size is not positive", func() {
+			for _, size := range []int{0, -1024} {
+				_, err = createDisk.Run(size, cloudProps, vmCID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("must be positive"))
+			}
+			Expect(diskService.CreateCalled).To(BeFalse())
+		})
+
 		It("returns an error if diskService create call returns an error", func() {
 			diskService.CreateErr = errors.New("fake-disk-service-error")
 
@@ -88,6 +116,16 @@ var _ = Describe("CreateDisk", func() {
 				Expect(diskCID).To(Equal(DiskCID("fake-disk-id")))
 			})
 
+			It("describes the disk with the deployment of the vm", func() {
+				vmService.FindInstance.Labels = map[string]string{"deployment": "fake-deployment"}
+				cloudProps.Description = "Disk of {deployment} managed by {director_uuid}"
+				createDisk = NewCreateDisk(diskService, diskTypeService, snapshotService, vmService, ResourceDescription{DirectorUUID: "fake-director-uuid"})
+
+				_, err = createDisk.Run(32768, cloudProps, vmCID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskService.CreateDescription).To(Equal("Disk of fake-deployment managed by fake-director-uuid"))
+			})
+
 			It("returns an error if vmService find call returns an error", func() {
 				vmService.FindErr = errors.New("fake-instance-service-error")
 
@@ -152,6 +190,54 @@ var _ = Describe("CreateDisk", func() {
 				Expect(diskService.CreateCalled).To(BeFalse())
 			})
 		})
+
+		Context("when snapshot is set", func() {
+			BeforeEach(func() {
+				cloudProps.Snapshot = "fake-snapshot-id"
+				snapshotService.FindFound = true
+				snapshotService.FindSnapshot = snapshot.Snapshot{
+					Name:       "fake-snapshot-id",
+					SelfLink:   "fake-snapshot-self-link",
+					Status:     "READY",
+					DiskSizeGb: 32,
+				}
+			})
+
+			It("restores the disk from the snapshot", func() {
+				diskCID, err = createDisk.Run(32768, cloudProps, vmCID)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskService.CreateFromSnapshotCalled).To(BeTrue())
+				Expect(diskService.CreateFromSnapshotSnapshot).To(Equal("fake-snapshot-self-link"))
+				Expect(diskService.CreateSize).To(Equal(32))
+				Expect(diskService.CreateZone).To(Equal("fake-default-zone"))
+				Expect(diskCID).To(Equal(DiskCID("fake-disk-id")))
+			})
+
+			It("returns an error if the disk is smaller than the snapshot", func() {
+				_, err = createDisk.Run(16384, cloudProps, vmCID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Disk size 16 GB is smaller than the 32 GB of snapshot 'fake-snapshot-id'"))
+				Expect(diskService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the snapshot does not exist", func() {
+				snapshotService.FindFound = false
+
+				_, err = createDisk.Run(32768, cloudProps, vmCID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Snapshot 'fake-snapshot-id' does not exist"))
+				Expect(diskService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if snapshotService find call returns an error", func() {
+				snapshotService.FindErr = errors.New("fake-snapshot-service-error")
+
+				_, err = createDisk.Run(32768, cloudProps, vmCID)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-snapshot-service-error"))
+				Expect(diskService.CreateCalled).To(BeFalse())
+			})
+		})
 	})
 
 })
@@ -0,0 +1,95 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/image_service"
+	"bosh-google-cpi/google/instance_service"
+)
+
+type CreateImageFromVM struct {
+	vmService    instance.Service
+	imageService image.Service
+	description  ResourceDescription
+}
+
+func NewCreateImageFromVM(
+	vmService instance.Service,
+	imageService image.Service,
+	description ResourceDescription,
+) CreateImageFromVM {
+	return CreateImageFromVM{
+		vmService:    vmService,
+		imageService: imageService,
+		description:  description,
+	}
+}
+
+// Run creates an image from the boot disk of a VM and returns it as a
+// stemcell. Images can only be created from the disks of stopped VMs, so a
+// running VM is stopped first and started again afterwards when stop_vm is
+// set.
+func (ci CreateImageFromVM) Run(vmCID VMCID, cloudProps ImageCloudProperties) (StemcellCID, error) {
+	if err := cloudProps.Labels.Validate(); err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating image from vm '%s'", vmCID)
+	}
+
+	// Find the VM and its boot disk
+	vm, found, err := ci.vmService.Find(string(vmCID), "")
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating image from vm '%s'", vmCID)
+	}
+	if !found {
+		return "", api.NewVMNotFoundError(string(vmCID))
+	}
+
+	var bootDisk string
+	for _, attachedDisk := range vm.Disks {
+		if attachedDisk.Boot {
+			bootDisk = attachedDisk.Source
+		}
+	}
+	if bootDisk == "" {
+		return "", bosherr.Errorf("Creating image from vm '%s': vm has no boot disk", vmCID)
+	}
+
+	description, err := ci.description.Expand(cloudProps.Description, vm.Labels["deployment"])
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating image from vm '%s'", vmCID)
+	}
+
+	// Stop the VM while its boot disk is imaged
+	restart := false
+	if vm.Status != instance.STATUS_TERMINATED {
+		if !cloudProps.StopVM {
+			return "", bosherr.Errorf("Creating image from vm '%s': vm must be stopped, or 'stop_vm' set", vmCID)
+		}
+		if err = ci.vmService.Stop(string(vmCID)); err != nil {
+			return "", bosherr.WrapErrorf(err, "Creating image from vm '%s'", vmCID)
+		}
+		restart = true
+	}
+
+	imageProps := image.Properties{
+		Family: cloudProps.Family,
+		Labels: cloudProps.Labels,
+	}
+	stemcell, err := ci.imageService.CreateFromDisk(bootDisk, description, imageProps)
+
+	// Start the VM again, even if the image could not be created
+	if restart {
+		if startErr := ci.vmService.Start(string(vmCID)); startErr != nil {
+			if err != nil {
+				return "", bosherr.WrapErrorf(err, "Creating image from vm '%s' (starting the vm again also failed: %s)", vmCID, startErr)
+			}
+			return "", bosherr.WrapErrorf(startErr, "Starting vm '%s' after creating image '%s'", vmCID, stemcell)
+		}
+	}
+
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating image from vm '%s'", vmCID)
+	}
+
+	return StemcellCID(stemcell), nil
+}
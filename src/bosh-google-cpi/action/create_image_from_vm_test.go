@@ -0,0 +1,152 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	"bosh-google-cpi/api"
+
+	imagefakes "bosh-google-cpi/google/image_service/fakes"
+	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+
+	"bosh-google-cpi/google/image_service"
+	"bosh-google-cpi/google/instance_service"
+
+	"google.golang.org/api/compute/v1"
+)
+
+var _ = Describe("CreateImageFromVM", func() {
+	var (
+		err        error
+		stemcell   StemcellCID
+		cloudProps ImageCloudProperties
+
+		vmService    *instancefakes.FakeInstanceService
+		imageService *imagefakes.FakeImageService
+
+		createImageFromVM CreateImageFromVM
+	)
+
+	BeforeEach(func() {
+		vmService = &instancefakes.FakeInstanceService{}
+		imageService = &imagefakes.FakeImageService{}
+		createImageFromVM = NewCreateImageFromVM(vmService, imageService, ResourceDescription{})
+
+		vmService.FindFound = true
+		vmService.FindInstance = &compute.Instance{
+			Name:   "fake-vm-id",
+			Status: "TERMINATED",
+			Disks: []*compute.AttachedDisk{
+				{Source: "fake-persistent-disk-self-link"},
+				{Source: "fake-boot-disk-self-link", Boot: true},
+			},
+		}
+		imageService.CreateFromDiskID = "fake-image"
+
+		cloudProps = ImageCloudProperties{
+			Family:      "fake-family",
+			Labels:      instance.Labels{"fake-key": "fake-value"},
+			Description: "fake-description",
+		}
+	})
+
+	Describe("Run", func() {
+		It("creates the image from the boot disk of a stopped vm", func() {
+			stemcell, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stemcell).To(Equal(StemcellCID("fake-image")))
+			Expect(imageService.CreateFromDiskCalled).To(BeTrue())
+			Expect(imageService.CreateFromDiskDiskLink).To(Equal("fake-boot-disk-self-link"))
+			Expect(imageService.CreateFromDiskDescription).To(Equal("fake-description"))
+			Expect(imageService.CreateFromDiskProperties).To(Equal(image.Properties{
+				Family: "fake-family",
+				Labels: map[string]string{"fake-key": "fake-value"},
+			}))
+			Expect(vmService.StopCalled).To(BeFalse())
+			Expect(vmService.StartCalled).To(BeFalse())
+		})
+
+		Context("when the vm is running", func() {
+			BeforeEach(func() {
+				vmService.FindInstance.Status = "RUNNING"
+			})
+
+			It("stops the vm and starts it again when stop_vm is set", func() {
+				cloudProps.StopVM = true
+
+				stemcell, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(stemcell).To(Equal(StemcellCID("fake-image")))
+				Expect(vmService.StopCalled).To(BeTrue())
+				Expect(imageService.CreateFromDiskCalled).To(BeTrue())
+				Expect(vmService.StartCalled).To(BeTrue())
+			})
+
+			It("starts the vm again if the image can not be created", func() {
+				cloudProps.StopVM = true
+				imageService.CreateFromDiskErr = errors.New("fake-image-service-error")
+
+				_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-image-service-error"))
+				Expect(vmService.StartCalled).To(BeTrue())
+			})
+
+			It("returns an error if the vm can not be started again", func() {
+				cloudProps.StopVM = true
+				vmService.StartErr = errors.New("fake-vm-service-error")
+
+				_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			})
+
+			It("returns an error if the vm can not be stopped", func() {
+				cloudProps.StopVM = true
+				vmService.StopErr = errors.New("fake-vm-service-error")
+
+				_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(imageService.CreateFromDiskCalled).To(BeFalse())
+				Expect(vmService.StartCalled).To(BeFalse())
+			})
+
+			It("returns an error if stop_vm is not set", func() {
+				_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("vm must be stopped, or 'stop_vm' set"))
+				Expect(vmService.StopCalled).To(BeFalse())
+				Expect(imageService.CreateFromDiskCalled).To(BeFalse())
+			})
+		})
+
+		It("returns the cloud error if the vm does not exist", func() {
+			vmService.FindFound = false
+
+			_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+			Expect(err).To(Equal(api.NewVMNotFoundError("fake-vm-id")))
+			Expect(imageService.CreateFromDiskCalled).To(BeFalse())
+		})
+
+		It("returns an error if the vm has no boot disk", func() {
+			vmService.FindInstance.Disks = nil
+
+			_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("vm has no boot disk"))
+		})
+
+		It("returns an error if the labels are invalid", func() {
+			cloudProps.Labels = instance.Labels{"Fake-Key": "fake-value"}
+
+			_, err = createImageFromVM.Run("fake-vm-id", cloudProps)
+			Expect(err).To(HaveOccurred())
+			Expect(vmService.FindCalled).To(BeFalse())
+		})
+	})
+})
@@ -11,14 +11,23 @@ import (
 const googleInfrastructure = "google"
 
 type CreateStemcell struct {
-	imageService image.Service
+	imageService     image.Service
+	directorUUID     string
+	deprecationState string
+	description      ResourceDescription
 }
 
 func NewCreateStemcell(
 	imageService image.Service,
+	directorUUID string,
+	deprecationState string,
+	description ResourceDescription,
 ) CreateStemcell {
 	return CreateStemcell{
-		imageService: imageService,
+		imageService:     imageService,
+		directorUUID:     directorUUID,
+		deprecationState: deprecationState,
+		description:      description,
 	}
 }
 
@@ -30,17 +39,48 @@ func (cs CreateStemcell) Run(stemcellPath string, cloudProps StemcellCloudProper
 		return "", bosherr.Errorf("Creating stemcell: Invalid '%s' infrastructure", cloudProps.Infrastructure)
 	}
 
-	if cloudProps.Name != "" && cloudProps.Version != "" {
+	// Stemcells belong to no deployment
+	description, err = cs.description.Expand("", "")
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating stemcell")
+	}
+	if description == "" && cloudProps.Name != "" && cloudProps.Version != "" {
 		description = fmt.Sprintf("%s/%s", cloudProps.Name, cloudProps.Version)
 	}
 
+	imageProps := image.Properties{
+		GuestOsFeatures: cloudProps.GuestOsFeatures,
+		Licenses:        cloudProps.Licenses,
+		Family:          cloudProps.Family,
+	}
+	if cloudProps.Family != "" {
+		imageProps.DeprecationState = cs.deprecationState
+	}
+	if cloudProps.GPUDriver {
+		imageProps.Labels = map[string]string{image.GPUDriverLabelKey: "true"}
+	}
+	if err = imageProps.Validate(); err != nil {
+		return "", bosherr.WrapError(err, "Creating stemcell")
+	}
+
 	switch {
 	case cloudProps.ImageURL != "":
+		// The features and licenses of existing images cannot be changed
+		if len(imageProps.GuestOsFeatures) > 0 || len(imageProps.Licenses) > 0 {
+			return "", bosherr.Error("Creating stemcell: 'guest_os_features' and 'licenses' cannot be used with 'image_url'")
+		}
+		if cloudProps.GPUDriver {
+			return "", bosherr.Error("Creating stemcell: 'gpu_driver' cannot be used with 'image_url'")
+		}
+		if cloudProps.Family != "" {
+			return "", bosherr.Error("Creating stemcell: 'family' cannot be used with 'image_url'")
+		}
 		stemcell = cloudProps.ImageURL
 	case cloudProps.SourceURL != "":
-		stemcell, err = cs.imageService.CreateFromURL(cloudProps.SourceURL, cloudProps.SourceSha1, description)
+		stemcell, err = cs.imageService.CreateFromURL(cloudProps.SourceURL, cloudProps.SourceSha1, description, imageProps)
 	default:
-		stemcell, err = cs.imageService.CreateFromTarball(stemcellPath, description)
+		imageProps.TarballMetadata = cs.tarballMetadata(cloudProps)
+		stemcell, err = cs.imageService.CreateFromTarball(stemcellPath, description, imageProps)
 	}
 	if err != nil {
 		return "", bosherr.WrapError(err, "Creating stemcell")
@@ -48,3 +88,19 @@ func (cs CreateStemcell) Run(stemcellPath string, cloudProps StemcellCloudProper
 
 	return StemcellCID(stemcell), nil
 }
+
+// tarballMetadata records the stemcell and the director the uploaded tarball
+// comes from.
+func (cs CreateStemcell) tarballMetadata(cloudProps StemcellCloudProperties) map[string]string {
+	metadata := map[string]string{}
+	for k, v := range map[string]string{
+		image.TarballStemcellNameKey:    cloudProps.Name,
+		image.TarballStemcellVersionKey: cloudProps.Version,
+		image.TarballDirectorUUIDKey:    cs.directorUUID,
+	} {
+		if v != "" {
+			metadata[k] = v
+		}
+	}
+	return metadata
+}
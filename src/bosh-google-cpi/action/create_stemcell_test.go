@@ -8,6 +8,7 @@ import (
 
 	. "bosh-google-cpi/action"
 
+	"bosh-google-cpi/google/image_service"
 	imagefakes "bosh-google-cpi/google/image_service/fakes"
 )
 
@@ -23,7 +24,7 @@ var _ = Describe("CreateStemcell", func() {
 
 	BeforeEach(func() {
 		imageService = &imagefakes.FakeImageService{}
-		createStemcell = NewCreateStemcell(imageService)
+		createStemcell = NewCreateStemcell(imageService, "fake-director-uuid", "", ResourceDescription{})
 	})
 
 	Describe("Run", func() {
@@ -67,6 +68,22 @@ var _ = Describe("CreateStemcell", func() {
 				Expect(imageService.CreateFromURLDescription).To(Equal("fake-stemcell-name/fake-stemcell-version"))
 			})
 
+			It("sets the guest OS features", func() {
+				cloudProps.GuestOsFeatures = []string{"VIRTIO_SCSI_MULTIQUEUE"}
+
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.CreateFromURLProperties.GuestOsFeatures).To(Equal([]string{"VIRTIO_SCSI_MULTIQUEUE"}))
+			})
+
+			It("labels the image when the stemcell ships the GPU driver", func() {
+				cloudProps.GPUDriver = true
+
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.CreateFromURLProperties.Labels).To(Equal(map[string]string{image.GPUDriverLabelKey: "true"}))
+			})
+
 			It("returns an error if imageService create from tarball call returns an error", func() {
 				imageService.CreateFromURLErr = errors.New("fake-image-service-error")
 
@@ -93,6 +110,65 @@ var _ = Describe("CreateStemcell", func() {
 				Expect(imageService.CreateFromTarballDescription).To(Equal("fake-stemcell-name/fake-stemcell-version"))
 			})
 
+			It("sets the guest OS features and licenses", func() {
+				cloudProps.GuestOsFeatures = []string{"UEFI_COMPATIBLE", "GVNIC"}
+				cloudProps.Licenses = []string{"https://www.googleapis.com/compute/v1/projects/fake-project/global/licenses/fake-license"}
+
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.CreateFromTarballProperties.GuestOsFeatures).To(Equal([]string{"UEFI_COMPATIBLE", "GVNIC"}))
+				Expect(imageService.CreateFromTarballProperties.Licenses).To(Equal([]string{"https://www.googleapis.com/compute/v1/projects/fake-project/global/licenses/fake-license"}))
+			})
+
+			It("records the stemcell and the director in the tarball metadata", func() {
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.CreateFromTarballProperties.TarballMetadata).To(Equal(map[string]string{
+					image.TarballStemcellNameKey:    "fake-stemcell-name",
+					image.TarballStemcellVersionKey: "fake-stemcell-version",
+					image.TarballDirectorUUIDKey:    "fake-director-uuid",
+				}))
+			})
+
+			It("creates the image in the stemcell family without deprecating by default", func() {
+				cloudProps.Family = "fake-family"
+
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.CreateFromTarballProperties.Family).To(Equal("fake-family"))
+				Expect(imageService.CreateFromTarballProperties.DeprecationState).To(BeEmpty())
+			})
+
+			Context("when a deprecation state is set", func() {
+				BeforeEach(func() {
+					createStemcell = NewCreateStemcell(imageService, "fake-director-uuid", "OBSOLETE", ResourceDescription{})
+				})
+
+				It("applies it to the previous image of the stemcell family", func() {
+					cloudProps.Family = "fake-family"
+
+					_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(imageService.CreateFromTarballProperties.Family).To(Equal("fake-family"))
+					Expect(imageService.CreateFromTarballProperties.DeprecationState).To(Equal("OBSOLETE"))
+				})
+
+				It("does not apply it without a stemcell family", func() {
+					_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(imageService.CreateFromTarballProperties.DeprecationState).To(BeEmpty())
+				})
+			})
+
+			It("returns an error if a guest OS feature is unknown", func() {
+				cloudProps.GuestOsFeatures = []string{"UEFI_COMPATIBLE", "FAKE_FEATURE"}
+
+				_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Unknown guest OS feature 'FAKE_FEATURE'"))
+				Expect(imageService.CreateFromTarballCalled).To(BeFalse())
+			})
+
 			It("returns an error if imageService create from tarball call returns an error", func() {
 				imageService.CreateFromTarballErr = errors.New("fake-stemcell-service-error")
 
@@ -107,7 +183,10 @@ var _ = Describe("CreateStemcell", func() {
 
 	Context("from a image url", func() {
 		BeforeEach(func() {
-			cloudProps.ImageURL = "fake-image-url"
+			cloudProps = StemcellCloudProperties{
+				Infrastructure: "google",
+				ImageURL:       "fake-image-url",
+			}
 		})
 
 		It("creates the stemcell", func() {
@@ -117,5 +196,29 @@ var _ = Describe("CreateStemcell", func() {
 			Expect(imageService.CreateFromURLCalled).To(BeFalse())
 			Expect(stemcellCID).To(Equal(StemcellCID(cloudProps.ImageURL)))
 		})
+
+		It("returns an error if guest OS features are set", func() {
+			cloudProps.GuestOsFeatures = []string{"UEFI_COMPATIBLE"}
+
+			_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'guest_os_features' and 'licenses' cannot be used with 'image_url'"))
+		})
+
+		It("returns an error if the GPU driver is set", func() {
+			cloudProps.GPUDriver = true
+
+			_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'gpu_driver' cannot be used with 'image_url'"))
+		})
+
+		It("returns an error if the family is set", func() {
+			cloudProps.Family = "fake-family"
+
+			_, err = createStemcell.Run("fake-stemcell-tarball", cloudProps)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'family' cannot be used with 'image_url'"))
+		})
 	})
 })
@@ -2,6 +2,7 @@ package action
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
@@ -11,7 +12,9 @@ import (
 	"bosh-google-cpi/google/disk_type_service"
 	"bosh-google-cpi/google/image_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/instance_template_service"
 	"bosh-google-cpi/google/machine_type_service"
+	"bosh-google-cpi/google/zone_service"
 	"bosh-google-cpi/util"
 
 	"bosh-google-cpi/google/accelerator_type_service"
@@ -19,17 +22,24 @@ import (
 )
 
 type CreateVM struct {
-	vmService              instance.Service
-	diskService            disk.Service
-	diskTypeService        disktype.Service
-	imageService           image.Service
-	machineTypeService     machinetype.Service
-	acceleratorTypeService acceleratortype.Service
-	registryClient         registry.Client
-	registryOptions        registry.ClientOptions
-	agentOptions           registry.AgentOptions
-	defaultRootDiskSizeGb  int
-	defaultRootDiskType    string
+	vmService               instance.Service
+	diskService             disk.Service
+	diskTypeService         disktype.Service
+	imageService            image.Service
+	machineTypeService      machinetype.Service
+	acceleratorTypeService  acceleratortype.Service
+	instanceTemplateService instancetemplate.Service
+	zoneService             zone.Service
+	registryClient          registry.Client
+	registryOptions         registry.ClientOptions
+	agentOptions            registry.AgentOptions
+	defaultRootDiskSizeGb   int
+	defaultRootDiskType     string
+	defaultServiceScopes    []string
+	defaultNetworkTier      string
+	defaultTags             []string
+	disableExternalIP       bool
+	description             ResourceDescription
 }
 
 func NewCreateVM(
@@ -39,56 +49,59 @@ func NewCreateVM(
 	imageService image.Service,
 	machineTypeService machinetype.Service,
 	acceleratorTypeService acceleratortype.Service,
+	instanceTemplateService instancetemplate.Service,
+	zoneService zone.Service,
 	registryClient registry.Client,
 	registryOptions registry.ClientOptions,
 	agentOptions registry.AgentOptions,
 	defaultRootDiskSizeGb int,
 	defaultRootDiskType string,
+	defaultServiceScopes []string,
+	defaultNetworkTier string,
+	defaultTags []string,
+	disableExternalIP bool,
+	description ResourceDescription,
 ) CreateVM {
 	return CreateVM{
-		vmService:              vmService,
-		diskService:            diskService,
-		diskTypeService:        diskTypeService,
-		imageService:           imageService,
-		machineTypeService:     machineTypeService,
-		acceleratorTypeService: acceleratorTypeService,
-		registryClient:         registryClient,
-		registryOptions:        registryOptions,
-		agentOptions:           agentOptions,
-		defaultRootDiskSizeGb:  defaultRootDiskSizeGb,
-		defaultRootDiskType:    defaultRootDiskType,
+		vmService:               vmService,
+		diskService:             diskService,
+		diskTypeService:         diskTypeService,
+		imageService:            imageService,
+		machineTypeService:      machineTypeService,
+		acceleratorTypeService:  acceleratorTypeService,
+		instanceTemplateService: instanceTemplateService,
+		zoneService:             zoneService,
+		registryClient:          registryClient,
+		registryOptions:         registryOptions,
+		agentOptions:            agentOptions,
+		defaultRootDiskSizeGb:   defaultRootDiskSizeGb,
+		defaultRootDiskType:     defaultRootDiskType,
+		defaultServiceScopes:    defaultServiceScopes,
+		defaultNetworkTier:      defaultNetworkTier,
+		defaultTags:             defaultTags,
+		disableExternalIP:       disableExternalIP,
+		description:             description,
 	}
 }
 
 func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMCloudProperties, networks Networks, disks []DiskCID, env Environment) (VMCID, error) {
-	// Find zone
-	zone, err := cv.findZone(cloudProps.Zone, disks)
+	// Find the zones to try, in order
+	zones, err := cv.findZones(cloudProps.Zone, cloudProps.Region, disks)
 	if err != nil {
 		return "", err
 	}
 
 	// Find stemcell
-	stemcellLink, err := cv.findStemcellLink(string(stemcellCID))
+	stemcell, err := cv.findStemcell(string(stemcellCID), cloudProps.ImageFamily)
 	if err != nil {
 		return "", err
 	}
 
-	// Find machine type
-	machineTypeLink, err := cv.findMachineTypeLink(cloudProps, zone)
-	if err != nil {
-		return "", err
-	}
-
-	// Find the root Disk Type
-	rootDiskTypeLink, err := cv.findRootDiskTypeLink(cloudProps.RootDiskType, zone)
-	if err != nil {
-		return "", err
-	}
-
-	// Find Accelerator Type
-	acceleratorTypeLinks, err := cv.findAcceleratorTypeLinks(cloudProps.Accelerators, zone)
-	if err != nil {
-		return "", err
+	// Accelerators are useless without the GPU driver. Images given by URL
+	// are not looked up, so they cannot be checked.
+	imageLookedUp := cloudProps.ImageFamily != "" || !isGcpImageURL(string(stemcellCID))
+	if len(cloudProps.Accelerators) > 0 && !cloudProps.ForceGPU && imageLookedUp && !stemcell.GPUReady() {
+		return "", bosherr.Errorf("Creating vm: Image '%s' does not ship the GPU driver required by 'accelerators', set 'force_gpu' to attach them anyway", stemcell.Name)
 	}
 
 	// Parse networks
@@ -97,6 +110,13 @@ func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMClo
 		return "", bosherr.WrapError(err, "Creating VM")
 	}
 
+	// With external IPs disabled, VMs only get an ephemeral external IP when
+	// their cloud properties ask for one, so a network asking for one is a
+	// mistake. A vip network still attaches its external IP.
+	if cv.disableExternalIP && cloudProps.EphemeralExternalIP == nil && vmNetworks.Network().EphemeralExternalIP {
+		return "", bosherr.Error("Creating VM: Network property 'ephemeral_external_ip' can not be set when 'disable_external_ip' is enabled, set it in the VM cloud properties instead")
+	}
+
 	// Certain properties defined in the Networks section of a manifest can be
 	// overridden by VM properties. Here, we see if any of the VM properties
 	// have been set and should override Network settings
@@ -107,6 +127,15 @@ func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMClo
 		vmNetworks.Network().EphemeralExternalIP = *cloudProps.EphemeralExternalIP
 	}
 
+	// The default network tier applies unless the network sets one, while
+	// the default tags are added to the VM tags
+	if vmNetworks.Network().NetworkTier == "" {
+		vmNetworks.Network().NetworkTier = cv.defaultNetworkTier
+	}
+	if len(cv.defaultTags) > 0 {
+		cloudProps.Tags = append(append(instance.Tags{}, cv.defaultTags...), cloudProps.Tags...)
+	}
+
 	// Extract any tags from env.bosh.groups
 	if boshenv, ok := env["bosh"]; ok {
 		if boshgroups, ok := boshenv.(map[string]interface{})["groups"]; ok {
@@ -123,34 +152,42 @@ func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMClo
 		return "", bosherr.WrapError(err, "Creating VM")
 	}
 
+	description, err := cv.description.Expand(cloudProps.Description, envDeployment(env))
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating VM")
+	}
+
 	bs, err := parseBackendService(cloudProps.BackendService)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Parsing BackendService %#v", cloudProps.BackendService)
 	}
 
-	// Parse VM properties
-	vmProps := &instance.Properties{
-		Zone:              zone,
-		Name:              cloudProps.Name,
-		Stemcell:          stemcellLink,
-		MachineType:       machineTypeLink,
-		RootDiskSizeGb:    cv.findRootDiskSizeGb(cloudProps.RootDiskSizeGb),
-		RootDiskType:      rootDiskTypeLink,
-		AutomaticRestart:  cloudProps.AutomaticRestart,
-		OnHostMaintenance: cloudProps.OnHostMaintenance,
-		Preemptible:       cloudProps.Preemptible,
-		ServiceAccount:    instance.ServiceAccount(cloudProps.ServiceAccount),
-		ServiceScopes:     instance.ServiceScopes(cloudProps.ServiceScopes),
-		TargetPool:        cloudProps.TargetPool,
-		BackendService:    bs,
-		Tags:              cloudProps.Tags,
-		Labels:            cloudProps.Labels,
-		Accelerators:      acceleratorTypeLinks,
+	startupScript, err := parseStartupScript(cloudProps.StartupScript)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Parsing StartupScript")
 	}
 
-	// Create VM
-	vm, err := cv.vmService.Create(vmProps, vmNetworks, cv.registryOptions.EndpointWithCredentials())
-	if err != nil {
+	// Create VM, moving to the next zone if a zone runs out of resources or
+	// does not offer one of them
+	var vm string
+	for i, zone := range zones {
+		var vmProps *instance.Properties
+		vmProps, err = cv.findVMProperties(zone, stemcell.SelfLink, cloudProps, bs, startupScript)
+		if err != nil {
+			if _, ok := err.(zoneResourceNotFoundError); ok && i < len(zones)-1 {
+				continue
+			}
+			return "", err
+		}
+		vmProps.Description = description
+
+		vm, err = cv.vmService.Create(vmProps, vmNetworks, cv.registryOptions.EndpointWithCredentials())
+		if err == nil {
+			break
+		}
+		if instance.IsZoneStockoutError(err) && i < len(zones)-1 {
+			continue
+		}
 		if _, ok := err.(api.CloudError); ok {
 			return "", err
 		}
@@ -167,6 +204,9 @@ func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMClo
 	// Create VM settings
 	agentNetworks := networks.AsRegistryNetworks()
 	agentSettings := registry.NewAgentSettings(agentID, vm, agentNetworks, registry.EnvSettings(env), cv.agentOptions)
+	if cloudProps.EphemeralDisk != nil {
+		agentSettings.Disks.Ephemeral = instance.EphemeralDiskDevicePath
+	}
 	if err = cv.registryClient.Update(vm, agentSettings); err != nil {
 		return "", bosherr.WrapErrorf(err, "Creating VM")
 	}
@@ -174,6 +214,86 @@ func (cv CreateVM) Run(agentID string, stemcellCID StemcellCID, cloudProps VMClo
 	return VMCID(vm), nil
 }
 
+// zoneResourceNotFoundError is returned when a zonal resource of the VM, such
+// as its machine type, is not available in a zone. The VM may still be
+// created in another zone.
+type zoneResourceNotFoundError struct {
+	message string
+}
+
+func newZoneResourceNotFoundError(format string, args ...interface{}) zoneResourceNotFoundError {
+	return zoneResourceNotFoundError{message: fmt.Sprintf(format, args...)}
+}
+
+func (e zoneResourceNotFoundError) Error() string { return e.message }
+
+// findVMProperties resolves the zonal resources of the VM in zone
+func (cv CreateVM) findVMProperties(zone string, stemcellLink string, cloudProps VMCloudProperties, bs instance.BackendService, startupScript instance.StartupScript) (*instance.Properties, error) {
+	// Find instance template
+	template, err := cv.findInstanceTemplate(cloudProps.SourceInstanceTemplate, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find machine type
+	machineTypeLink, err := cv.findMachineTypeLink(cloudProps, zone, template)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the root Disk Type
+	rootDiskTypeLink, err := cv.findRootDiskTypeLink(cloudProps.RootDiskType, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find the ephemeral disk
+	ephemeralDisk, err := cv.findEphemeralDisk(cloudProps.EphemeralDisk, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Find Accelerator Type
+	acceleratorTypeLinks, err := cv.findAcceleratorTypeLinks(cloudProps.Accelerators, zone)
+	if err != nil {
+		return nil, err
+	}
+
+	// Parse VM properties
+	vmProps := &instance.Properties{
+		Zone:              zone,
+		Name:              cloudProps.Name,
+		Stemcell:          stemcellLink,
+		MachineType:       machineTypeLink,
+		RootDiskSizeGb:    cv.findRootDiskSizeGb(cloudProps.RootDiskSizeGb),
+		RootDiskType:      rootDiskTypeLink,
+		RootDeviceName:    cloudProps.RootDeviceName,
+		RootDiskInterface: cloudProps.DiskInterface,
+		EphemeralDisk:     ephemeralDisk,
+		AutomaticRestart:  cloudProps.AutomaticRestart,
+		OnHostMaintenance: cloudProps.OnHostMaintenance,
+		Preemptible:       cloudProps.Preemptible,
+		ServiceAccount:    instance.ServiceAccount(cloudProps.ServiceAccount),
+		ServiceScopes:     cv.findServiceScopes(cloudProps.ServiceScopes, template),
+		TargetPool:        cloudProps.TargetPool,
+		BackendService:    bs,
+		Tags:              cloudProps.Tags,
+		Labels:            cloudProps.Labels,
+		Accelerators:      acceleratorTypeLinks,
+		StartupScript:     startupScript,
+
+		EnableGuestAttributes:  cloudProps.EnableGuestAttributes,
+		EnableOSLogin:          cloudProps.EnableOSLogin,
+		SSHKeys:                cloudProps.SSHKeys,
+		RebootMethod:           cloudProps.RebootMethod,
+		RootDiskKmsKeyName:     cloudProps.KmsKeyName,
+		SourceImageKmsKeyName:  cloudProps.SourceImageKmsKeyName,
+		SourceInstanceTemplate: template,
+	}
+
+	return vmProps, nil
+}
+
 func extract(src map[string]interface{}, key string) string {
 	if src[key] != nil {
 		if val, ok := src[key].(string); ok {
@@ -212,6 +332,88 @@ func parseBackendService(backendService interface{}) (instance.BackendService, e
 	return bs, nil
 }
 
+func isGcsURL(s string) bool {
+	return strings.HasPrefix(s, "gs://") || strings.HasPrefix(s, "https://storage.googleapis.com/")
+}
+
+func parseStartupScript(startupScript interface{}) (instance.StartupScript, error) {
+	if startupScript == nil {
+		return instance.StartupScript{}, nil
+	}
+
+	// startup_script: <inline script or GCS URL>
+	if script, ok := startupScript.(string); ok {
+		if isGcsURL(script) {
+			return instance.StartupScript{URL: script}, nil
+		}
+		return instance.StartupScript{Inline: script}, nil
+	}
+
+	//  startup_script:
+	//    inline: <script>
+	//    url: <GCS URL>
+	ss := instance.StartupScript{}
+	if ssMap, ok := startupScript.(map[string]string); ok {
+		ss.Inline = ssMap["inline"]
+		ss.URL = ssMap["url"]
+	} else if ssMap, ok := startupScript.(map[string]interface{}); ok {
+		ss.Inline = extract(ssMap, "inline")
+		ss.URL = extract(ssMap, "url")
+	} else {
+		return ss, bosherr.Errorf("unexpected type %T", startupScript)
+	}
+
+	if ss.Inline != "" && ss.URL != "" {
+		return ss, bosherr.Error("'inline' and 'url' are mutually exclusive")
+	}
+	if ss.Inline == "" && ss.URL == "" {
+		return ss, bosherr.Error("expected key: inline or url")
+	}
+	if ss.URL != "" && !isGcsURL(ss.URL) {
+		return ss, bosherr.Errorf("'url' must be a Google Cloud Storage URL, got %q", ss.URL)
+	}
+
+	return ss, nil
+}
+
+// findZones returns the zones the VM can be created in, in the order they
+// should be tried. A zone is selected at random among the zones of the region
+// unless the zone is set or implied by the disks.
+func (cv CreateVM) findZones(zoneName string, regionName string, disks []DiskCID) ([]string, error) {
+	if zoneName == "" && len(disks) == 0 && regionName != "" {
+		regionZones, err := cv.zoneService.FindByRegion(regionName)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Creating vm")
+		}
+		if len(regionZones) == 0 {
+			return nil, bosherr.Errorf("Creating vm: no zones available in region '%s'", regionName)
+		}
+
+		var zones []string
+		for _, i := range rand.Perm(len(regionZones)) {
+			zones = append(zones, regionZones[i].Name)
+		}
+		return zones, nil
+	}
+
+	zone, err := cv.findZone(zoneName, disks)
+	if err != nil {
+		return nil, err
+	}
+
+	if regionName != "" {
+		region, err := util.RegionFromZone(zone)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Creating vm")
+		}
+		if region != regionName {
+			return nil, bosherr.Errorf("Creating vm: zone '%s' is not in region '%s'", zone, regionName)
+		}
+	}
+
+	return []string{zone}, nil
+}
+
 func (cv CreateVM) findZone(zoneName string, disks []DiskCID) (string, error) {
 	zones := make(map[string]struct{})
 	if zoneName != "" {
@@ -244,23 +446,75 @@ func isGcpImageURL(s string) bool {
 	return strings.HasPrefix(s, "https://www.googleapis.com/compute/v1/projects/")
 }
 
-func (cv CreateVM) findStemcellLink(stemcellID string) (string, error) {
+// findStemcellLink finds the stemcell image, or the latest image of the image
+// family when one is set.
+func (cv CreateVM) findStemcell(stemcellID string, imageFamily string) (image.Image, error) {
+	if imageFamily != "" {
+		stemcell, found, err := cv.imageService.FindByFamily(imageFamily)
+		if err != nil {
+			return image.Image{}, bosherr.WrapError(err, "Creating vm")
+		}
+		if !found {
+			return image.Image{}, bosherr.Errorf("Creating vm: Image family '%s' does not exists or has no available images", imageFamily)
+		}
+
+		return stemcell, nil
+	}
+
 	if isGcpImageURL(stemcellID) {
-		return stemcellID, nil
+		return image.Image{SelfLink: stemcellID}, nil
 	}
 	stemcell, found, err := cv.imageService.Find(stemcellID)
 	if err != nil {
-		return "", bosherr.WrapError(err, "Creating vm")
+		return image.Image{}, bosherr.WrapError(err, "Creating vm")
 	}
 	if !found {
-		return "", bosherr.WrapErrorf(err, "Creating vm: Stemcell '%s' does not exists", stemcellID)
+		return image.Image{}, bosherr.WrapErrorf(err, "Creating vm: Stemcell '%s' does not exists", stemcellID)
 	}
 
-	return stemcell.SelfLink, nil
+	return stemcell, nil
 }
 
-func (cv CreateVM) findMachineTypeLink(cloudProps VMCloudProperties, zone string) (string, error) {
+func (cv CreateVM) findInstanceTemplate(name string, zone string) (*instancetemplate.InstanceTemplate, error) {
+	if name == "" {
+		return nil, nil
+	}
+
+	template, found, err := cv.instanceTemplateService.Find(name)
+	if err != nil {
+		return nil, bosherr.WrapError(err, "Creating vm")
+	}
+	if !found {
+		return nil, bosherr.WrapErrorf(err, "Creating vm: Instance Template '%s' does not exists", name)
+	}
+
+	if err := template.ValidateZone(zone); err != nil {
+		return nil, bosherr.WrapError(err, "Creating vm")
+	}
+
+	return &template, nil
+}
+
+func (cv CreateVM) findMachineTypeLink(cloudProps VMCloudProperties, zone string, template *instancetemplate.InstanceTemplate) (string, error) {
 	machineTypeLink := ""
+
+	// The machine type of the template is used unless one is provided, but
+	// it is not available in every zone
+	if template != nil && cloudProps.MachineType == "" && cloudProps.CPU == 0 && cloudProps.RAM == 0 {
+		if template.MachineType == "" {
+			return "", nil
+		}
+
+		_, found, err := cv.machineTypeService.Find(template.MachineType, zone)
+		if err != nil {
+			return "", bosherr.WrapError(err, "Creating vm")
+		}
+		if !found {
+			return "", newZoneResourceNotFoundError("Creating vm: Machine Type '%s' of Instance Template '%s' does not exists in zone '%s'", template.MachineType, template.Name, zone)
+		}
+		return "", nil
+	}
+
 	if cloudProps.MachineType != "" {
 		if cloudProps.CPU != 0 || cloudProps.RAM != 0 {
 			return "", bosherr.Error("Creating vm: 'machine_type' and 'cpu' or 'ram' cannot be provided together")
@@ -271,7 +525,7 @@ func (cv CreateVM) findMachineTypeLink(cloudProps VMCloudProperties, zone string
 			return "", bosherr.WrapError(err, "Creating vm")
 		}
 		if !found {
-			return "", bosherr.WrapErrorf(err, "Creating vm: Machine Type '%s' does not exists", cloudProps.MachineType)
+			return "", newZoneResourceNotFoundError("Creating vm: Machine Type '%s' does not exists in zone '%s'", cloudProps.MachineType, zone)
 		}
 		machineTypeLink = machineType.SelfLink
 	} else {
@@ -305,8 +559,11 @@ func (cv CreateVM) findRootDiskTypeLink(diskTypeName string, zone string) (strin
 		if err != nil {
 			return "", bosherr.WrapError(err, "Creating vm")
 		}
+		if !found && diskTypeName == "" {
+			return "", newZoneResourceNotFoundError("Creating vm: Default Root Disk Type '%s' does not exists in zone '%s'", diskType, zone)
+		}
 		if !found {
-			return "", bosherr.WrapErrorf(err, "Creating vm: Root Disk Type '%s' does not exists", diskTypeName)
+			return "", newZoneResourceNotFoundError("Creating vm: Root Disk Type '%s' does not exists in zone '%s'", diskTypeName, zone)
 		}
 
 		return dt.SelfLink, nil
@@ -314,6 +571,45 @@ func (cv CreateVM) findRootDiskTypeLink(diskTypeName string, zone string) (strin
 
 	return "", nil
 }
+
+func (cv CreateVM) findEphemeralDisk(ephemeralDisk *EphemeralDisk, zone string) (*instance.EphemeralDisk, error) {
+	if ephemeralDisk == nil {
+		return nil, nil
+	}
+
+	if ephemeralDisk.Size <= 0 {
+		return nil, bosherr.Error("Creating vm: 'ephemeral_disk' must have a size")
+	}
+
+	diskTypeLink := ""
+	if ephemeralDisk.Type != "" {
+		dt, found, err := cv.diskTypeService.Find(ephemeralDisk.Type, zone)
+		if err != nil {
+			return nil, bosherr.WrapError(err, "Creating vm")
+		}
+		if !found {
+			return nil, newZoneResourceNotFoundError("Creating vm: Ephemeral Disk Type '%s' does not exists in zone '%s'", ephemeralDisk.Type, zone)
+		}
+		diskTypeLink = dt.SelfLink
+	}
+
+	return &instance.EphemeralDisk{
+		SizeGb:   util.ConvertMib2Gib(ephemeralDisk.Size),
+		DiskType: diskTypeLink,
+	}, nil
+}
+
+// findServiceScopes returns the default service scopes when the cloud
+// properties do not set any. An explicitly empty list means no scopes, and
+// VMs created from an instance template get the template service account.
+func (cv CreateVM) findServiceScopes(serviceScopes VMServiceScopes, template *instancetemplate.InstanceTemplate) instance.ServiceScopes {
+	if serviceScopes == nil && template == nil && len(cv.defaultServiceScopes) > 0 {
+		return instance.ServiceScopes(cv.defaultServiceScopes)
+	}
+
+	return instance.ServiceScopes(serviceScopes)
+}
+
 func (cv CreateVM) findAcceleratorTypeLinks(accelerators []Accelerator, zone string) ([]instance.Accelerator, error) {
 	if len(accelerators) == 0 {
 		return nil, nil
@@ -321,13 +617,25 @@ func (cv CreateVM) findAcceleratorTypeLinks(accelerators []Accelerator, zone str
 
 	acceleratorLinkTypes := []instance.Accelerator{}
 
+	seen := map[string]bool{}
 	for _, acc := range accelerators {
+		if seen[acc.AcceleratorType] {
+			return nil, bosherr.Errorf("Creating vm: Accelerator Type '%s' is set more than once", acc.AcceleratorType)
+		}
+		seen[acc.AcceleratorType] = true
+		if acc.Count < 1 {
+			return nil, bosherr.Errorf("Creating vm: Accelerator Type '%s' must have a count of at least 1", acc.AcceleratorType)
+		}
+
 		acceleratorType, found, err := cv.acceleratorTypeService.Find(acc.AcceleratorType, zone)
 		if err != nil {
 			return nil, bosherr.WrapError(err, "Creating vm")
 		}
 		if !found {
-			return nil, bosherr.WrapErrorf(err, "Creating vm: Accelerator Type '%s' does not exists", acc.AcceleratorType)
+			return nil, newZoneResourceNotFoundError("Creating vm: Accelerator Type '%s' does not exists in zone '%s'", acc.AcceleratorType, zone)
+		}
+		if max := acceleratorType.MaximumCardsPerInstance; max > 0 && acc.Count > max {
+			return nil, bosherr.Errorf("Creating vm: Accelerator Type '%s' allows at most %d cards per instance, %d requested", acc.AcceleratorType, max, acc.Count)
 		}
 		updatedAcc := instance.Accelerator{
 			AcceleratorType: acceleratorType.SelfLink,
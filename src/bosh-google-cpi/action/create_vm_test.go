@@ -12,12 +12,17 @@ import (
 	imagefakes "bosh-google-cpi/google/image_service/fakes"
 	"bosh-google-cpi/google/instance_service"
 	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+	"bosh-google-cpi/google/instance_template_service"
+	instancetemplatefakes "bosh-google-cpi/google/instance_template_service/fakes"
 	"bosh-google-cpi/google/machine_type_service"
 	machinetypefakes "bosh-google-cpi/google/machine_type_service/fakes"
+	"bosh-google-cpi/google/zone_service"
+	zonefakes "bosh-google-cpi/google/zone_service/fakes"
 	"bosh-google-cpi/registry"
 	"errors"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"strings"
 
 	registryfakes "bosh-google-cpi/registry/fakes"
 
@@ -34,19 +39,26 @@ var _ = Describe("CreateVM", func() {
 		env                      Environment
 		defaultRootDiskSizeGb    int
 		defaultRootDiskType      string
+		defaultServiceScopes     []string
+		defaultNetworkTier       string
+		defaultTags              []string
+		disableExternalIP        bool
+		description              ResourceDescription
 		registryOptions          registry.ClientOptions
 		agentOptions             registry.AgentOptions
 		expectedVMProps          *instance.Properties
 		expectedInstanceNetworks instance.Networks
 		expectedAgentSettings    registry.AgentSettings
 
-		vmService              *instancefakes.FakeInstanceService
-		diskService            *diskfakes.FakeDiskService
-		diskTypeService        *disktypefakes.FakeDiskTypeService
-		machineTypeService     *machinetypefakes.FakeMachineTypeService
-		imageService           *imagefakes.FakeImageService
-		registryClient         *registryfakes.FakeClient
-		acceleratorTypeService *acceleratortypefakes.FakeAcceleratorTypeService
+		vmService               *instancefakes.FakeInstanceService
+		diskService             *diskfakes.FakeDiskService
+		diskTypeService         *disktypefakes.FakeDiskTypeService
+		machineTypeService      *machinetypefakes.FakeMachineTypeService
+		imageService            *imagefakes.FakeImageService
+		registryClient          *registryfakes.FakeClient
+		acceleratorTypeService  *acceleratortypefakes.FakeAcceleratorTypeService
+		instanceTemplateService *instancetemplatefakes.FakeInstanceTemplateService
+		zoneService             *zonefakes.FakeZoneService
 
 		createVM CreateVM
 	)
@@ -57,6 +69,8 @@ var _ = Describe("CreateVM", func() {
 		diskTypeService = &disktypefakes.FakeDiskTypeService{}
 		machineTypeService = &machinetypefakes.FakeMachineTypeService{}
 		acceleratorTypeService = &acceleratortypefakes.FakeAcceleratorTypeService{}
+		instanceTemplateService = &instancetemplatefakes.FakeInstanceTemplateService{}
+		zoneService = &zonefakes.FakeZoneService{}
 		imageService = &imagefakes.FakeImageService{}
 		registryClient = &registryfakes.FakeClient{}
 		registryOptions = registry.ClientOptions{
@@ -74,6 +88,11 @@ var _ = Describe("CreateVM", func() {
 		}
 		defaultRootDiskSizeGb = 0
 		defaultRootDiskType = ""
+		defaultServiceScopes = nil
+		defaultNetworkTier = ""
+		defaultTags = nil
+		disableExternalIP = false
+		description = ResourceDescription{}
 		createVM = NewCreateVM(
 			vmService,
 			diskService,
@@ -81,11 +100,18 @@ var _ = Describe("CreateVM", func() {
 			imageService,
 			machineTypeService,
 			acceleratorTypeService,
+			instanceTemplateService,
+			zoneService,
 			registryClient,
 			registryOptions,
 			agentOptions,
 			defaultRootDiskSizeGb,
 			defaultRootDiskType,
+			defaultServiceScopes,
+			defaultNetworkTier,
+			defaultTags,
+			disableExternalIP,
+			description,
 		)
 	})
 
@@ -246,6 +272,105 @@ var _ = Describe("CreateVM", func() {
 			Expect(vmService.CreateVMProps.Stemcell).To(Equal(stemcellLink))
 		})
 
+		Context("when cloud properties image family is set", func() {
+			BeforeEach(func() {
+				cloudProps.ImageFamily = "fake-image-family"
+				imageService.FindByFamilyFound = true
+				imageService.FindByFamilyImage = image.Image{SelfLink: "fake-family-image-self-link"}
+			})
+
+			It("creates the vm from the latest image of the family", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(imageService.FindCalled).To(BeFalse())
+				Expect(imageService.FindByFamilyFamily).To(Equal("fake-image-family"))
+				Expect(vmService.CreateVMProps.Stemcell).To(Equal("fake-family-image-self-link"))
+			})
+
+			It("returns an error if the image family does not exist", func() {
+				imageService.FindByFamilyFound = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Image family 'fake-image-family' does not exists"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if imageService find by family call returns an error", func() {
+				imageService.FindByFamilyErr = errors.New("fake-image-service-error")
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-image-service-error"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when SourceInstanceTemplate is set", func() {
+			var template instancetemplate.InstanceTemplate
+
+			BeforeEach(func() {
+				template = instancetemplate.InstanceTemplate{
+					Name:        "fake-instance-template",
+					SelfLink:    "fake-instance-template-self-link",
+					MachineType: "fake-template-machine-type",
+				}
+				instanceTemplateService.FindFound = true
+				instanceTemplateService.FindInstanceTemplate = template
+				cloudProps.SourceInstanceTemplate = "fake-instance-template"
+				cloudProps.MachineType = ""
+			})
+
+			It("creates the vm from the template", func() {
+				expectedVMProps.MachineType = ""
+				expectedVMProps.SourceInstanceTemplate = &template
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(instanceTemplateService.FindCalled).To(BeTrue())
+				Expect(machineTypeService.FindCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("overrides the machine type of the template if provided", func() {
+				cloudProps.MachineType = "fake-machine-type"
+				expectedVMProps.SourceInstanceTemplate = &template
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if the template is not found", func() {
+				instanceTemplateService.FindFound = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Instance Template 'fake-instance-template' does not exists"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the template subnetwork is not in the zone region", func() {
+				template.Subnetworks = []string{"https://www.googleapis.com/compute/v1/projects/fake-project/regions/fake-other-region1/subnetworks/fake-subnetwork"}
+				instanceTemplateService.FindInstanceTemplate = template
+				cloudProps.Zone = "fake-region1-a"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("not usable in zone 'fake-region1-a'"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the template machine type is not available in the zone", func() {
+				machineTypeService.FindFound = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Machine Type 'fake-template-machine-type' of Instance Template 'fake-instance-template' does not exists in zone 'fake-default-zone'"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
 		It("returns an error if stemcell is not found", func() {
 			imageService.FindFound = false
 
@@ -356,6 +481,264 @@ var _ = Describe("CreateVM", func() {
 			})
 		})
 
+		Context("when a default root disk type is configured", func() {
+			BeforeEach(func() {
+				defaultRootDiskType = "pd-ssd"
+				createVM = NewCreateVM(
+					vmService,
+					diskService,
+					diskTypeService,
+					imageService,
+					machineTypeService,
+					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
+					registryClient,
+					registryOptions,
+					agentOptions,
+					defaultRootDiskSizeGb,
+					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
+				)
+			})
+
+			It("creates the vm with the default root disk type", func() {
+				diskTypeService.FindFound = true
+				diskTypeService.FindDiskType = disktype.DiskType{SelfLink: "fake-disk-type-self-link"}
+				expectedVMProps.RootDiskType = "fake-disk-type-self-link"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error naming the default if it is not available in the zone", func() {
+				diskTypeService.FindFound = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Default Root Disk Type 'pd-ssd' does not exists in zone 'fake-default-zone'"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when default service scopes are configured", func() {
+			BeforeEach(func() {
+				defaultServiceScopes = []string{"devstorage.read_only", "logging.write"}
+				createVM = NewCreateVM(
+					vmService,
+					diskService,
+					diskTypeService,
+					imageService,
+					machineTypeService,
+					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
+					registryClient,
+					registryOptions,
+					agentOptions,
+					defaultRootDiskSizeGb,
+					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
+				)
+			})
+
+			It("creates the vm with the default scopes when no scopes are set", func() {
+				cloudProps.ServiceScopes = nil
+				expectedVMProps.ServiceScopes = instance.ServiceScopes([]string{"devstorage.read_only", "logging.write"})
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("creates the vm without scopes when an empty list of scopes is set", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps.ServiceScopes).To(Equal(instance.ServiceScopes([]string{})))
+			})
+
+			It("creates the vm with the scopes of the cloud properties", func() {
+				cloudProps.ServiceScopes = []string{"fake-service-scope"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps.ServiceScopes).To(Equal(instance.ServiceScopes([]string{"fake-service-scope"})))
+			})
+		})
+
+		Context("when a default network tier and tags are configured", func() {
+			BeforeEach(func() {
+				defaultNetworkTier = "STANDARD"
+				defaultTags = []string{"fake-default-tag"}
+				createVM = NewCreateVM(
+					vmService,
+					diskService,
+					diskTypeService,
+					imageService,
+					machineTypeService,
+					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
+					registryClient,
+					registryOptions,
+					agentOptions,
+					defaultRootDiskSizeGb,
+					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
+				)
+			})
+
+			It("creates the vm with the defaults when the network and cloud properties set none", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateNetworks.NetworkTier()).To(Equal("STANDARD"))
+				Expect(vmService.CreateVMProps.Tags).To(Equal(instance.Tags{"fake-default-tag"}))
+			})
+
+			It("overrides the default network tier with the network one", func() {
+				networks["fake-network-name"].CloudProperties.NetworkTier = "PREMIUM"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateNetworks.NetworkTier()).To(Equal("PREMIUM"))
+			})
+
+			It("merges the default tags with the cloud properties tags", func() {
+				cloudProps.Tags = instance.Tags{"fake-tag"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps.Tags).To(Equal(instance.Tags{"fake-default-tag", "fake-tag"}))
+				Expect(defaultTags).To(Equal([]string{"fake-default-tag"}))
+			})
+		})
+
+		Context("when external IPs are disabled", func() {
+			BeforeEach(func() {
+				disableExternalIP = true
+				createVM = NewCreateVM(
+					vmService,
+					diskService,
+					diskTypeService,
+					imageService,
+					machineTypeService,
+					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
+					registryClient,
+					registryOptions,
+					agentOptions,
+					defaultRootDiskSizeGb,
+					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
+				)
+			})
+
+			It("creates the vm without an ephemeral external IP", func() {
+				networks["fake-network-name"].CloudProperties.EphemeralExternalIP = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateNetworks.EphemeralExternalIP()).To(BeFalse())
+			})
+
+			It("returns an error if the network sets an ephemeral external IP", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Network property 'ephemeral_external_ip' can not be set when 'disable_external_ip' is enabled"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("creates the vm with an ephemeral external IP if the cloud properties set one", func() {
+				ephemeralExternalIP := true
+				cloudProps.EphemeralExternalIP = &ephemeralExternalIP
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateNetworks.EphemeralExternalIP()).To(BeTrue())
+			})
+
+			It("keeps the vip network external IP", func() {
+				networks["fake-network-name"].CloudProperties.EphemeralExternalIP = false
+				networks["fake-vip-network"] = &Network{Type: "vip", IP: "fake-vip-ip"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateNetworks.VipNetwork().IP).To(Equal("fake-vip-ip"))
+			})
+		})
+
+		Context("when a description is set", func() {
+			var deploymentEnv Environment
+
+			BeforeEach(func() {
+				description = ResourceDescription{Default: "fake-default-description", DirectorUUID: "fake-director-uuid"}
+				deploymentEnv = Environment{"bosh": map[string]interface{}{
+					"groups": []interface{}{"fake-director", "fake-deployment", "fake-instance-group"},
+				}}
+				createVM = NewCreateVM(
+					vmService,
+					diskService,
+					diskTypeService,
+					imageService,
+					machineTypeService,
+					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
+					registryClient,
+					registryOptions,
+					agentOptions,
+					defaultRootDiskSizeGb,
+					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
+				)
+			})
+
+			It("creates the vm with the default description", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, deploymentEnv)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps.Description).To(Equal("fake-default-description"))
+			})
+
+			It("expands the placeholders of the cloud properties description", func() {
+				cloudProps.Description = "VM of {deployment} managed by {director_uuid}"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, deploymentEnv)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps.Description).To(Equal("VM of fake-deployment managed by fake-director-uuid"))
+			})
+
+			It("returns an error if the description is too long", func() {
+				cloudProps.Description = strings.Repeat("a", 2049)
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, deploymentEnv)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Description is 2049 characters long, at most 2048 are allowed"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
 		Context("when custom machine type is set", func() {
 			BeforeEach(func() {
 				cloudProps.MachineType = ""
@@ -456,11 +839,18 @@ var _ = Describe("CreateVM", func() {
 					imageService,
 					machineTypeService,
 					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
 					registryClient,
 					registryOptions,
 					agentOptions,
 					defaultRootDiskSizeGb,
 					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
 				)
 			})
 
@@ -519,11 +909,18 @@ var _ = Describe("CreateVM", func() {
 					imageService,
 					machineTypeService,
 					acceleratorTypeService,
+					instanceTemplateService,
+					zoneService,
 					registryClient,
 					registryOptions,
 					agentOptions,
 					defaultRootDiskSizeGb,
 					defaultRootDiskType,
+					defaultServiceScopes,
+					defaultNetworkTier,
+					defaultTags,
+					disableExternalIP,
+					description,
 				)
 			})
 
@@ -600,6 +997,146 @@ var _ = Describe("CreateVM", func() {
 			})
 		})
 
+		Context("when cloud properties ephemeral disk is set", func() {
+			BeforeEach(func() {
+				diskTypeService.FindFound = true
+				cloudProps.EphemeralDisk = &EphemeralDisk{Size: 20480, Type: "fake-ephemeral-disk-type"}
+				expectedVMProps.EphemeralDisk = &instance.EphemeralDisk{SizeGb: 20, DiskType: "fake-disk-type-self-link"}
+				expectedAgentSettings.Disks.Ephemeral = "/dev/disk/by-id/google-ephemeral-disk"
+			})
+
+			It("creates the vm with the ephemeral disk and passes its device path to the agent", func() {
+				vmCID, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskTypeService.FindCalled).To(BeTrue())
+				Expect(vmService.CreateCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+				Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
+				Expect(vmCID).To(Equal(VMCID("fake-vm-id")))
+			})
+
+			It("leaves the disk type to the default when unset", func() {
+				cloudProps.EphemeralDisk.Type = ""
+				expectedVMProps.EphemeralDisk.DiskType = ""
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskTypeService.FindCalled).To(BeFalse())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if the size is not set", func() {
+				cloudProps.EphemeralDisk.Size = 0
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("'ephemeral_disk' must have a size"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the disk type is not available in the zone", func() {
+				diskTypeService.FindFound = false
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Ephemeral Disk Type 'fake-ephemeral-disk-type' does not exists"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+				Expect(registryClient.UpdateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when the root device name and disk interface are set", func() {
+			BeforeEach(func() {
+				cloudProps.RootDeviceName = "fake-root-device"
+				cloudProps.DiskInterface = "SCSI"
+				expectedVMProps.RootDeviceName = "fake-root-device"
+				expectedVMProps.RootDiskInterface = "SCSI"
+			})
+
+			It("creates the vm with both set on the boot disk", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if the root device name is invalid", func() {
+				cloudProps.RootDeviceName = "Fake Root Device"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Root device name \"Fake Root Device\" is invalid"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the root device name is used by the ephemeral disk", func() {
+				cloudProps.RootDeviceName = "ephemeral-disk"
+				cloudProps.EphemeralDisk = &EphemeralDisk{Size: 20480}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Root device name \"ephemeral-disk\" is already used by the ephemeral disk"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the disk interface is NVME, which persistent disks do not support", func() {
+				cloudProps.DiskInterface = "NVME"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Disk interface \"NVME\" is not supported by the root disk"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the disk interface is not supported", func() {
+				cloudProps.DiskInterface = "IDE"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Disk interface \"IDE\" is invalid"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when the root disk and its source image use KMS keys", func() {
+			const (
+				diskKey  = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-disk-key"
+				imageKey = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-image-key"
+			)
+
+			BeforeEach(func() {
+				cloudProps.KmsKeyName = diskKey
+				cloudProps.SourceImageKmsKeyName = imageKey
+				expectedVMProps.RootDiskKmsKeyName = diskKey
+				expectedVMProps.SourceImageKmsKeyName = imageKey
+			})
+
+			It("creates the vm with both keys", func() {
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if the disk key is invalid", func() {
+				cloudProps.KmsKeyName = "fake-disk-key"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("KMS key name \"fake-disk-key\" is invalid"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the source image key is invalid", func() {
+				cloudProps.SourceImageKmsKeyName = "projects/fake-project/cryptoKeys/fake-image-key"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Source image KMS key name \"projects/fake-project/cryptoKeys/fake-image-key\" is invalid"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
 		Context("when zone is set", func() {
 			BeforeEach(func() {
 				cloudProps.Zone = "fake-zone"
@@ -624,6 +1161,232 @@ var _ = Describe("CreateVM", func() {
 			})
 		})
 
+		Context("when region is set", func() {
+			BeforeEach(func() {
+				cloudProps.Zone = ""
+				cloudProps.Region = "fake-region1"
+				zoneService.FindByRegionZones = []zone.Zone{
+					{Name: "fake-region1-a"},
+					{Name: "fake-region1-b"},
+					{Name: "fake-region1-c"},
+				}
+			})
+
+			It("creates the vm in one of the zones of the region", func() {
+				vmCID, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmCID).To(Equal(VMCID("fake-vm-id")))
+				Expect(zoneService.FindByRegionCalled).To(BeTrue())
+				Expect(zoneService.FindByRegionRegion).To(Equal("fake-region1"))
+				Expect(vmService.CreateZones).To(HaveLen(1))
+				Expect([]string{"fake-region1-a", "fake-region1-b", "fake-region1-c"}).To(ContainElement(vmService.CreateVMProps.Zone))
+			})
+
+			It("moves to the next zone when a zone runs out of resources", func() {
+				stockoutErr := api.NewVMCreationFailedError("The zone 'projects/fake-project/zones/fake-region1-a' does not have enough resources available to fulfill the request.", true)
+				vmService.CreateErrs = []error{stockoutErr, stockoutErr}
+
+				vmCID, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmCID).To(Equal(VMCID("fake-vm-id")))
+				Expect(vmService.CreateZones).To(ConsistOf("fake-region1-a", "fake-region1-b", "fake-region1-c"))
+				Expect(vmService.CreateVMProps.Zone).To(Equal(vmService.CreateZones[2]))
+				Expect(registryClient.UpdateCalled).To(BeTrue())
+			})
+
+			It("returns the error when every zone runs out of resources", func() {
+				stockoutErr := api.NewVMCreationFailedError("ZONE_RESOURCE_POOL_EXHAUSTED", true)
+				vmService.CreateErr = stockoutErr
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(Equal(stockoutErr))
+				Expect(vmService.CreateZones).To(HaveLen(3))
+				Expect(registryClient.UpdateCalled).To(BeFalse())
+			})
+
+			It("moves to the next zone when a zone does not offer the machine type", func() {
+				machineTypeService.FindFoundZones = []string{"fake-region1-c"}
+
+				vmCID, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmCID).To(Equal(VMCID("fake-vm-id")))
+				Expect(vmService.CreateZones).To(Equal([]string{"fake-region1-c"}))
+				Expect(registryClient.UpdateCalled).To(BeTrue())
+			})
+
+			It("returns an error when no zone offers the machine type", func() {
+				machineTypeService.FindFoundZones = []string{}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Machine Type 'fake-machine-type' does not exists in zone"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("does not move to the next zone on other errors", func() {
+				vmService.CreateErr = errors.New("fake-vm-service-error")
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(vmService.CreateZones).To(HaveLen(1))
+			})
+
+			It("returns an error if the region has no zones", func() {
+				zoneService.FindByRegionZones = nil
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("no zones available in region 'fake-region1'"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if the zone is not in the region", func() {
+				cloudProps.Zone = "fake-region2-a"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("zone 'fake-region2-a' is not in region 'fake-region1'"))
+				Expect(zoneService.FindByRegionCalled).To(BeFalse())
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("uses the zone of the disks", func() {
+				diskService.FindFound = true
+				diskService.FindDisk = disk.Disk{Zone: "fake-region1-b"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, []DiskCID{"fake-disk-1"}, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(zoneService.FindByRegionCalled).To(BeFalse())
+				Expect(vmService.CreateZones).To(Equal([]string{"fake-region1-b"}))
+			})
+		})
+
+		Context("when guest attributes are enabled", func() {
+			It("creates the vm with guest attributes enabled", func() {
+				cloudProps.EnableGuestAttributes = true
+				expectedVMProps.EnableGuestAttributes = true
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+		})
+
+		Context("when OS Login is set", func() {
+			It("creates the vm with OS Login enabled", func() {
+				enableOSLogin := true
+				cloudProps.EnableOSLogin = &enableOSLogin
+				expectedVMProps.EnableOSLogin = &enableOSLogin
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("creates the vm with OS Login disabled and metadata SSH keys", func() {
+				enableOSLogin := false
+				cloudProps.EnableOSLogin = &enableOSLogin
+				cloudProps.SSHKeys = []string{"fake-user:ssh-rsa fake-key fake-user"}
+				expectedVMProps.EnableOSLogin = &enableOSLogin
+				expectedVMProps.SSHKeys = []string{"fake-user:ssh-rsa fake-key fake-user"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if SSH keys are set with OS Login enabled", func() {
+				enableOSLogin := true
+				cloudProps.EnableOSLogin = &enableOSLogin
+				cloudProps.SSHKeys = []string{"fake-user:ssh-rsa fake-key fake-user"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("SSH keys can not be set when OS Login is enabled"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if an SSH key does not name its user", func() {
+				cloudProps.SSHKeys = []string{"ssh-rsa fake-key fake-user"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("SSH key \"ssh-rsa fake-key fake-user\" is invalid. Must be '<username>:<public key>'"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when reboot method is set", func() {
+			It("creates the vm with the reboot method", func() {
+				cloudProps.RebootMethod = "STOP_START"
+				expectedVMProps.RebootMethod = "STOP_START"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if the reboot method is not supported", func() {
+				cloudProps.RebootMethod = "fake-reboot-method"
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Reboot method \"fake-reboot-method\" is invalid"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
+		Context("when startup script is set", func() {
+			It("creates the vm with an inline startup script", func() {
+				cloudProps.StartupScript = "#!/bin/bash\necho fake-startup-script"
+				expectedVMProps.StartupScript = instance.StartupScript{Inline: "#!/bin/bash\necho fake-startup-script"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("creates the vm with a startup script url", func() {
+				cloudProps.StartupScript = "gs://fake-bucket/fake-startup-script"
+				expectedVMProps.StartupScript = instance.StartupScript{URL: "gs://fake-bucket/fake-startup-script"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("creates the vm with a startup script set as a hash", func() {
+				cloudProps.StartupScript = map[string]interface{}{"url": "https://storage.googleapis.com/fake-bucket/fake-startup-script"}
+				expectedVMProps.StartupScript = instance.StartupScript{URL: "https://storage.googleapis.com/fake-bucket/fake-startup-script"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if inline and url are both set", func() {
+				cloudProps.StartupScript = map[string]interface{}{
+					"inline": "fake-startup-script",
+					"url":    "gs://fake-bucket/fake-startup-script",
+				}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("'inline' and 'url' are mutually exclusive"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if url is not a GCS url", func() {
+				cloudProps.StartupScript = map[string]interface{}{"url": "http://fake-host/fake-startup-script"}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("'url' must be a Google Cloud Storage URL"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+		})
+
 		Context("when accelerator is set", func() {
 			BeforeEach(func() {
 				acceleratorTypeService.FindFound = true
@@ -639,6 +1402,8 @@ var _ = Describe("CreateVM", func() {
 				cloudProps.Accelerators = []Accelerator{acc}
 				expectedVMProps.Accelerators = []instance.Accelerator{expectedAcc}
 				expectedVMProps.OnHostMaintenance = "TERMINATE"
+				imageService.FindImage.Name = "fake-image"
+				imageService.FindImage.Labels = map[string]string{image.GPUDriverLabelKey: "true"}
 			})
 
 			It("creates the vm with the accelerator", func() {
@@ -650,6 +1415,36 @@ var _ = Describe("CreateVM", func() {
 
 			})
 
+			It("returns an error if the stemcell does not ship the GPU driver", func() {
+				imageService.FindImage.Labels = nil
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Image 'fake-image' does not ship the GPU driver required by 'accelerators'"))
+				Expect(acceleratorTypeService.FindCalled).To(BeFalse())
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("creates the vm with the accelerator on a stemcell without the GPU driver if forced", func() {
+				imageService.FindImage.Labels = nil
+				cloudProps.ForceGPU = true
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("does not check images given by url", func() {
+				imageService.FindImage.Labels = nil
+				stemcellLink := "https://www.googleapis.com/compute/v1/projects/fake/stemcell/path"
+				expectedVMProps.Stemcell = stemcellLink
+
+				_, err = createVM.Run("fake-agent-id", StemcellCID(stemcellLink), cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
 			It("returns an error if acceleratorTypeService find call returns an error", func() {
 				acceleratorTypeService.FindErr = errors.New("fake-accelerator-type-service-error")
 
@@ -681,6 +1476,54 @@ var _ = Describe("CreateVM", func() {
 				Expect(vmService.CleanUpCalled).To(BeFalse())
 				Expect(registryClient.UpdateCalled).To(BeFalse())
 			})
+
+			It("creates the vm with accelerators of different types", func() {
+				acceleratorTypeService.FindAcceleratorTypes = map[string]acceleratortype.AcceleratorType{
+					"fake-accelerator-type":       {SelfLink: "fake-accelerator-type-self-link", MaximumCardsPerInstance: 4},
+					"fake-other-accelerator-type": {SelfLink: "fake-other-accelerator-type-self-link", MaximumCardsPerInstance: 8},
+				}
+				cloudProps.Accelerators = []Accelerator{
+					{AcceleratorType: "fake-accelerator-type", Count: 4},
+					{AcceleratorType: "fake-other-accelerator-type", Count: 2},
+				}
+				expectedVMProps.Accelerators = []instance.Accelerator{
+					{AcceleratorType: "fake-accelerator-type-self-link", Count: 4},
+					{AcceleratorType: "fake-other-accelerator-type-self-link", Count: 2},
+				}
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.CreateCalled).To(BeTrue())
+				Expect(vmService.CreateVMProps).To(Equal(expectedVMProps))
+			})
+
+			It("returns an error if more cards are requested than the accelerator type allows", func() {
+				acceleratorTypeService.FindAcceleratorType.MaximumCardsPerInstance = 4
+				cloudProps.Accelerators[0].Count = 8
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Accelerator Type 'fake-accelerator-type' allows at most 4 cards per instance, 8 requested"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if an accelerator type is set more than once", func() {
+				cloudProps.Accelerators = append(cloudProps.Accelerators, Accelerator{AcceleratorType: "fake-accelerator-type", Count: 2})
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Accelerator Type 'fake-accelerator-type' is set more than once"))
+				Expect(vmService.CreateCalled).To(BeFalse())
+			})
+
+			It("returns an error if an accelerator count is not set", func() {
+				cloudProps.Accelerators[0].Count = 0
+
+				_, err = createVM.Run("fake-agent-id", "fake-stemcell-id", cloudProps, networks, disks, env)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Accelerator Type 'fake-accelerator-type' must have a count of at least 1"))
+				Expect(acceleratorTypeService.FindCalled).To(BeFalse())
+			})
 		})
 
 		Context("when DiskCIDs is set", func() {
@@ -20,7 +20,22 @@ func NewDeleteDisk(
 }
 
 func (dd DeleteDisk) Run(diskCID DiskCID) (interface{}, error) {
-	if err := dd.diskService.Delete(string(diskCID)); err != nil {
+	name, _, region, ok := parseDiskCID(diskCID)
+	if !ok {
+		return nil, bosherr.Errorf("Deleting disk '%s': %s", diskCID, malformedDiskCIDMessage)
+	}
+
+	var err error
+	if region != "" {
+		err = dd.diskService.DeleteInRegion(name, region)
+	} else {
+		err = dd.diskService.Delete(name)
+	}
+	if err != nil {
+		// The disk is already gone, retried deletes succeed
+		if _, ok := err.(api.DiskNotFoundError); ok {
+			return nil, nil
+		}
 		if _, ok := err.(api.CloudError); ok {
 			return nil, err
 		}
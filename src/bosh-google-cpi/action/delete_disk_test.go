@@ -9,6 +9,8 @@ import (
 	. "bosh-google-cpi/action"
 
 	diskfakes "bosh-google-cpi/google/disk_service/fakes"
+
+	"bosh-google-cpi/api"
 )
 
 var _ = Describe("DeleteDisk", func() {
@@ -32,6 +34,44 @@ var _ = Describe("DeleteDisk", func() {
 			Expect(diskService.DeleteCalled).To(BeTrue())
 		})
 
+		It("succeeds if the disk was already deleted", func() {
+			diskService.DeleteErr = api.NewDiskNotFoundError("fake-disk-id", false)
+
+			_, err = deleteDisk.Run("fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.DeleteCalled).To(BeTrue())
+		})
+
+		It("deletes a zonal disk given its path", func() {
+			_, err = deleteDisk.Run("zones/fake-zone/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.DeleteIDs).To(Equal([]string{"fake-disk-id"}))
+			Expect(diskService.DeleteInRegionCalled).To(BeFalse())
+		})
+
+		It("deletes a regional disk", func() {
+			_, err = deleteDisk.Run("regions/fake-region/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.DeleteCalled).To(BeFalse())
+			Expect(diskService.DeleteInRegionCalled).To(BeTrue())
+			Expect(diskService.DeleteInRegionID).To(Equal("fake-disk-id"))
+			Expect(diskService.DeleteInRegionRegion).To(Equal("fake-region"))
+		})
+
+		It("succeeds if the regional disk was already deleted", func() {
+			diskService.DeleteInRegionErr = api.NewDiskNotFoundError("fake-disk-id", false)
+
+			_, err = deleteDisk.Run("regions/fake-region/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("returns an error if the disk CID is malformed", func() {
+			_, err = deleteDisk.Run("zones/fake-zone/fake-disk-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Malformed disk CID"))
+			Expect(diskService.DeleteCalled).To(BeFalse())
+		})
+
 		It("returns an error if diskService delete call returns an error", func() {
 			diskService.DeleteErr = errors.New("fake-disk-service-error")
 
@@ -0,0 +1,99 @@
+package action
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/snapshot_service"
+)
+
+// DeleteOldSnapshotsOptions select the snapshots to delete. Only snapshots
+// taken by snapshot_disk, which are labelled with their source disk, are
+// ever considered.
+type DeleteOldSnapshotsOptions struct {
+	// MaxAge is the age, as a duration such as "720h", past which
+	// snapshots are deleted
+	MaxAge string            `json:"max_age"`
+	Labels map[string]string `json:"labels"`
+
+	// DryRun only reports the snapshots that would be deleted
+	DryRun bool `json:"dry_run"`
+}
+
+// OldSnapshots are the snapshots deleted, or that would be deleted on a dry
+// run.
+type OldSnapshots struct {
+	DryRun    bool          `json:"dry_run"`
+	Snapshots []OldSnapshot `json:"snapshots"`
+}
+
+type OldSnapshot struct {
+	CID       string `json:"cid"`
+	DiskCID   string `json:"disk_cid"`
+	CreatedAt string `json:"created_at"`
+}
+
+type DeleteOldSnapshots struct {
+	snapshotService snapshot.Service
+}
+
+func NewDeleteOldSnapshots(
+	snapshotService snapshot.Service,
+) DeleteOldSnapshots {
+	return DeleteOldSnapshots{
+		snapshotService: snapshotService,
+	}
+}
+
+func (ds DeleteOldSnapshots) Run(options DeleteOldSnapshotsOptions) (OldSnapshots, error) {
+	old := OldSnapshots{DryRun: options.DryRun, Snapshots: []OldSnapshot{}}
+
+	maxAge, err := time.ParseDuration(options.MaxAge)
+	if err != nil {
+		return old, bosherr.WrapErrorf(err, "Deleting old snapshots: Invalid 'max_age' '%s'", options.MaxAge)
+	}
+	if maxAge <= 0 {
+		return old, bosherr.Errorf("Deleting old snapshots: 'max_age' must be positive, got '%s'", options.MaxAge)
+	}
+	labels := instance.Labels(options.Labels)
+	if err := labels.Validate(); err != nil {
+		return old, bosherr.WrapError(err, "Deleting old snapshots")
+	}
+
+	snapshots, err := ds.snapshotService.FindByLabels(options.Labels)
+	if err != nil {
+		return old, bosherr.WrapError(err, "Deleting old snapshots")
+	}
+
+	createdBefore := time.Now().Add(-maxAge)
+	for _, s := range snapshots {
+		// Never touch snapshots the CPI did not take
+		diskCID := s.Labels[snapshot.DiskLabelKey]
+		if diskCID == "" {
+			continue
+		}
+
+		createdAt, err := time.Parse(time.RFC3339, s.CreationTimestamp)
+		if err != nil {
+			return old, bosherr.WrapErrorf(err, "Deleting old snapshots: Invalid creation timestamp of snapshot '%s'", s.Name)
+		}
+		if !createdAt.Before(createdBefore) {
+			continue
+		}
+
+		if !options.DryRun {
+			if err := ds.snapshotService.Delete(s.Name); err != nil {
+				return old, bosherr.WrapErrorf(err, "Deleting old snapshot '%s'", s.Name)
+			}
+		}
+		old.Snapshots = append(old.Snapshots, OldSnapshot{
+			CID:       s.Name,
+			DiskCID:   diskCID,
+			CreatedAt: s.CreationTimestamp,
+		})
+	}
+
+	return old, nil
+}
@@ -0,0 +1,126 @@
+package action_test
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	snapshotfakes "bosh-google-cpi/google/snapshot_service/fakes"
+
+	"bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("DeleteOldSnapshots", func() {
+	var (
+		err     error
+		old     OldSnapshots
+		options DeleteOldSnapshotsOptions
+
+		snapshotService *snapshotfakes.FakeSnapshotService
+
+		deleteOldSnapshots DeleteOldSnapshots
+	)
+
+	BeforeEach(func() {
+		snapshotService = &snapshotfakes.FakeSnapshotService{}
+		deleteOldSnapshots = NewDeleteOldSnapshots(snapshotService)
+
+		recent := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		snapshotService.FindByLabelsSnapshots = []snapshot.Snapshot{
+			{Name: "fake-old-snapshot", Labels: map[string]string{snapshot.DiskLabelKey: "fake-disk-id"}, CreationTimestamp: "2016-01-02T03:04:05.678-07:00"},
+			{Name: "fake-recent-snapshot", Labels: map[string]string{snapshot.DiskLabelKey: "fake-disk-id"}, CreationTimestamp: recent},
+			{Name: "fake-unmanaged-snapshot", CreationTimestamp: "2016-01-02T03:04:05.678-07:00"},
+		}
+		options = DeleteOldSnapshotsOptions{
+			MaxAge: "720h",
+			Labels: map[string]string{"director": "fake-director"},
+		}
+	})
+
+	Describe("Run", func() {
+		It("deletes the managed snapshots older than the max age", func() {
+			old, err = deleteOldSnapshots.Run(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshotService.FindByLabelsLabels).To(Equal(map[string]string{"director": "fake-director"}))
+			Expect(snapshotService.DeleteIDs).To(Equal([]string{"fake-old-snapshot"}))
+			Expect(old).To(Equal(OldSnapshots{
+				Snapshots: []OldSnapshot{{CID: "fake-old-snapshot", DiskCID: "fake-disk-id", CreatedAt: "2016-01-02T03:04:05.678-07:00"}},
+			}))
+		})
+
+		It("only reports the snapshots on a dry run", func() {
+			options.DryRun = true
+
+			old, err = deleteOldSnapshots.Run(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshotService.DeleteCalled).To(BeFalse())
+			Expect(old.DryRun).To(BeTrue())
+			Expect(old.Snapshots).To(Equal([]OldSnapshot{{CID: "fake-old-snapshot", DiskCID: "fake-disk-id", CreatedAt: "2016-01-02T03:04:05.678-07:00"}}))
+		})
+
+		It("returns an empty list when no snapshot is old enough", func() {
+			options.MaxAge = "876000h"
+
+			old, err = deleteOldSnapshots.Run(options)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(old.Snapshots).To(BeEmpty())
+			Expect(old.Snapshots).NotTo(BeNil())
+			Expect(snapshotService.DeleteCalled).To(BeFalse())
+		})
+
+		It("returns an error if the max age is invalid", func() {
+			options.MaxAge = "30 days"
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid 'max_age' '30 days'"))
+			Expect(snapshotService.FindByLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if the max age is not positive", func() {
+			options.MaxAge = "0s"
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'max_age' must be positive"))
+			Expect(snapshotService.FindByLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if a label is invalid", func() {
+			options.Labels = map[string]string{"director": "Fake Director"}
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(snapshotService.FindByLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if a creation timestamp cannot be parsed", func() {
+			snapshotService.FindByLabelsSnapshots[0].CreationTimestamp = "fake-timestamp"
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid creation timestamp of snapshot 'fake-old-snapshot'"))
+			Expect(snapshotService.DeleteCalled).To(BeFalse())
+		})
+
+		It("returns an error if snapshotService find by labels call returns an error", func() {
+			snapshotService.FindByLabelsErr = errors.New("fake-snapshot-service-error")
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-snapshot-service-error"))
+		})
+
+		It("returns an error if snapshotService delete call returns an error", func() {
+			snapshotService.DeleteErr = errors.New("fake-snapshot-service-error")
+
+			_, err = deleteOldSnapshots.Run(options)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-snapshot-service-error"))
+		})
+	})
+})
@@ -4,27 +4,62 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/firewall_service"
 	"bosh-google-cpi/google/instance_service"
 
 	"bosh-google-cpi/registry"
+	"bosh-google-cpi/util"
+
+	"google.golang.org/api/compute/v1"
 )
 
 type DeleteVM struct {
-	vmService      instance.Service
-	registryClient registry.Client
+	vmService           instance.Service
+	diskService         disk.Service
+	firewallService     firewall.Service
+	registryClient      registry.Client
+	deploymentIsolation bool
 }
 
 func NewDeleteVM(
 	vmService instance.Service,
+	diskService disk.Service,
+	firewallService firewall.Service,
 	registryClient registry.Client,
+	deploymentIsolation bool,
 ) DeleteVM {
 	return DeleteVM{
-		vmService:      vmService,
-		registryClient: registryClient,
+		vmService:           vmService,
+		diskService:         diskService,
+		firewallService:     firewallService,
+		registryClient:      registryClient,
+		deploymentIsolation: deploymentIsolation,
 	}
 }
 
 func (dv DeleteVM) Run(vmCID VMCID) (interface{}, error) {
+	// Find the VM, its deployment tags and disks are needed after it is gone
+	vm, found, err := dv.vmService.Find(string(vmCID), "")
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Deleting vm '%s'", vmCID)
+	}
+	if !found {
+		return nil, api.NewVMNotFoundError(string(vmCID))
+	}
+
+	var deploymentTags []string
+	if dv.deploymentIsolation {
+		deploymentTags = dv.findDeploymentTags(vm)
+	}
+
+	// Detach any persistent disks still attached to the VM, otherwise the
+	// delete can race with an in-progress attach and be refused by GCE
+	ephemeralDisks, err := dv.detachDisks(vm)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Deleting vm '%s'", vmCID)
+	}
+
 	// Delete the VM
 	if err := dv.vmService.Delete(string(vmCID)); err != nil {
 		if _, ok := err.(api.CloudError); ok {
@@ -33,10 +68,103 @@ func (dv DeleteVM) Run(vmCID VMCID) (interface{}, error) {
 		return nil, bosherr.WrapErrorf(err, "Deleting vm '%s'", vmCID)
 	}
 
+	// Delete the ephemeral disks that were detached from the VM
+	for _, diskID := range ephemeralDisks {
+		if err := dv.diskService.Delete(diskID); err != nil {
+			// The disk was auto-deleted with the VM
+			if _, ok := err.(api.DiskNotFoundError); ok {
+				continue
+			}
+			return nil, bosherr.WrapErrorf(err, "Deleting ephemeral disk '%s' of vm '%s'", diskID, vmCID)
+		}
+	}
+
 	// Delete the VM agent settings
 	if err := dv.registryClient.Delete(string(vmCID)); err != nil {
 		return nil, bosherr.WrapErrorf(err, "Deleting vm '%s'", vmCID)
 	}
 
+	// Delete the deployment firewall rule along with the last VM using it
+	for _, tag := range deploymentTags {
+		if err := dv.deleteUnusedFirewall(tag, vmCID); err != nil {
+			return nil, bosherr.WrapErrorf(err, "Deleting vm '%s'", vmCID)
+		}
+	}
+
 	return nil, nil
 }
+
+func (dv DeleteVM) findDeploymentTags(vm *compute.Instance) []string {
+	if vm.Tags == nil {
+		return nil
+	}
+
+	var tags []string
+	for _, tag := range vm.Tags.Items {
+		if firewall.IsDeploymentTag(tag) {
+			tags = append(tags, tag)
+		}
+	}
+
+	return tags
+}
+
+func (dv DeleteVM) deleteUnusedFirewall(tag string, vmCID VMCID) error {
+	vms, err := dv.vmService.FindByTag(tag)
+	if err != nil {
+		return err
+	}
+	// Asynchronously deleted VMs are still listed
+	for _, vm := range vms {
+		if vm != string(vmCID) {
+			return nil
+		}
+	}
+
+	rule, found, err := dv.firewallService.Find(tag)
+	if err != nil {
+		return err
+	}
+	if !found || !rule.IsManaged() {
+		return nil
+	}
+
+	return dv.firewallService.Delete(tag)
+}
+
+// detachDisks detaches the disks attached to the VM and returns the ephemeral
+// disks among them. Auto-delete ephemeral disks are left attached so GCE
+// deletes them with the VM, which also holds when the delete is not waited on.
+func (dv DeleteVM) detachDisks(vm *compute.Instance) ([]string, error) {
+	zone := util.ResourceSplitter(vm.Zone)
+
+	var ephemeralDisks []string
+	for _, attachedDisk := range vm.Disks {
+		if attachedDisk.Boot {
+			continue
+		}
+
+		diskID := util.ResourceSplitter(attachedDisk.Source)
+		d, found, err := dv.diskService.Find(diskID, zone)
+		if err != nil {
+			return nil, err
+		}
+		ephemeral := found && d.Ephemeral()
+		if ephemeral && attachedDisk.AutoDelete {
+			continue
+		}
+
+		if err := dv.vmService.DetachDisk(vm.Name, diskID); err != nil {
+			// The disk is already gone, nothing to detach
+			if _, ok := err.(api.DiskNotAttachedError); !ok {
+				return nil, bosherr.WrapErrorf(err, "Detaching disk '%s'", diskID)
+			}
+		}
+
+		if ephemeral {
+			ephemeralDisks = append(ephemeralDisks, diskID)
+		}
+	}
+
+	return ephemeralDisks, nil
+}
@@ -2,31 +2,58 @@ package action_test
 
 import (
 	"errors"
+	"os"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	. "bosh-google-cpi/action"
 
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_service"
+
+	diskfakes "bosh-google-cpi/google/disk_service/fakes"
+	"bosh-google-cpi/google/firewall_service"
+	firewallfakes "bosh-google-cpi/google/firewall_service/fakes"
+
 	instancefakes "bosh-google-cpi/google/instance_service/fakes"
 
 	registryfakes "bosh-google-cpi/registry/fakes"
+
+	"google.golang.org/api/compute/v1"
 )
 
 var _ = Describe("DeleteVM", func() {
 	var (
 		err error
 
-		vmService      *instancefakes.FakeInstanceService
-		registryClient *registryfakes.FakeClient
+		vmService       *instancefakes.FakeInstanceService
+		diskService     *diskfakes.FakeDiskService
+		firewallService *firewallfakes.FakeFirewallService
+		registryClient  *registryfakes.FakeClient
 
 		deleteVM DeleteVM
 	)
 
 	BeforeEach(func() {
 		vmService = &instancefakes.FakeInstanceService{}
+		diskService = &diskfakes.FakeDiskService{}
+		firewallService = &firewallfakes.FakeFirewallService{}
 		registryClient = &registryfakes.FakeClient{}
-		deleteVM = NewDeleteVM(vmService, registryClient)
+		deleteVM = NewDeleteVM(vmService, diskService, firewallService, registryClient, false)
+
+		vmService.FindFound = true
+		vmService.FindInstance = &compute.Instance{
+			Name: "fake-vm-id",
+			Zone: "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone",
+			Disks: []*compute.AttachedDisk{
+				{
+					Boot:   true,
+					Source: "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/disks/fake-vm-id",
+				},
+			},
+		}
 	})
 
 	Describe("Run", func() {
@@ -37,6 +64,212 @@ var _ = Describe("DeleteVM", func() {
 			Expect(registryClient.DeleteCalled).To(BeTrue())
 		})
 
+		Context("when deployment isolation is enabled", func() {
+			var tag string
+
+			BeforeEach(func() {
+				tag = firewall.DeploymentTag("fake-deployment")
+				deleteVM = NewDeleteVM(vmService, diskService, firewallService, registryClient, true)
+				vmService.FindInstance.Tags = &compute.Tags{Items: []string{"fake-tag", tag}}
+				firewallService.FindFound = true
+				firewallService.FindFirewall = firewall.Firewall{Name: tag, Description: "Firewall rule managed by BOSH"}
+			})
+
+			It("deletes the firewall rule with the last vm of the deployment", func() {
+				vmService.FindByTagNames = []string{"fake-vm-id"}
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.DeleteCalled).To(BeTrue())
+				Expect(vmService.FindByTagTag).To(Equal(tag))
+				Expect(firewallService.DeleteCalled).To(BeTrue())
+				Expect(firewallService.DeleteID).To(Equal(tag))
+			})
+
+			It("keeps the firewall rule while other vms of the deployment remain", func() {
+				vmService.FindByTagNames = []string{"fake-other-vm-id"}
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.FindByTagCalled).To(BeTrue())
+				Expect(firewallService.DeleteCalled).To(BeFalse())
+			})
+
+			It("does not delete firewall rules not managed by BOSH", func() {
+				firewallService.FindFirewall.Description = "fake-description"
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(firewallService.DeleteCalled).To(BeFalse())
+			})
+
+			It("does not look for firewall rules if the vm has no deployment tag", func() {
+				vmService.FindInstance.Tags.Items = []string{"fake-tag"}
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.FindByTagCalled).To(BeFalse())
+				Expect(firewallService.FindCalled).To(BeFalse())
+			})
+
+			It("returns an error if the firewall rule can not be deleted", func() {
+				firewallService.DeleteErr = errors.New("fake-firewall-service-error")
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-firewall-service-error"))
+			})
+		})
+
+		Context("when the vm has attached disks", func() {
+			BeforeEach(func() {
+				vmService.FindInstance.Disks = append(vmService.FindInstance.Disks,
+					&compute.AttachedDisk{
+						DeviceName: "fake-disk-1",
+						Source:     "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/disks/fake-disk-1",
+					},
+					&compute.AttachedDisk{
+						DeviceName: "fake-disk-2",
+						Source:     "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/disks/fake-disk-2",
+					},
+				)
+			})
+
+			It("detaches the disks before deleting the vm", func() {
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskService.FindZone).To(Equal("fake-zone"))
+				Expect(vmService.DetachDiskIDs).To(Equal([]string{"fake-disk-1", "fake-disk-2"}))
+				Expect(vmService.DeleteDetachedDisks).To(Equal([]string{"fake-disk-1", "fake-disk-2"}))
+				Expect(registryClient.DeleteCalled).To(BeTrue())
+			})
+
+			It("ignores disks that are no longer attached", func() {
+				vmService.DetachDiskErr = api.NewDiskNotAttachedError("fake-vm-id", "fake-disk-1", false)
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.DetachDiskCalled).To(BeTrue())
+				Expect(vmService.DeleteCalled).To(BeTrue())
+			})
+
+			Context("and the vm was created with an ephemeral disk", func() {
+				BeforeEach(func() {
+					// The shape create_vm gives the ephemeral disk
+					vmService.FindInstance.Disks[2] = &compute.AttachedDisk{
+						AutoDelete: true,
+						DeviceName: instance.EphemeralDiskDeviceName,
+						Source:     "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/disks/fake-vm-id-1",
+					}
+					diskService.FindDisks = map[string]disk.Disk{
+						"fake-vm-id-1": {
+							Name:   "fake-vm-id-1",
+							Zone:   "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone",
+							Labels: map[string]string{disk.EphemeralLabelKey: "true"},
+						},
+					}
+				})
+
+				It("leaves the ephemeral disk to be deleted with the vm and the persistent disk alone", func() {
+					_, err = deleteVM.Run("fake-vm-id")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(vmService.DetachDiskIDs).To(Equal([]string{"fake-disk-1"}))
+					Expect(vmService.DeleteCalled).To(BeTrue())
+					Expect(diskService.DeleteCalled).To(BeFalse())
+					Expect(registryClient.DeleteCalled).To(BeTrue())
+				})
+
+				Context("and the vm is deleted asynchronously", func() {
+					BeforeEach(func() {
+						os.Setenv("CPI_ASYNC_DELETE", "true")
+					})
+
+					AfterEach(func() {
+						os.Unsetenv("CPI_ASYNC_DELETE")
+					})
+
+					It("does not delete the still attached ephemeral disk", func() {
+						_, err = deleteVM.Run("fake-vm-id")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(vmService.DeleteCalled).To(BeTrue())
+						Expect(diskService.DeleteCalled).To(BeFalse())
+						Expect(registryClient.DeleteCalled).To(BeTrue())
+					})
+				})
+
+				Context("that is not auto-deleted", func() {
+					BeforeEach(func() {
+						vmService.FindInstance.Disks[2].AutoDelete = false
+					})
+
+					It("detaches the ephemeral disk before deleting the vm and then deletes it", func() {
+						_, err = deleteVM.Run("fake-vm-id")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(vmService.DeleteDetachedDisks).To(Equal([]string{"fake-disk-1", "fake-vm-id-1"}))
+						Expect(diskService.DeleteIDs).To(Equal([]string{"fake-vm-id-1"}))
+						Expect(registryClient.DeleteCalled).To(BeTrue())
+					})
+
+					It("ignores an ephemeral disk that is already deleted", func() {
+						diskService.DeleteErr = api.NewDiskNotFoundError("fake-vm-id-1", false)
+
+						_, err = deleteVM.Run("fake-vm-id")
+						Expect(err).NotTo(HaveOccurred())
+						Expect(diskService.DeleteCalled).To(BeTrue())
+						Expect(registryClient.DeleteCalled).To(BeTrue())
+					})
+
+					It("returns an error if diskService delete call returns an error", func() {
+						diskService.DeleteErr = errors.New("fake-disk-service-error")
+
+						_, err = deleteVM.Run("fake-vm-id")
+						Expect(err).To(HaveOccurred())
+						Expect(err.Error()).To(ContainSubstring("fake-disk-service-error"))
+						Expect(vmService.DeleteCalled).To(BeTrue())
+						Expect(registryClient.DeleteCalled).To(BeFalse())
+					})
+				})
+			})
+
+			It("returns an error if diskService find call returns an error", func() {
+				diskService.FindErr = errors.New("fake-disk-service-error")
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-disk-service-error"))
+				Expect(vmService.DeleteCalled).To(BeFalse())
+			})
+
+			It("returns an error if vmService detach disk call returns an error", func() {
+				vmService.DetachDiskErr = errors.New("fake-vm-service-error")
+
+				_, err = deleteVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(vmService.DetachDiskCalled).To(BeTrue())
+				Expect(vmService.DeleteCalled).To(BeFalse())
+				Expect(registryClient.DeleteCalled).To(BeFalse())
+			})
+		})
+
+		It("returns an error if vm is not found", func() {
+			vmService.FindFound = false
+
+			_, err = deleteVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(api.NewVMNotFoundError("fake-vm-id")))
+			Expect(vmService.DeleteCalled).To(BeFalse())
+		})
+
+		It("returns an error if vmService find call returns an error", func() {
+			vmService.FindErr = errors.New("fake-vm-service-error")
+
+			_, err = deleteVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			Expect(vmService.DeleteCalled).To(BeFalse())
+		})
+
 		It("returns an error if vmService delete call returns an error", func() {
 			vmService.DeleteErr = errors.New("fake-vm-service-error")
 
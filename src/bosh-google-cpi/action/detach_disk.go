@@ -27,10 +27,17 @@ func NewDetachDisk(
 func (dd DetachDisk) Run(vmCID VMCID, diskCID DiskCID) (interface{}, error) {
 	// Detach the disk
 	if err := dd.vmService.DetachDisk(string(vmCID), string(diskCID)); err != nil {
-		if _, ok := err.(api.CloudError); ok {
+		switch err.(type) {
+		case api.DiskNotAttachedError:
+			// Already detached, still make sure the agent settings are updated
+		case api.VMNotFoundError:
+			// Nothing is attached to a deleted VM
+			return nil, nil
+		case api.CloudError:
 			return nil, err
+		default:
+			return nil, bosherr.WrapErrorf(err, "Detaching disk '%s' from vm '%s", diskCID, vmCID)
 		}
-		return nil, bosherr.WrapErrorf(err, "Detaching disk '%s' from vm '%s", diskCID, vmCID)
 	}
 
 	// Read VM agent settings
@@ -11,6 +11,7 @@ import (
 	instancefakes "bosh-google-cpi/google/instance_service/fakes"
 	registryfakes "bosh-google-cpi/registry/fakes"
 
+	"bosh-google-cpi/api"
 	"bosh-google-cpi/registry"
 )
 
@@ -57,6 +58,26 @@ var _ = Describe("DetachDisk", func() {
 			Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
 		})
 
+		It("updates the agent settings if the disk is already detached", func() {
+			vmService.DetachDiskErr = api.NewDiskNotAttachedError("fake-vm-id", "fake-disk-id", false)
+
+			_, err = detachDisk.Run("fake-vm-id", "fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.DetachDiskCalled).To(BeTrue())
+			Expect(registryClient.UpdateCalled).To(BeTrue())
+			Expect(registryClient.UpdateSettings).To(Equal(expectedAgentSettings))
+		})
+
+		It("succeeds if the vm does not exist", func() {
+			vmService.DetachDiskErr = api.NewVMNotFoundError("fake-vm-id")
+
+			_, err = detachDisk.Run("fake-vm-id", "fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.DetachDiskCalled).To(BeTrue())
+			Expect(registryClient.FetchCalled).To(BeFalse())
+			Expect(registryClient.UpdateCalled).To(BeFalse())
+		})
+
 		It("returns an error if vmService detach disk call returns an error", func() {
 			vmService.DetachDiskErr = errors.New("fake-vm-service-error")
 
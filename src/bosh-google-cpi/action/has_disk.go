@@ -1,11 +1,36 @@
 package action
 
 import (
+	"regexp"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/google/disk_service"
 )
 
+// Disk CIDs are either a disk name, looked up across all zones, or the path
+// of a zonal or regional disk, optionally as part of its self link.
+var diskNameRe = regexp.MustCompile(`^[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?$`)
+var diskPathRe = regexp.MustCompile(`(?:^|/)(zones|regions)/([a-z0-9-]+)/disks/([a-z](?:[-a-z0-9]{0,61}[a-z0-9])?)$`)
+
+const malformedDiskCIDMessage = "Malformed disk CID, expected a disk name, 'zones/<zone>/disks/<name>' or 'regions/<region>/disks/<name>'"
+
+// parseDiskCID returns the name of the disk and, when the CID is a path, its
+// zone or region. It returns false when the CID is malformed.
+func parseDiskCID(diskCID DiskCID) (name string, zone string, region string, ok bool) {
+	switch {
+	case diskNameRe.MatchString(string(diskCID)):
+		return string(diskCID), "", "", true
+	case diskPathRe.MatchString(string(diskCID)):
+		match := diskPathRe.FindStringSubmatch(string(diskCID))
+		if match[1] == "regions" {
+			return match[3], "", match[2], true
+		}
+		return match[3], match[2], "", true
+	}
+	return "", "", "", false
+}
+
 type HasDisk struct {
 	diskService disk.Service
 }
@@ -19,7 +44,18 @@ func NewHasDisk(
 }
 
 func (hd HasDisk) Run(diskCID DiskCID) (bool, error) {
-	_, found, err := hd.diskService.Find(string(diskCID), "")
+	name, zone, region, ok := parseDiskCID(diskCID)
+	if !ok {
+		return false, bosherr.Errorf("Finding disk '%s': %s", diskCID, malformedDiskCIDMessage)
+	}
+
+	var found bool
+	var err error
+	if region != "" {
+		_, found, err = hd.diskService.FindInRegion(name, region)
+	} else {
+		_, found, err = hd.diskService.Find(name, zone)
+	}
 	if err != nil {
 		return false, bosherr.WrapErrorf(err, "Finding disk '%s'", diskCID)
 	}
@@ -53,5 +53,43 @@ var _ = Describe("HasDisk", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
 			Expect(diskService.FindCalled).To(BeTrue())
 		})
+
+		It("finds a zonal disk in its zone", func() {
+			diskService.FindFound = true
+
+			found, err = hasDisk.Run("projects/fake-project/zones/fake-zone/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskService.FindID).To(Equal("fake-disk-id"))
+			Expect(diskService.FindZone).To(Equal("fake-zone"))
+			Expect(diskService.FindInRegionCalled).To(BeFalse())
+		})
+
+		It("finds a regional disk in its region", func() {
+			diskService.FindInRegionFound = true
+
+			found, err = hasDisk.Run("regions/fake-region/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(diskService.FindInRegionID).To(Equal("fake-disk-id"))
+			Expect(diskService.FindInRegionRegion).To(Equal("fake-region"))
+			Expect(diskService.FindCalled).To(BeFalse())
+		})
+
+		It("returns false if the regional disk does not exist", func() {
+			diskService.FindInRegionFound = false
+
+			found, err = hasDisk.Run("regions/fake-region/disks/fake-disk-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error if the disk CID is malformed", func() {
+			_, err = hasDisk.Run("fake-region/fake-disk-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Malformed disk CID"))
+			Expect(diskService.FindCalled).To(BeFalse())
+			Expect(diskService.FindInRegionCalled).To(BeFalse())
+		})
 	})
 })
@@ -0,0 +1,76 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/util"
+)
+
+// ManagedResources are the VMs and disks carrying the labels BOSH sets, such
+// as director and deployment, to reconcile them with the director state.
+type ManagedResources struct {
+	VMs   []ManagedResource `json:"vms"`
+	Disks []ManagedResource `json:"disks"`
+}
+
+type ManagedResource struct {
+	CID       string `json:"cid"`
+	Zone      string `json:"zone"`
+	CreatedAt string `json:"created_at"`
+}
+
+type ListManagedResources struct {
+	vmService   instance.Service
+	diskService disk.Service
+}
+
+func NewListManagedResources(
+	vmService instance.Service,
+	diskService disk.Service,
+) ListManagedResources {
+	return ListManagedResources{
+		vmService:   vmService,
+		diskService: diskService,
+	}
+}
+
+func (lr ListManagedResources) Run(labels map[string]string) (ManagedResources, error) {
+	resources := ManagedResources{VMs: []ManagedResource{}, Disks: []ManagedResource{}}
+
+	// Listing every resource of the project is never what is meant
+	if len(labels) == 0 {
+		return resources, bosherr.Error("Listing managed resources: at least one label must be provided")
+	}
+	vmLabels := instance.Labels(labels)
+	if err := vmLabels.Validate(); err != nil {
+		return resources, bosherr.WrapError(err, "Listing managed resources")
+	}
+
+	vms, err := lr.vmService.FindByLabels(vmLabels)
+	if err != nil {
+		return resources, bosherr.WrapError(err, "Listing managed resources")
+	}
+	for _, vm := range vms {
+		resources.VMs = append(resources.VMs, ManagedResource{
+			CID:       vm.Name,
+			Zone:      util.ResourceSplitter(vm.Zone),
+			CreatedAt: vm.CreationTimestamp,
+		})
+	}
+
+	disks, err := lr.diskService.FindByLabels(labels)
+	if err != nil {
+		return resources, bosherr.WrapError(err, "Listing managed resources")
+	}
+	for _, d := range disks {
+		resources.Disks = append(resources.Disks, ManagedResource{
+			CID:       d.Name,
+			Zone:      util.ResourceSplitter(d.Zone),
+			CreatedAt: d.CreationTimestamp,
+		})
+	}
+
+	return resources, nil
+}
@@ -0,0 +1,86 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"google.golang.org/api/compute/v1"
+
+	. "bosh-google-cpi/action"
+
+	diskfakes "bosh-google-cpi/google/disk_service/fakes"
+	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("ListManagedResources", func() {
+	var (
+		err       error
+		resources ManagedResources
+
+		vmService   *instancefakes.FakeInstanceService
+		diskService *diskfakes.FakeDiskService
+
+		listManagedResources ListManagedResources
+	)
+
+	BeforeEach(func() {
+		vmService = &instancefakes.FakeInstanceService{}
+		diskService = &diskfakes.FakeDiskService{}
+		listManagedResources = NewListManagedResources(vmService, diskService)
+	})
+
+	Describe("Run", func() {
+		It("lists the vms and disks carrying the labels", func() {
+			vmService.FindByLabelsInstances = []*compute.Instance{
+				{Name: "fake-vm-id", Zone: "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone", CreationTimestamp: "fake-vm-timestamp"},
+			}
+			diskService.FindByLabelsDisks = []disk.Disk{
+				{Name: "fake-disk-id", Zone: "fake-zone", CreationTimestamp: "fake-disk-timestamp"},
+			}
+
+			resources, err = listManagedResources.Run(map[string]string{"director": "fake-director"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.FindByLabelsLabels).To(Equal(instance.Labels{"director": "fake-director"}))
+			Expect(diskService.FindByLabelsLabels).To(Equal(map[string]string{"director": "fake-director"}))
+			Expect(resources).To(Equal(ManagedResources{
+				VMs:   []ManagedResource{{CID: "fake-vm-id", Zone: "fake-zone", CreatedAt: "fake-vm-timestamp"}},
+				Disks: []ManagedResource{{CID: "fake-disk-id", Zone: "fake-zone", CreatedAt: "fake-disk-timestamp"}},
+			}))
+		})
+
+		It("returns empty lists when nothing is managed", func() {
+			resources, err = listManagedResources.Run(map[string]string{"director": "fake-director"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resources.VMs).To(BeEmpty())
+			Expect(resources.VMs).NotTo(BeNil())
+			Expect(resources.Disks).NotTo(BeNil())
+		})
+
+		It("returns an error if no label is provided", func() {
+			_, err = listManagedResources.Run(map[string]string{})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("at least one label must be provided"))
+			Expect(vmService.FindByLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if a label is invalid", func() {
+			_, err = listManagedResources.Run(map[string]string{"director": "Fake Director"})
+			Expect(err).To(HaveOccurred())
+			Expect(vmService.FindByLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if vmService find by labels call returns an error", func() {
+			vmService.FindByLabelsErr = errors.New("fake-vm-service-error")
+
+			_, err = listManagedResources.Run(map[string]string{"director": "fake-director"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			Expect(diskService.FindByLabelsCalled).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,144 @@
+package action
+
+import (
+	"fmt"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/disk_type_service"
+	"bosh-google-cpi/google/snapshot_service"
+	"bosh-google-cpi/util"
+)
+
+// MoveDisk recreates a disk in another zone from a snapshot, since zonal
+// disks can only be attached to VMs in their zone. The source disk is left
+// untouched. Every step finds the result of a previous attempt first, so a
+// failed move can be retried.
+type MoveDisk struct {
+	diskService     disk.Service
+	diskTypeService disktype.Service
+	snapshotService snapshot.Service
+}
+
+func NewMoveDisk(
+	diskService disk.Service,
+	diskTypeService disktype.Service,
+	snapshotService snapshot.Service,
+) MoveDisk {
+	return MoveDisk{
+		diskService:     diskService,
+		diskTypeService: diskTypeService,
+		snapshotService: snapshotService,
+	}
+}
+
+func (md MoveDisk) Run(diskCID DiskCID, zone string) (DiskCID, error) {
+	if zone == "" {
+		return "", bosherr.Error("Moving disk: a target zone must be provided")
+	}
+
+	d, found, err := md.diskService.Find(string(diskCID), "")
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Moving disk '%s'", diskCID)
+	}
+	if !found {
+		return "", api.NewDiskNotFoundError(string(diskCID), false)
+	}
+	if util.ResourceSplitter(d.Zone) == zone {
+		return diskCID, nil
+	}
+
+	// Intermediate snapshots left by a previous attempt are reused
+	snapshotLabels := map[string]string{
+		snapshot.DiskLabelKey:     string(diskCID),
+		snapshot.MoveZoneLabelKey: zone,
+	}
+	snapshots, err := md.snapshotService.FindByLabels(snapshotLabels)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Moving disk '%s'", diskCID)
+	}
+
+	movedDisk, err := md.findMovedDisk(diskCID, zone)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Moving disk '%s'", diskCID)
+	}
+	if movedDisk == "" {
+		if len(snapshots) == 0 {
+			s, err := md.createSnapshot(d, zone, snapshotLabels)
+			if err != nil {
+				return "", bosherr.WrapErrorf(err, "Moving disk '%s' to zone '%s'", diskCID, zone)
+			}
+			snapshots = append(snapshots, s)
+		}
+
+		if movedDisk, err = md.recreate(d, zone, snapshots[0].SelfLink); err != nil {
+			return "", bosherr.WrapErrorf(err, "Moving disk '%s' to zone '%s'", diskCID, zone)
+		}
+	}
+
+	// The snapshots are only needed until the disk is recreated
+	for _, s := range snapshots {
+		if err := md.snapshotService.Delete(s.Name); err != nil {
+			return "", bosherr.WrapErrorf(err, "Moving disk '%s' to zone '%s'", diskCID, zone)
+		}
+	}
+
+	return DiskCID(movedDisk), nil
+}
+
+// findMovedDisk finds the disk a previous attempt recreated in zone.
+func (md MoveDisk) findMovedDisk(diskCID DiskCID, zone string) (string, error) {
+	disks, err := md.diskService.FindByLabels(map[string]string{disk.MovedFromLabelKey: string(diskCID)})
+	if err != nil {
+		return "", err
+	}
+	for _, d := range disks {
+		if util.ResourceSplitter(d.Zone) == zone {
+			return d.Name, nil
+		}
+	}
+	return "", nil
+}
+
+func (md MoveDisk) createSnapshot(d disk.Disk, zone string, labels map[string]string) (snapshot.Snapshot, error) {
+	description := fmt.Sprintf("Move of disk %s to zone %s", d.Name, zone)
+	snapshotID, err := md.snapshotService.Create(d.Name, description, d.Zone, labels)
+	if err != nil {
+		return snapshot.Snapshot{}, err
+	}
+
+	s, found, err := md.snapshotService.Find(snapshotID)
+	if err != nil {
+		return snapshot.Snapshot{}, err
+	}
+	if !found {
+		return snapshot.Snapshot{}, bosherr.Errorf("Snapshot '%s' does not exist", snapshotID)
+	}
+	return s, nil
+}
+
+func (md MoveDisk) recreate(d disk.Disk, zone string, snapshotLink string) (string, error) {
+	// Disk types are zonal, the same type is looked up in the target zone
+	var diskType string
+	if d.Type != "" {
+		diskTypeName := util.ResourceSplitter(d.Type)
+		dt, found, err := md.diskTypeService.Find(diskTypeName, zone)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", bosherr.Errorf("Disk Type '%s' does not exists in zone '%s'", diskTypeName, zone)
+		}
+		diskType = dt.SelfLink
+	}
+
+	labels := map[string]string{}
+	for k, v := range d.Labels {
+		labels[k] = v
+	}
+	labels[disk.MovedFromLabelKey] = d.Name
+
+	return md.diskService.CreateFromSnapshot(snapshotLink, int(d.SizeGb), diskType, zone, labels, "")
+}
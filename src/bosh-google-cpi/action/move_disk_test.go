@@ -0,0 +1,158 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	diskfakes "bosh-google-cpi/google/disk_service/fakes"
+	disktypefakes "bosh-google-cpi/google/disk_type_service/fakes"
+	snapshotfakes "bosh-google-cpi/google/snapshot_service/fakes"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/disk_type_service"
+	"bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("MoveDisk", func() {
+	var (
+		err     error
+		diskCID DiskCID
+
+		diskService     *diskfakes.FakeDiskService
+		diskTypeService *disktypefakes.FakeDiskTypeService
+		snapshotService *snapshotfakes.FakeSnapshotService
+
+		moveDisk MoveDisk
+	)
+
+	BeforeEach(func() {
+		diskService = &diskfakes.FakeDiskService{}
+		diskTypeService = &disktypefakes.FakeDiskTypeService{}
+		snapshotService = &snapshotfakes.FakeSnapshotService{}
+		moveDisk = NewMoveDisk(diskService, diskTypeService, snapshotService)
+
+		diskService.FindFound = true
+		diskService.FindDisk = disk.Disk{
+			Name:   "fake-disk",
+			Zone:   "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone",
+			Type:   "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/diskTypes/pd-ssd",
+			SizeGb: 32,
+			Labels: map[string]string{"director": "fake-director"},
+		}
+		diskService.CreateID = "fake-moved-disk"
+		diskTypeService.FindFound = true
+		diskTypeService.FindDiskType = disktype.DiskType{SelfLink: "fake-other-zone-pd-ssd-self-link"}
+		snapshotService.CreateID = "fake-snapshot"
+		snapshotService.FindFound = true
+		snapshotService.FindSnapshot = snapshot.Snapshot{Name: "fake-snapshot", SelfLink: "fake-snapshot-self-link"}
+	})
+
+	Describe("Run", func() {
+		It("snapshots the disk, recreates it in the target zone and deletes the snapshot", func() {
+			diskCID, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal(DiskCID("fake-moved-disk")))
+
+			Expect(snapshotService.CreateDiskID).To(Equal("fake-disk"))
+			Expect(snapshotService.CreateZone).To(Equal(diskService.FindDisk.Zone))
+			Expect(snapshotService.CreateLabels).To(Equal(map[string]string{
+				snapshot.DiskLabelKey:     "fake-disk",
+				snapshot.MoveZoneLabelKey: "fake-other-zone",
+			}))
+			Expect(snapshotService.FindByLabelsLabels).To(Equal(snapshotService.CreateLabels))
+
+			Expect(diskService.CreateFromSnapshotSnapshot).To(Equal("fake-snapshot-self-link"))
+			Expect(diskService.CreateSize).To(Equal(32))
+			Expect(diskService.CreateDiskType).To(Equal("fake-other-zone-pd-ssd-self-link"))
+			Expect(diskService.CreateZone).To(Equal("fake-other-zone"))
+			Expect(diskService.CreateLabels).To(Equal(map[string]string{
+				"director":             "fake-director",
+				disk.MovedFromLabelKey: "fake-disk",
+			}))
+
+			Expect(snapshotService.DeleteIDs).To(Equal([]string{"fake-snapshot"}))
+		})
+
+		It("returns the disk as is when it is already in the target zone", func() {
+			diskCID, err = moveDisk.Run("fake-disk", "fake-zone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal(DiskCID("fake-disk")))
+			Expect(snapshotService.CreateCalled).To(BeFalse())
+			Expect(diskService.CreateCalled).To(BeFalse())
+		})
+
+		It("reuses the snapshot of a previous attempt", func() {
+			snapshotService.FindByLabelsSnapshots = []snapshot.Snapshot{{Name: "fake-previous-snapshot", SelfLink: "fake-previous-snapshot-self-link"}}
+
+			diskCID, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal(DiskCID("fake-moved-disk")))
+			Expect(snapshotService.CreateCalled).To(BeFalse())
+			Expect(diskService.CreateFromSnapshotSnapshot).To(Equal("fake-previous-snapshot-self-link"))
+			Expect(snapshotService.DeleteIDs).To(Equal([]string{"fake-previous-snapshot"}))
+		})
+
+		It("reuses the disk of a previous attempt and only cleans up its snapshot", func() {
+			snapshotService.FindByLabelsSnapshots = []snapshot.Snapshot{{Name: "fake-previous-snapshot"}}
+			diskService.FindByLabelsDisks = []disk.Disk{
+				{Name: "fake-stale-disk", Zone: "fake-third-zone"},
+				{Name: "fake-previous-disk", Zone: "fake-other-zone"},
+			}
+
+			diskCID, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskCID).To(Equal(DiskCID("fake-previous-disk")))
+			Expect(diskService.FindByLabelsLabels).To(Equal(map[string]string{disk.MovedFromLabelKey: "fake-disk"}))
+			Expect(snapshotService.CreateCalled).To(BeFalse())
+			Expect(diskService.CreateCalled).To(BeFalse())
+			Expect(snapshotService.DeleteIDs).To(Equal([]string{"fake-previous-snapshot"}))
+		})
+
+		It("returns an error if the target zone is not set", func() {
+			_, err = moveDisk.Run("fake-disk", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("a target zone must be provided"))
+			Expect(diskService.FindCalled).To(BeFalse())
+		})
+
+		It("returns a disk not found error if the disk does not exist", func() {
+			diskService.FindFound = false
+
+			_, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(api.DiskNotFoundError{}))
+		})
+
+		It("returns an error if the disk type does not exist in the target zone", func() {
+			diskTypeService.FindFound = false
+
+			_, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Disk Type 'pd-ssd' does not exists in zone 'fake-other-zone'"))
+			Expect(diskService.CreateCalled).To(BeFalse())
+		})
+
+		It("keeps the snapshot if the disk cannot be recreated", func() {
+			diskService.CreateErr = errors.New("fake-disk-service-error")
+
+			_, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-disk-service-error"))
+			Expect(snapshotService.DeleteCalled).To(BeFalse())
+		})
+
+		It("returns an error if snapshotService create call returns an error", func() {
+			snapshotService.CreateErr = errors.New("fake-snapshot-service-error")
+
+			_, err = moveDisk.Run("fake-disk", "fake-other-zone")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-snapshot-service-error"))
+			Expect(diskService.CreateCalled).To(BeFalse())
+		})
+	})
+})
@@ -36,6 +36,9 @@ func (ns Networks) AsInstanceServiceNetworks() instance.Networks {
 			Tags:                network.CloudProperties.Tags,
 			EphemeralExternalIP: network.CloudProperties.EphemeralExternalIP,
 			IPForwarding:        network.CloudProperties.IPForwarding,
+			ReservedIP:          network.CloudProperties.ReservedIP,
+			NetworkTier:         network.CloudProperties.NetworkTier,
+			InternalAddressName: network.CloudProperties.InternalAddressName,
 		}
 	}
 
@@ -1,31 +1,78 @@
 package action
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
+	bogcconfig "bosh-google-cpi/google/config"
 	"bosh-google-cpi/google/instance_service"
 )
 
 type RebootVM struct {
-	vmService instance.Service
+	vmService         instance.Service
+	stopStartOnReboot bool
+	waitForRunning    bool
+	gracePeriod       time.Duration
+	timeout           time.Duration
 }
 
 func NewRebootVM(
 	vmService instance.Service,
+	stopStartOnReboot bool,
+	waitForRunning bool,
+	gracePeriod time.Duration,
+	timeout time.Duration,
 ) RebootVM {
 	return RebootVM{
-		vmService: vmService,
+		vmService:         vmService,
+		stopStartOnReboot: stopStartOnReboot,
+		waitForRunning:    waitForRunning,
+		gracePeriod:       gracePeriod,
+		timeout:           timeout,
 	}
 }
 
 func (rv RebootVM) Run(vmCID VMCID) (interface{}, error) {
-	if err := rv.vmService.Reboot(string(vmCID)); err != nil {
+	if err := rv.reboot(string(vmCID)); err != nil {
 		if _, ok := err.(api.CloudError); ok {
 			return nil, err
 		}
 		return nil, bosherr.WrapErrorf(err, "Rebooting vm '%s'", vmCID)
 	}
 
+	// Give the agent time to come back before the director talks to it
+	if rv.waitForRunning {
+		if err := rv.vmService.WaitForRunning(string(vmCID), rv.timeout); err != nil {
+			if _, ok := err.(api.CloudError); ok {
+				return nil, err
+			}
+			return nil, bosherr.WrapErrorf(err, "Rebooting vm '%s'", vmCID)
+		}
+		time.Sleep(rv.gracePeriod)
+	}
+
 	return nil, nil
 }
+
+func (rv RebootVM) reboot(id string) error {
+	// The reboot method the VM was created with overrides the configured one
+	stopStart := rv.stopStartOnReboot
+	method, err := rv.vmService.RebootMethod(id)
+	if err != nil {
+		return err
+	}
+	if method != "" {
+		stopStart = bogcconfig.IsStopStartRebootMethod(method)
+	}
+
+	if !stopStart {
+		return rv.vmService.Reboot(id)
+	}
+
+	if err := rv.vmService.Stop(id); err != nil {
+		return err
+	}
+	return rv.vmService.Start(id)
+}
@@ -2,6 +2,7 @@ package action_test
 
 import (
 	"errors"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -22,7 +23,7 @@ var _ = Describe("RebootVM", func() {
 
 	BeforeEach(func() {
 		vmService = &instancefakes.FakeInstanceService{}
-		rebootVM = NewRebootVM(vmService)
+		rebootVM = NewRebootVM(vmService, false, false, 0, 0)
 	})
 
 	Describe("Run", func() {
@@ -30,6 +31,7 @@ var _ = Describe("RebootVM", func() {
 			_, err = rebootVM.Run("fake-vm-id")
 			Expect(err).NotTo(HaveOccurred())
 			Expect(vmService.RebootCalled).To(BeTrue())
+			Expect(vmService.WaitForRunningCalled).To(BeFalse())
 		})
 
 		It("returns an error if vmService reboot call returns an error", func() {
@@ -40,5 +42,101 @@ var _ = Describe("RebootVM", func() {
 			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
 			Expect(vmService.RebootCalled).To(BeTrue())
 		})
+
+		Context("when the reboot method is stop/start", func() {
+			BeforeEach(func() {
+				rebootVM = NewRebootVM(vmService, true, false, 0, 0)
+			})
+
+			It("stops and starts the vm", func() {
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.RebootCalled).To(BeFalse())
+				Expect(vmService.StopCalled).To(BeTrue())
+				Expect(vmService.StartCalled).To(BeTrue())
+			})
+
+			It("returns an error if vmService stop call returns an error", func() {
+				vmService.StopErr = errors.New("fake-vm-service-error")
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(vmService.StopCalled).To(BeTrue())
+				Expect(vmService.StartCalled).To(BeFalse())
+			})
+
+			It("returns an error if vmService start call returns an error", func() {
+				vmService.StartErr = errors.New("fake-vm-service-error")
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(vmService.StopCalled).To(BeTrue())
+				Expect(vmService.StartCalled).To(BeTrue())
+			})
+		})
+
+		Context("when the vm was created with a reboot method", func() {
+			It("stops and starts the vm created with the stop/start method", func() {
+				vmService.RebootMethodName = "STOP_START"
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.RebootMethodCalled).To(BeTrue())
+				Expect(vmService.RebootCalled).To(BeFalse())
+				Expect(vmService.StopCalled).To(BeTrue())
+				Expect(vmService.StartCalled).To(BeTrue())
+			})
+
+			It("resets the vm created with the reset method", func() {
+				rebootVM = NewRebootVM(vmService, true, false, 0, 0)
+				vmService.RebootMethodName = "HARD"
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.RebootCalled).To(BeTrue())
+				Expect(vmService.StopCalled).To(BeFalse())
+			})
+
+			It("returns an error if vmService reboot method call returns an error", func() {
+				vmService.RebootMethodErr = errors.New("fake-vm-service-error")
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+				Expect(vmService.RebootCalled).To(BeFalse())
+			})
+		})
+
+		Context("when waiting for the vm to be running", func() {
+			BeforeEach(func() {
+				rebootVM = NewRebootVM(vmService, false, true, 10*time.Millisecond, 5*time.Minute)
+			})
+
+			It("waits for the vm to be running up to the timeout", func() {
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.RebootCalled).To(BeTrue())
+				Expect(vmService.WaitForRunningCalled).To(BeTrue())
+				Expect(vmService.WaitForRunningTimeout).To(Equal(5 * time.Minute))
+			})
+
+			It("does not wait if the reboot fails", func() {
+				vmService.RebootErr = errors.New("fake-vm-service-error")
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(vmService.WaitForRunningCalled).To(BeFalse())
+			})
+
+			It("returns an error if vmService wait for running call returns an error", func() {
+				vmService.WaitForRunningErr = errors.New("fake-vm-service-error")
+
+				_, err = rebootVM.Run("fake-vm-id")
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			})
+		})
 	})
 })
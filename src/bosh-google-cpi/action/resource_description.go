@@ -0,0 +1,55 @@
+package action
+
+import (
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+// Placeholders the descriptions of created resources may use.
+const (
+	DescriptionDirectorUUIDPlaceholder = "{director_uuid}"
+	DescriptionDeploymentPlaceholder   = "{deployment}"
+)
+
+// ResourceDescription describes the VMs, disks and images created by the
+// actions. Resources use the description of their cloud properties, or else
+// Default, and get the Google default one when both are empty.
+type ResourceDescription struct {
+	Default      string
+	DirectorUUID string
+}
+
+// Expand returns the description of a resource of deployment, with the
+// placeholders replaced. The deployment is empty when it is not known.
+func (d ResourceDescription) Expand(description string, deployment string) (string, error) {
+	if description == "" {
+		description = d.Default
+	}
+
+	expanded := strings.NewReplacer(
+		DescriptionDirectorUUIDPlaceholder, d.DirectorUUID,
+		DescriptionDeploymentPlaceholder, deployment,
+	).Replace(description)
+	if len(expanded) > util.MaxDescriptionLength {
+		return "", bosherr.Errorf("Description is %d characters long, at most %d are allowed", len(expanded), util.MaxDescriptionLength)
+	}
+
+	return expanded, nil
+}
+
+// envDeployment returns the deployment of a VM from env.bosh.groups, which
+// the director sets to the director name, the deployment name and then the
+// instance group name and their combinations.
+func envDeployment(env Environment) string {
+	boshenv, _ := env["bosh"].(map[string]interface{})
+	groups, _ := boshenv["groups"].([]interface{})
+	if len(groups) < 2 {
+		return ""
+	}
+
+	deployment, _ := groups[1].(string)
+	return deployment
+}
@@ -0,0 +1,76 @@
+package action
+
+import (
+	"regexp"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_service"
+)
+
+// Only metadata keys that are valid label keys become disk labels.
+var labelKeyRe = regexp.MustCompile(`^[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?$`)
+
+type SetDiskMetadata struct {
+	diskService disk.Service
+}
+
+func NewSetDiskMetadata(
+	diskService disk.Service,
+) SetDiskMetadata {
+	return SetDiskMetadata{
+		diskService: diskService,
+	}
+}
+
+func (sdm SetDiskMetadata) Run(diskCID DiskCID, diskMetadata DiskMetadata) (interface{}, error) {
+	labels, err := diskLabels(diskMetadata)
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Setting metadata for disk '%s'", diskCID)
+	}
+
+	name, zone, region, ok := parseDiskCID(diskCID)
+	if !ok {
+		return nil, bosherr.Errorf("Setting metadata for disk '%s': %s", diskCID, malformedDiskCIDMessage)
+	}
+
+	if region != "" {
+		err = sdm.diskService.SetLabelsInRegion(name, region, labels)
+	} else {
+		err = sdm.diskService.SetLabels(name, zone, labels)
+	}
+	if err != nil {
+		if _, ok := err.(api.CloudError); ok {
+			return nil, err
+		}
+		return nil, bosherr.WrapErrorf(err, "Setting metadata for disk '%s'", diskCID)
+	}
+
+	return nil, nil
+}
+
+// diskLabels maps the disk metadata to the disk labels. Entries whose key is
+// not a valid label key are skipped, values are lower cased and sanitized,
+// and an empty value removes the label.
+func diskLabels(diskMetadata DiskMetadata) (map[string]string, error) {
+	labels := map[string]string{}
+	for k, v := range diskMetadata {
+		if !labelKeyRe.MatchString(k) {
+			continue
+		}
+		if v == "" {
+			labels[k] = ""
+			continue
+		}
+
+		label, err := instance.SafeLabel(strings.ToLower(v))
+		if err != nil {
+			return nil, bosherr.Errorf("Label value %q for key %q is invalid. Must match regular expression %q once '/', '_' and ':' are replaced with '-'", v, k, labelKeyRe.String())
+		}
+		labels[k] = label
+	}
+	return labels, nil
+}
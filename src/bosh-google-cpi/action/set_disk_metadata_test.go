@@ -0,0 +1,107 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	"bosh-google-cpi/api"
+	diskfakes "bosh-google-cpi/google/disk_service/fakes"
+)
+
+var _ = Describe("SetDiskMetadata", func() {
+	var (
+		err          error
+		diskMetadata DiskMetadata
+
+		diskService *diskfakes.FakeDiskService
+
+		setDiskMetadata SetDiskMetadata
+	)
+
+	BeforeEach(func() {
+		diskService = &diskfakes.FakeDiskService{}
+		setDiskMetadata = NewSetDiskMetadata(diskService)
+		diskMetadata = DiskMetadata{
+			"director":    "fake-director",
+			"deployment":  "fake_deployment",
+			"attached_at": "2020-01-02T03:04:05Z",
+			"job":         "",
+		}
+	})
+
+	Describe("Run", func() {
+		It("sets the label-safe metadata as disk labels", func() {
+			_, err = setDiskMetadata.Run("fake-disk-id", diskMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.SetLabelsCalled).To(BeTrue())
+			Expect(diskService.SetLabelsID).To(Equal("fake-disk-id"))
+			Expect(diskService.SetLabelsZone).To(Equal(""))
+			Expect(diskService.SetLabelsLabels).To(Equal(map[string]string{
+				"director":   "fake-director",
+				"deployment": "fake-deployment",
+				"job":        "",
+			}))
+		})
+
+		It("sets the labels of a zonal disk given its path", func() {
+			_, err = setDiskMetadata.Run("zones/fake-zone/disks/fake-disk-id", diskMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.SetLabelsID).To(Equal("fake-disk-id"))
+			Expect(diskService.SetLabelsZone).To(Equal("fake-zone"))
+			Expect(diskService.SetLabelsInRegionCalled).To(BeFalse())
+		})
+
+		It("sets the labels of a regional disk", func() {
+			_, err = setDiskMetadata.Run("projects/fake-project/regions/fake-region/disks/fake-disk-id", diskMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.SetLabelsCalled).To(BeFalse())
+			Expect(diskService.SetLabelsInRegionCalled).To(BeTrue())
+			Expect(diskService.SetLabelsInRegionID).To(Equal("fake-disk-id"))
+			Expect(diskService.SetLabelsInRegionRegion).To(Equal("fake-region"))
+			Expect(diskService.SetLabelsInRegionLabels).To(HaveKeyWithValue("deployment", "fake-deployment"))
+		})
+
+		It("skips the metadata whose key is not a valid label key", func() {
+			diskMetadata["Instance Group"] = "fake-instance-group"
+
+			_, err = setDiskMetadata.Run("fake-disk-id", diskMetadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.SetLabelsLabels).NotTo(HaveKey("Instance Group"))
+		})
+
+		It("returns an error if a label value is invalid", func() {
+			diskMetadata["index"] = "0.1"
+
+			_, err = setDiskMetadata.Run("fake-disk-id", diskMetadata)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Label value "0.1" for key "index" is invalid`))
+			Expect(diskService.SetLabelsCalled).To(BeFalse())
+		})
+
+		It("returns an error if the disk CID is malformed", func() {
+			_, err = setDiskMetadata.Run("zones/fake-zone/fake-disk-id", diskMetadata)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Malformed disk CID"))
+			Expect(diskService.SetLabelsCalled).To(BeFalse())
+		})
+
+		It("returns the disk not found error as is", func() {
+			diskService.SetLabelsErr = api.NewDiskNotFoundError("fake-disk-id", false)
+
+			_, err = setDiskMetadata.Run("fake-disk-id", diskMetadata)
+			Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk-id", false)))
+		})
+
+		It("returns an error if diskService set labels call returns an error", func() {
+			diskService.SetLabelsErr = errors.New("fake-disk-service-error")
+
+			_, err = setDiskMetadata.Run("fake-disk-id", diskMetadata)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Setting metadata for disk 'fake-disk-id': fake-disk-service-error"))
+		})
+	})
+})
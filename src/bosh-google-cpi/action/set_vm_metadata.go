@@ -4,18 +4,28 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/firewall_service"
 	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/util"
+
+	"google.golang.org/api/compute/v1"
 )
 
 type SetVMMetadata struct {
-	vmService instance.Service
+	vmService           instance.Service
+	firewallService     firewall.Service
+	deploymentIsolation bool
 }
 
 func NewSetVMMetadata(
 	vmService instance.Service,
+	firewallService firewall.Service,
+	deploymentIsolation bool,
 ) SetVMMetadata {
 	return SetVMMetadata{
-		vmService: vmService,
+		vmService:           vmService,
+		firewallService:     firewallService,
+		deploymentIsolation: deploymentIsolation,
 	}
 }
 
@@ -27,5 +37,64 @@ func (svm SetVMMetadata) Run(vmCID VMCID, vmMetadata VMMetadata) (interface{}, e
 		return nil, bosherr.WrapErrorf(err, "Setting metadata for vm '%s'", vmCID)
 	}
 
+	// The deployment is only known once the metadata is set
+	if deployment := vmMetadata["deployment"]; svm.deploymentIsolation && deployment != "" {
+		if err := svm.isolateDeployment(vmCID, deployment); err != nil {
+			if _, ok := err.(api.CloudError); ok {
+				return nil, err
+			}
+			return nil, bosherr.WrapErrorf(err, "Isolating deployment '%s' of vm '%s'", deployment, vmCID)
+		}
+	}
+
 	return nil, nil
 }
+
+// isolateDeployment tags the VM with its deployment tag and makes sure the
+// firewall rule allowing traffic between the deployment VMs exists.
+func (svm SetVMMetadata) isolateDeployment(vmCID VMCID, deployment string) error {
+	tag := firewall.DeploymentTag(deployment)
+
+	vm, found, err := svm.vmService.Find(string(vmCID), "")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return api.NewVMNotFoundError(string(vmCID))
+	}
+	if len(vm.NetworkInterfaces) == 0 {
+		return bosherr.Errorf("No network interface on vm '%s'", vmCID)
+	}
+	network := vm.NetworkInterfaces[0].Network
+
+	rule, found, err := svm.firewallService.Find(tag)
+	if err != nil {
+		return err
+	}
+	if found {
+		if !rule.IsManaged() {
+			return bosherr.Errorf("Firewall rule '%s' already exists and is not managed by BOSH", tag)
+		}
+		if rule.Network != network {
+			return bosherr.Errorf("Firewall rule '%s' is for network '%s', not for the vm network '%s'", tag, util.ResourceSplitter(rule.Network), util.ResourceSplitter(network))
+		}
+	} else {
+		if err := svm.firewallService.Create(tag, network, tag); err != nil {
+			return err
+		}
+	}
+
+	tags := &compute.Tags{}
+	if vm.Tags != nil {
+		tags.Items = vm.Tags.Items
+		tags.Fingerprint = vm.Tags.Fingerprint
+	}
+	for _, item := range tags.Items {
+		if item == tag {
+			return nil
+		}
+	}
+	tags.Items = append(tags.Items, tag)
+
+	return svm.vmService.SetTags(string(vmCID), vm.Zone, tags)
+}
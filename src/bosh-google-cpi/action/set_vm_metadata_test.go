@@ -8,9 +8,13 @@ import (
 
 	. "bosh-google-cpi/action"
 
+	firewallfakes "bosh-google-cpi/google/firewall_service/fakes"
 	instancefakes "bosh-google-cpi/google/instance_service/fakes"
 
+	"bosh-google-cpi/google/firewall_service"
 	"bosh-google-cpi/google/instance_service"
+
+	"google.golang.org/api/compute/v1"
 )
 
 var _ = Describe("SetVMMetadata", func() {
@@ -18,7 +22,8 @@ var _ = Describe("SetVMMetadata", func() {
 		err        error
 		vmMetadata VMMetadata
 
-		vmService *instancefakes.FakeInstanceService
+		vmService       *instancefakes.FakeInstanceService
+		firewallService *firewallfakes.FakeFirewallService
 
 		setVMMetadata SetVMMetadata
 	)
@@ -30,7 +35,8 @@ var _ = Describe("SetVMMetadata", func() {
 			"index":      "fake-index",
 		}
 		vmService = &instancefakes.FakeInstanceService{}
-		setVMMetadata = NewSetVMMetadata(vmService)
+		firewallService = &firewallfakes.FakeFirewallService{}
+		setVMMetadata = NewSetVMMetadata(vmService, firewallService, false)
 	})
 
 	Describe("Run", func() {
@@ -39,6 +45,83 @@ var _ = Describe("SetVMMetadata", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(vmService.SetMetadataCalled).To(BeTrue())
 			Expect(vmService.SetMetadataVMMetadata).To(Equal(instance.Metadata(vmMetadata)))
+			Expect(vmService.SetTagsCalled).To(BeFalse())
+			Expect(firewallService.FindCalled).To(BeFalse())
+		})
+
+		Context("when deployment isolation is enabled", func() {
+			var tag string
+
+			BeforeEach(func() {
+				tag = firewall.DeploymentTag("fake-deployment")
+				setVMMetadata = NewSetVMMetadata(vmService, firewallService, true)
+				vmService.FindFound = true
+				vmService.FindInstance = &compute.Instance{
+					Name:              "fake-vm-id",
+					Zone:              "fake-zone",
+					NetworkInterfaces: []*compute.NetworkInterface{{Network: "fake-network-self-link"}},
+					Tags:              &compute.Tags{Items: []string{"fake-tag"}, Fingerprint: "fake-fingerprint"},
+				}
+			})
+
+			It("creates the deployment firewall rule and tags the vm", func() {
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(firewallService.CreateCalled).To(BeTrue())
+				Expect(firewallService.CreateID).To(Equal(tag))
+				Expect(firewallService.CreateNetwork).To(Equal("fake-network-self-link"))
+				Expect(firewallService.CreateTag).To(Equal(tag))
+				Expect(vmService.SetTagsCalled).To(BeTrue())
+				Expect(vmService.SetTagsTags).To(Equal(&compute.Tags{Items: []string{"fake-tag", tag}, Fingerprint: "fake-fingerprint"}))
+			})
+
+			It("reuses the deployment firewall rule", func() {
+				firewallService.FindFound = true
+				firewallService.FindFirewall = firewall.Firewall{Name: tag, Description: "Firewall rule managed by BOSH", Network: "fake-network-self-link"}
+
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(firewallService.CreateCalled).To(BeFalse())
+				Expect(vmService.SetTagsCalled).To(BeTrue())
+			})
+
+			It("does not tag vms already tagged", func() {
+				vmService.FindInstance.Tags.Items = []string{tag}
+
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(vmService.SetTagsCalled).To(BeFalse())
+			})
+
+			It("returns an error if a firewall rule not managed by BOSH has the same name", func() {
+				firewallService.FindFound = true
+				firewallService.FindFirewall = firewall.Firewall{Name: tag, Description: "fake-description", Network: "fake-network-self-link"}
+
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Firewall rule '" + tag + "' already exists and is not managed by BOSH"))
+				Expect(vmService.SetTagsCalled).To(BeFalse())
+			})
+
+			It("returns an error if the firewall rule is for another network", func() {
+				firewallService.FindFound = true
+				firewallService.FindFirewall = firewall.Firewall{Name: tag, Description: "Firewall rule managed by BOSH", Network: "fake-other-network-self-link"}
+
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("is for network 'fake-other-network-self-link'"))
+				Expect(vmService.SetTagsCalled).To(BeFalse())
+			})
+
+			It("returns an error if the vm has no network interface", func() {
+				vmService.FindInstance.NetworkInterfaces = nil
+
+				_, err = setVMMetadata.Run("fake-vm-id", vmMetadata)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("No network interface on vm 'fake-vm-id'"))
+				Expect(firewallService.CreateCalled).To(BeFalse())
+				Expect(vmService.SetTagsCalled).To(BeFalse())
+			})
 		})
 
 		It("returns an error if vmService set metadata call returns an error", func() {
@@ -7,7 +7,9 @@ import (
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_service"
 	"bosh-google-cpi/google/snapshot_service"
+	"bosh-google-cpi/util"
 )
 
 type SnapshotDisk struct {
@@ -28,8 +30,20 @@ func NewSnapshotDisk(
 func (sd SnapshotDisk) Run(diskCID DiskCID, metadata SnapshotMetadata) (SnapshotCID, error) {
 	var description string
 
+	name, zone, region, ok := parseDiskCID(diskCID)
+	if !ok {
+		return "", bosherr.Errorf("Creating disk snapshot: %s", malformedDiskCIDMessage)
+	}
+
 	// Find the disk
-	disk, found, err := sd.diskService.Find(string(diskCID), "")
+	var disk disk.Disk
+	var found bool
+	var err error
+	if region != "" {
+		disk, found, err = sd.diskService.FindInRegion(name, region)
+	} else {
+		disk, found, err = sd.diskService.Find(name, zone)
+	}
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Failed to find disk '%s'", diskCID)
 	}
@@ -42,10 +56,46 @@ func (sd SnapshotDisk) Run(diskCID DiskCID, metadata SnapshotMetadata) (Snapshot
 		description = fmt.Sprintf("%s/%s/%s", metadata.Deployment, metadata.Job, metadata.Index)
 	}
 
-	snapshot, err := sd.snapshotService.Create(string(diskCID), description, disk.Zone)
+	labels, err := sd.snapshotLabels(diskCID, disk, metadata)
+	if err != nil {
+		return "", bosherr.WrapError(err, "Creating disk snapshot")
+	}
+
+	var snapshot string
+	if region != "" {
+		snapshot, err = sd.snapshotService.CreateInRegion(name, description, region, labels)
+	} else {
+		snapshot, err = sd.snapshotService.Create(name, description, disk.Zone, labels)
+	}
 	if err != nil {
 		return "", bosherr.WrapError(err, "Creating disk snapshot")
 	}
 
 	return SnapshotCID(snapshot), nil
 }
+
+// snapshotLabels labels the snapshot with the disk and VM it is taken from,
+// and with the deployment and director, on top of the manifest tags.
+func (sd SnapshotDisk) snapshotLabels(diskCID DiskCID, d disk.Disk, metadata SnapshotMetadata) (map[string]string, error) {
+	labels, _ := instance.Metadata(metadata.CustomTags).Split()
+
+	provenance := map[string]string{
+		snapshot.DiskLabelKey: string(diskCID),
+		"deployment":          metadata.Deployment,
+		"director":            metadata.DirectorName,
+	}
+	if len(d.Users) > 0 {
+		provenance[snapshot.VMLabelKey] = util.ResourceSplitter(d.Users[0])
+	}
+	for k, v := range provenance {
+		if l, err := instance.SafeLabel(v); err == nil {
+			labels[k] = l
+		}
+	}
+
+	if err := labels.Validate(); err != nil {
+		return nil, err
+	}
+
+	return labels, nil
+}
@@ -53,6 +53,40 @@ var _ = Describe("SnapshotDisk", func() {
 				Expect(snapshotID).To(Equal(SnapshotCID("fake-snapshot-id")))
 			})
 
+			It("labelled with the disk, deployment and director", func() {
+				metadata.DirectorName = "fake_director"
+
+				_, err = snapshotDisk.Run("fake-disk-id", metadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshotService.CreateLabels).To(Equal(map[string]string{
+					"bosh-disk-id": "fake-disk-id",
+					"deployment":   "fake-deployment",
+					"director":     "fake-director",
+				}))
+			})
+
+			It("labelled with the VM the disk is attached to", func() {
+				diskService.FindDisk.Users = []string{"https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone/instances/fake-vm-id"}
+
+				_, err = snapshotDisk.Run("fake-disk-id", metadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshotService.CreateLabels).To(HaveKeyWithValue("bosh-vm-id", "fake-vm-id"))
+			})
+
+			It("labelled with the custom tags, which cannot override the provenance labels", func() {
+				metadata.CustomTags = map[string]string{
+					"team":         "Fake Team",
+					"cost-center":  "fake_center",
+					"bosh-disk-id": "other-disk",
+				}
+
+				_, err = snapshotDisk.Run("fake-disk-id", metadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(snapshotService.CreateLabels).To(HaveKeyWithValue("cost-center", "fake-center"))
+				Expect(snapshotService.CreateLabels).To(HaveKeyWithValue("bosh-disk-id", "fake-disk-id"))
+				Expect(snapshotService.CreateLabels).NotTo(HaveKey("team"))
+			})
+
 			Context("when metadata is empty", func() {
 				BeforeEach(func() {
 					metadata = SnapshotMetadata{}
@@ -71,6 +105,42 @@ var _ = Describe("SnapshotDisk", func() {
 			})
 		})
 
+		It("snapshots a zonal disk given its path", func() {
+			_, err = snapshotDisk.Run("zones/fake-zone/disks/fake-disk-id", metadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskService.FindID).To(Equal("fake-disk-id"))
+			Expect(diskService.FindZone).To(Equal("fake-zone"))
+			Expect(snapshotService.CreateDiskID).To(Equal("fake-disk-id"))
+			Expect(snapshotService.CreateInRegionCalled).To(BeFalse())
+		})
+
+		Context("with a regional disk", func() {
+			BeforeEach(func() {
+				diskService.FindInRegionFound = true
+			})
+
+			It("snapshots the disk in its region", func() {
+				snapshotID, err = snapshotDisk.Run("regions/fake-region/disks/fake-disk-id", metadata)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diskService.FindInRegionID).To(Equal("fake-disk-id"))
+				Expect(diskService.FindInRegionRegion).To(Equal("fake-region"))
+				Expect(snapshotService.CreateCalled).To(BeFalse())
+				Expect(snapshotService.CreateInRegionCalled).To(BeTrue())
+				Expect(snapshotService.CreateInRegionDiskID).To(Equal("fake-disk-id"))
+				Expect(snapshotService.CreateInRegionRegion).To(Equal("fake-region"))
+				Expect(snapshotService.CreateInRegionLabels).To(HaveKeyWithValue("bosh-disk-id", "regions-fake-region-disks-fake-disk-id"))
+				Expect(snapshotID).To(Equal(SnapshotCID("fake-snapshot-id")))
+			})
+
+			It("returns an error if the regional disk is not found", func() {
+				diskService.FindInRegionFound = false
+
+				_, err = snapshotDisk.Run("regions/fake-region/disks/fake-disk-id", metadata)
+				Expect(err).To(Equal(api.NewDiskNotFoundError("regions/fake-region/disks/fake-disk-id", false)))
+				Expect(snapshotService.CreateInRegionCalled).To(BeFalse())
+			})
+		})
+
 		It("returns an error if diskService find call returns an error", func() {
 			diskService.FindErr = errors.New("fake-disk-service-error")
 
@@ -0,0 +1,31 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/instance_service"
+)
+
+type StartVM struct {
+	vmService instance.Service
+}
+
+func NewStartVM(
+	vmService instance.Service,
+) StartVM {
+	return StartVM{
+		vmService: vmService,
+	}
+}
+
+func (sv StartVM) Run(vmCID VMCID) (interface{}, error) {
+	if err := sv.vmService.Start(string(vmCID)); err != nil {
+		if _, ok := err.(api.CloudError); ok {
+			return nil, err
+		}
+		return nil, bosherr.WrapErrorf(err, "Starting vm '%s'", vmCID)
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,54 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	"bosh-google-cpi/api"
+
+	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+)
+
+var _ = Describe("StartVM", func() {
+	var (
+		err error
+
+		vmService *instancefakes.FakeInstanceService
+
+		startVM StartVM
+	)
+
+	BeforeEach(func() {
+		vmService = &instancefakes.FakeInstanceService{}
+		startVM = NewStartVM(vmService)
+	})
+
+	Describe("Run", func() {
+		It("starts the vm", func() {
+			_, err = startVM.Run("fake-vm-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.StartCalled).To(BeTrue())
+		})
+
+		It("returns an error if vmService start call returns an error", func() {
+			vmService.StartErr = errors.New("fake-vm-service-error")
+
+			_, err = startVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			Expect(vmService.StartCalled).To(BeTrue())
+		})
+
+		It("returns the cloud error if the vm does not exist", func() {
+			vmService.StartErr = api.NewVMNotFoundError("fake-vm-id")
+
+			_, err = startVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(api.NewVMNotFoundError("fake-vm-id")))
+		})
+	})
+})
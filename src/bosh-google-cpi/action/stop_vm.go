@@ -0,0 +1,31 @@
+package action
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/instance_service"
+)
+
+type StopVM struct {
+	vmService instance.Service
+}
+
+func NewStopVM(
+	vmService instance.Service,
+) StopVM {
+	return StopVM{
+		vmService: vmService,
+	}
+}
+
+func (sv StopVM) Run(vmCID VMCID) (interface{}, error) {
+	if err := sv.vmService.Stop(string(vmCID)); err != nil {
+		if _, ok := err.(api.CloudError); ok {
+			return nil, err
+		}
+		return nil, bosherr.WrapErrorf(err, "Stopping vm '%s'", vmCID)
+	}
+
+	return nil, nil
+}
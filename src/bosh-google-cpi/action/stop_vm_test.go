@@ -0,0 +1,54 @@
+package action_test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/action"
+
+	"bosh-google-cpi/api"
+
+	instancefakes "bosh-google-cpi/google/instance_service/fakes"
+)
+
+var _ = Describe("StopVM", func() {
+	var (
+		err error
+
+		vmService *instancefakes.FakeInstanceService
+
+		stopVM StopVM
+	)
+
+	BeforeEach(func() {
+		vmService = &instancefakes.FakeInstanceService{}
+		stopVM = NewStopVM(vmService)
+	})
+
+	Describe("Run", func() {
+		It("stops the vm", func() {
+			_, err = stopVM.Run("fake-vm-id")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vmService.StopCalled).To(BeTrue())
+		})
+
+		It("returns an error if vmService stop call returns an error", func() {
+			vmService.StopErr = errors.New("fake-vm-service-error")
+
+			_, err = stopVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-vm-service-error"))
+			Expect(vmService.StopCalled).To(BeTrue())
+		})
+
+		It("returns the cloud error if the vm does not exist", func() {
+			vmService.StopErr = api.NewVMNotFoundError("fake-vm-id")
+
+			_, err = stopVM.Run("fake-vm-id")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(Equal(api.NewVMNotFoundError("fake-vm-id")))
+		})
+	})
+})
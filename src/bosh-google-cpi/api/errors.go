@@ -45,6 +45,23 @@ func (e VMCreationFailedError) Type() string   { return "Bosh::Clouds::VMCreatio
 func (e VMCreationFailedError) Error() string  { return fmt.Sprintf("VM failed to create: %v", e.reason) }
 func (e VMCreationFailedError) CanRetry() bool { return e.canRetry }
 
+// VMCapacityError is a VM creation failure caused by the zone running out of
+// resources or the project running out of quota. It can always be retried,
+// later or in another zone.
+type VMCapacityError struct {
+	reason string
+}
+
+func NewVMCapacityError(reason string) VMCapacityError {
+	return VMCapacityError{reason: reason}
+}
+
+func (e VMCapacityError) Type() string { return "Bosh::Clouds::VMCreationFailed" }
+func (e VMCapacityError) Error() string {
+	return fmt.Sprintf("VM failed to create, not enough capacity: %v", e.reason)
+}
+func (e VMCapacityError) CanRetry() bool { return true }
+
 type NoDiskSpaceError struct {
 	diskID   string
 	canRetry bool
@@ -4,4 +4,8 @@ type AcceleratorType struct {
 	Name     string
 	SelfLink string
 	Zone     string
+
+	// MaximumCardsPerInstance is the number of cards of this type that can
+	// be attached to a single instance
+	MaximumCardsPerInstance int64
 }
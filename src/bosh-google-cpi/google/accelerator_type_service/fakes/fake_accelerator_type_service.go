@@ -9,9 +9,14 @@ type FakeAcceleratorTypeService struct {
 	FindFound           bool
 	FindAcceleratorType acceleratortype.AcceleratorType
 	FindErr             error
+
+	FindAcceleratorTypes map[string]acceleratortype.AcceleratorType
 }
 
 func (d *FakeAcceleratorTypeService) Find(id string, zone string) (acceleratortype.AcceleratorType, bool, error) {
 	d.FindCalled = true
+	if acceleratorType, ok := d.FindAcceleratorTypes[id]; ok {
+		return acceleratorType, true, d.FindErr
+	}
 	return d.FindAcceleratorType, d.FindFound, d.FindErr
 }
@@ -22,6 +22,8 @@ func (m GoogleAcceleratorTypeService) Find(id string, zone string) (AcceleratorT
 		Name:     acceleratorTypeItem.Name,
 		SelfLink: acceleratorTypeItem.SelfLink,
 		Zone:     acceleratorTypeItem.Zone,
+
+		MaximumCardsPerInstance: acceleratorTypeItem.MaximumCardsPerInstance,
 	}
 	return acceleratorType, true, nil
 }
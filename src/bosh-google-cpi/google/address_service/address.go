@@ -1,6 +1,52 @@
 package address
 
+import (
+	"google.golang.org/api/compute/v1"
+)
+
+const (
+	AddressTypeInternal = "INTERNAL"
+	StatusReserved      = "RESERVED"
+)
+
 type Address struct {
-	Name     string
-	SelfLink string
+	Name        string
+	SelfLink    string
+	Address     string
+	AddressType string
+	Region      string
+	Status      string
+	Subnetwork  string
+	Users       []string
+	Description string
+}
+
+// IsInternal reports whether the Address is reserved from a subnetwork range
+// rather than being an external IP.
+func (a Address) IsInternal() bool {
+	return a.AddressType == AddressTypeInternal
+}
+
+// IsManaged reports whether the Address was reserved by the CPI.
+func (a Address) IsManaged() bool {
+	return a.Description == googleAddressDescription
+}
+
+// IsInUse reports whether the Address is assigned to a resource.
+func (a Address) IsInUse() bool {
+	return a.Status != StatusReserved || len(a.Users) > 0
+}
+
+func newAddress(addressItem *compute.Address) Address {
+	return Address{
+		Name:        addressItem.Name,
+		SelfLink:    addressItem.SelfLink,
+		Address:     addressItem.Address,
+		AddressType: addressItem.AddressType,
+		Region:      addressItem.Region,
+		Status:      addressItem.Status,
+		Subnetwork:  addressItem.Subnetwork,
+		Users:       addressItem.Users,
+		Description: addressItem.Description,
+	}
 }
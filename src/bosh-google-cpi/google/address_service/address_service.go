@@ -3,4 +3,6 @@ package address
 type Service interface {
 	Find(id string, region string) (Address, bool, error)
 	FindByIP(ipAddress string) (Address, bool, error)
+	Reserve(id string, region string, subnetwork string, ipAddress string) (Address, error)
+	Delete(id string, region string) error
 }
@@ -0,0 +1,42 @@
+package address_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/address_service"
+)
+
+var _ = Describe("Address", func() {
+	Describe("IsInternal", func() {
+		It("returns true for an INTERNAL address", func() {
+			Expect(Address{AddressType: AddressTypeInternal}.IsInternal()).To(BeTrue())
+		})
+
+		It("returns false for an external address", func() {
+			Expect(Address{AddressType: "EXTERNAL"}.IsInternal()).To(BeFalse())
+			Expect(Address{}.IsInternal()).To(BeFalse())
+		})
+	})
+
+	Describe("IsManaged", func() {
+		It("returns true for an address reserved by the CPI", func() {
+			Expect(Address{Description: "Address managed by BOSH"}.IsManaged()).To(BeTrue())
+		})
+
+		It("returns false for other addresses", func() {
+			Expect(Address{Description: "fake-description"}.IsManaged()).To(BeFalse())
+		})
+	})
+
+	Describe("IsInUse", func() {
+		It("returns false for a reserved address without users", func() {
+			Expect(Address{Status: StatusReserved}.IsInUse()).To(BeFalse())
+		})
+
+		It("returns true for an address in use", func() {
+			Expect(Address{Status: "IN_USE"}.IsInUse()).To(BeTrue())
+			Expect(Address{Status: StatusReserved, Users: []string{"fake-user"}}.IsInUse()).To(BeTrue())
+		})
+	})
+})
@@ -5,19 +5,42 @@ import (
 )
 
 type FakeAddressService struct {
-	FindCalled  bool
-	FindFound   bool
-	FindAddress address.Address
-	FindErr     error
+	FindCalled    bool
+	FindID        string
+	FindRegion    string
+	FindFound     bool
+	FindAddress   address.Address
+	FindAddresses []address.Address
+	FindErr       error
 
 	FindByIPCalled  bool
 	FindByIPFound   bool
 	FindByIPAddress address.Address
 	FindByIPErr     error
+
+	ReserveCalled     bool
+	ReserveID         string
+	ReserveRegion     string
+	ReserveSubnetwork string
+	ReserveIPAddress  string
+	ReserveAddress    address.Address
+	ReserveErr        error
+
+	DeleteCalled bool
+	DeleteID     string
+	DeleteRegion string
+	DeleteErr    error
 }
 
 func (n *FakeAddressService) Find(id string, region string) (address.Address, bool, error) {
 	n.FindCalled = true
+	n.FindID = id
+	n.FindRegion = region
+	if len(n.FindAddresses) > 0 {
+		foundAddress := n.FindAddresses[0]
+		n.FindAddresses = n.FindAddresses[1:]
+		return foundAddress, n.FindFound, n.FindErr
+	}
 	return n.FindAddress, n.FindFound, n.FindErr
 }
 
@@ -25,3 +48,19 @@ func (n *FakeAddressService) FindByIP(ipAddress string) (address.Address, bool,
 	n.FindByIPCalled = true
 	return n.FindByIPAddress, n.FindByIPFound, n.FindByIPErr
 }
+
+func (n *FakeAddressService) Reserve(id string, region string, subnetwork string, ipAddress string) (address.Address, error) {
+	n.ReserveCalled = true
+	n.ReserveID = id
+	n.ReserveRegion = region
+	n.ReserveSubnetwork = subnetwork
+	n.ReserveIPAddress = ipAddress
+	return n.ReserveAddress, n.ReserveErr
+}
+
+func (n *FakeAddressService) Delete(id string, region string) error {
+	n.DeleteCalled = true
+	n.DeleteID = id
+	n.DeleteRegion = region
+	return n.DeleteErr
+}
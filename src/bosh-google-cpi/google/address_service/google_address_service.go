@@ -3,25 +3,30 @@ package address
 import (
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
+	"bosh-google-cpi/google/operation_service"
 	"google.golang.org/api/compute/v1"
 )
 
 const googleAddressServiceLogTag = "GoogleAddressService"
+const googleAddressDescription = "Address managed by BOSH"
 
 type GoogleAddressService struct {
-	project        string
-	computeService *compute.Service
-	logger         boshlog.Logger
+	project          string
+	computeService   *compute.Service
+	operationService operation.Service
+	logger           boshlog.Logger
 }
 
 func NewGoogleAddressService(
 	project string,
 	computeService *compute.Service,
+	operationService operation.Service,
 	logger boshlog.Logger,
 ) GoogleAddressService {
 	return GoogleAddressService{
-		project:        project,
-		computeService: computeService,
-		logger:         logger,
+		project:          project,
+		computeService:   computeService,
+		operationService: operationService,
+		logger:           logger,
 	}
 }
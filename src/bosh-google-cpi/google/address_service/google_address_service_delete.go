@@ -0,0 +1,21 @@
+package address
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+func (a GoogleAddressService) Delete(id string, region string) error {
+	a.logger.Debug(googleAddressServiceLogTag, "Deleting Google Address '%s' in region '%s'", id, region)
+	operation, err := a.computeService.Addresses.Delete(a.project, util.ResourceSplitter(region), id).Do()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to delete Google Address '%s'", id)
+	}
+
+	if _, err = a.operationService.Waiter(operation, "", region); err != nil {
+		return bosherr.WrapErrorf(err, "Failed to delete Google Address '%s'", id)
+	}
+
+	return nil
+}
@@ -21,11 +21,7 @@ func (a GoogleAddressService) Find(id string, region string) (Address, bool, err
 		for _, addressItems := range addresses.Items {
 			for _, addressItem := range addressItems.Addresses {
 				// Return the first address (it can only be 1 address with the same name across all regions)
-				address := Address{
-					Name:     addressItem.Name,
-					SelfLink: addressItem.SelfLink,
-				}
-				return address, true, nil
+				return newAddress(addressItem), true, nil
 			}
 		}
 
@@ -42,9 +38,5 @@ func (a GoogleAddressService) Find(id string, region string) (Address, bool, err
 		return Address{}, false, bosherr.WrapErrorf(err, "Failed to find Google Address '%s' in region '%s'", id, region)
 	}
 
-	address := Address{
-		Name:     addressItem.Name,
-		SelfLink: addressItem.SelfLink,
-	}
-	return address, true, nil
+	return newAddress(addressItem), true, nil
 }
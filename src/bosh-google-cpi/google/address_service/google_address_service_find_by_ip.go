@@ -17,11 +17,7 @@ func (a GoogleAddressService) FindByIP(ipAddress string) (Address, bool, error)
 	for _, addressItems := range addresses.Items {
 		for _, addressItem := range addressItems.Addresses {
 			// Return the first address (it can only be 1 address with the same IP across all regions)
-			address := Address{
-				Name:     addressItem.Name,
-				SelfLink: addressItem.SelfLink,
-			}
-			return address, true, nil
+			return newAddress(addressItem), true, nil
 		}
 	}
 
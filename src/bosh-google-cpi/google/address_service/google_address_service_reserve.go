@@ -0,0 +1,40 @@
+package address
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+	"google.golang.org/api/compute/v1"
+)
+
+// Reserve reserves ipAddress from the range of subnetwork as an INTERNAL
+// address managed by the CPI.
+func (a GoogleAddressService) Reserve(id string, region string, subnetwork string, ipAddress string) (Address, error) {
+	addressItem := &compute.Address{
+		Name:        id,
+		Description: googleAddressDescription,
+		Address:     ipAddress,
+		AddressType: AddressTypeInternal,
+		Subnetwork:  subnetwork,
+	}
+
+	a.logger.Debug(googleAddressServiceLogTag, "Reserving Google Address '%s' for IP '%s' in region '%s'", id, ipAddress, region)
+	operation, err := a.computeService.Addresses.Insert(a.project, util.ResourceSplitter(region), addressItem).Do()
+	if err != nil {
+		return Address{}, bosherr.WrapErrorf(err, "Failed to reserve Google Address '%s' for IP '%s'", id, ipAddress)
+	}
+
+	if _, err = a.operationService.Waiter(operation, "", region); err != nil {
+		return Address{}, bosherr.WrapErrorf(err, "Failed to reserve Google Address '%s' for IP '%s'", id, ipAddress)
+	}
+
+	address, found, err := a.Find(id, region)
+	if err != nil {
+		return Address{}, err
+	}
+	if !found {
+		return Address{}, bosherr.Errorf("Google Address '%s' does not exist after being reserved", id)
+	}
+
+	return address, nil
+}
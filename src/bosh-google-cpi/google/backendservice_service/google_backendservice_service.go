@@ -50,7 +50,10 @@ func (i GoogleBackendServiceService) AddInstance(id, vmLink string) error {
 		return bosherr.Errorf("Could not find VM zone in %q", vmLink)
 	}
 	i.logger.Debug(googleBackendServiceServiceLogTag, "Adding instance %q to all backends for Backend Service %q in zone %q", vmLink, id, zone)
-	region := util.RegionFromZone(zone)
+	region, err := util.RegionFromZone(zone)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Adding instance %q to Backend Service %q", vmLink, id)
+	}
 	backendService, found, err := i.find(id, region)
 	if err != nil {
 		return err
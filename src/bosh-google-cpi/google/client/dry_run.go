@@ -0,0 +1,80 @@
+package client
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	dryRunLogTag = "DryRunTransport"
+
+	// DryRunOperationName is the name of the operation returned for the
+	// requests skipped in dry run. It is reported as done when polled.
+	DryRunOperationName = "operation-dry-run"
+
+	dryRunOperation = `{"kind": "compute#operation", "name": "` + DryRunOperationName + `", "status": "DONE", "progress": 100}`
+)
+
+// DryRunTransport skips the requests that would mutate resources, logging
+// them instead and answering them with a done operation. Reads go through,
+// so resources are still looked up and validated.
+type DryRunTransport struct {
+	Base   http.RoundTripper
+	logger boshlog.Logger
+}
+
+// NewDryRunTransport returns a transport only sending the reads through base.
+func NewDryRunTransport(base http.RoundTripper, logger boshlog.Logger) *DryRunTransport {
+	return &DryRunTransport{
+		Base:   base,
+		logger: logger,
+	}
+}
+
+func (rt *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch {
+	case isMutatingRequest(req):
+		rt.logger.Warn(dryRunLogTag, "Dry run, not sending %s %s", req.Method, RedactURL(req.URL))
+		if req.Body != nil {
+			req.Body.Close()
+		}
+	case strings.HasSuffix(req.URL.Path, "/operations/"+DryRunOperationName):
+		rt.logger.Debug(dryRunLogTag, "Dry run, operation %s is done", RedactURL(req.URL))
+	default:
+		return rt.Base.RoundTrip(req)
+	}
+
+	return &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       ioutil.NopCloser(strings.NewReader(dryRunOperation)),
+		Request:    req,
+	}, nil
+}
+
+// readOnlyPostMethods are the API methods reading resources with a POST.
+var readOnlyPostMethods = []string{
+	"/getHealth",
+	"/listInstances",
+}
+
+func isMutatingRequest(req *http.Request) bool {
+	switch req.Method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	case http.MethodPost:
+		for _, method := range readOnlyPostMethods {
+			if strings.HasSuffix(req.URL.Path, method) {
+				return false
+			}
+		}
+	}
+	return true
+}
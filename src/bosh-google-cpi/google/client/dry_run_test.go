@@ -0,0 +1,157 @@
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/storage/v1"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/client"
+
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/firewall_service"
+	"bosh-google-cpi/google/image_service"
+	"bosh-google-cpi/google/instance_service"
+	"bosh-google-cpi/google/operation_service"
+	"bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("DryRunTransport", func() {
+	var (
+		server   *httptest.Server
+		requests []string
+		client   *http.Client
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r.Method+" "+r.URL.Path)
+			w.Header().Set("Content-Type", "application/json")
+			switch {
+			case r.Method == "GET" && r.URL.Path == "/fake-project/aggregated/instances":
+				fmt.Fprint(w, `{"items": {"zones/fake-zone": {"instances": [{"name": "fake-vm", "zone": "fake-zone", "status": "RUNNING", "metadata": {"fingerprint": "fake-fingerprint"}}]}}}`)
+			case r.Method == "GET" && r.URL.Path == "/fake-project/aggregated/disks":
+				fmt.Fprint(w, `{"items": {"zones/fake-zone": {"disks": [{"name": "fake-disk", "zone": "fake-zone", "status": "READY"}]}}}`)
+			case r.Method == "GET" && r.URL.Path == "/fake-project/global/snapshots/fake-snapshot":
+				fmt.Fprint(w, `{"name": "fake-snapshot", "status": "READY"}`)
+			case r.Method == "GET" && r.URL.Path == "/fake-project/global/images/fake-image":
+				fmt.Fprint(w, `{"name": "fake-image", "status": "READY"}`)
+			case r.Method == "GET":
+				w.WriteHeader(http.StatusNotFound)
+				fmt.Fprint(w, `{"error": {"code": 404, "message": "not found"}}`)
+			default:
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			}
+		}))
+		client = &http.Client{Transport: NewDryRunTransport(http.DefaultTransport, boshlog.NewLogger(boshlog.LevelNone))}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	mutatingRequests := func() []string {
+		var mutating []string
+		for _, request := range requests {
+			if !strings.HasPrefix(request, "GET ") {
+				mutating = append(mutating, request)
+			}
+		}
+		return mutating
+	}
+
+	It("sends the reads", func() {
+		resp, err := client.Get(server.URL + "/fake-project/global/images/fake-image")
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(Equal([]string{"GET /fake-project/global/images/fake-image"}))
+	})
+
+	It("answers the mutating requests with a done operation without sending them", func() {
+		for _, method := range []string{"POST", "PUT", "PATCH", "DELETE"} {
+			req, err := http.NewRequest(method, server.URL+"/fake-project/global/images", strings.NewReader("{}"))
+			Expect(err).NotTo(HaveOccurred())
+			resp, err := client.Do(req)
+			Expect(err).NotTo(HaveOccurred())
+
+			var operation compute.Operation
+			Expect(json.NewDecoder(resp.Body).Decode(&operation)).To(Succeed())
+			resp.Body.Close()
+			Expect(operation.Name).To(Equal(DryRunOperationName))
+			Expect(operation.Status).To(Equal("DONE"))
+		}
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("sends the reads made with a POST", func() {
+		for _, path := range []string{
+			"/fake-project/zones/fake-zone/instanceGroups/fake-instance-group/listInstances",
+			"/fake-project/global/backendServices/fake-backend-service/getHealth",
+			"/fake-project/regions/fake-region/targetPools/fake-target-pool/getHealth",
+		} {
+			resp, err := client.Post(server.URL+path, "application/json", strings.NewReader("{}"))
+			Expect(err).NotTo(HaveOccurred())
+			resp.Body.Close()
+		}
+		Expect(requests).To(Equal([]string{
+			"POST /fake-project/zones/fake-zone/instanceGroups/fake-instance-group/listInstances",
+			"POST /fake-project/global/backendServices/fake-backend-service/getHealth",
+			"POST /fake-project/regions/fake-region/targetPools/fake-target-pool/getHealth",
+		}))
+	})
+
+	It("reports the skipped operations as done without polling them", func() {
+		resp, err := client.Get(server.URL + "/fake-project/zones/fake-zone/operations/" + DryRunOperationName)
+		Expect(err).NotTo(HaveOccurred())
+		resp.Body.Close()
+		Expect(resp.StatusCode).To(Equal(http.StatusOK))
+		Expect(requests).To(BeEmpty())
+	})
+
+	It("does not mutate anything whichever service sends the request", func() {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		computeService, err := compute.New(client)
+		Expect(err).NotTo(HaveOccurred())
+		computeService.BasePath = server.URL + "/"
+		computeServiceB, err := computebeta.New(client)
+		Expect(err).NotTo(HaveOccurred())
+		computeServiceB.BasePath = server.URL + "/"
+		storageService, err := storage.New(client)
+		Expect(err).NotTo(HaveOccurred())
+		storageService.BasePath = server.URL + "/storage/"
+
+		operationService := operation.NewGoogleOperationService("fake-project", computeService, computeServiceB, logger, time.Millisecond, 0, false, operation.Timeouts{})
+		instanceService := instance.NewGoogleInstanceService("fake-project", computeService, computeServiceB, nil, nil, nil, operationService, nil, nil, &fakeuuid.FakeGenerator{}, logger, false, 0, false, "", "")
+		diskService := disk.NewGoogleDiskService("fake-project", computeService, computeServiceB, operationService, &fakeuuid.FakeGenerator{}, logger, false, "", 0)
+		snapshotService := snapshot.NewGoogleSnapshotService("fake-project", computeService, computeServiceB, operationService, &fakeuuid.FakeGenerator{}, logger, false, "", 0)
+		firewallService := firewall.NewGoogleFirewallService("fake-project", computeService, operationService, logger)
+		imageService := image.NewGoogleImageService("fake-project", computeService, storageService, operationService, &fakeuuid.FakeGenerator{}, logger, "fake-bucket", false, 0)
+
+		Expect(instanceService.Stop("fake-vm")).To(Succeed())
+		Expect(instanceService.Reboot("fake-vm")).To(Succeed())
+		Expect(instanceService.SetMetadata("fake-vm", instance.Metadata{"deployment": "fake-deployment", "fake-key": "fake value"})).To(Succeed())
+		Expect(instanceService.SetTags("fake-vm", "fake-zone", &compute.Tags{Items: []string{"fake-tag"}})).To(Succeed())
+		Expect(diskService.SetLabels("fake-disk", "", map[string]string{"deployment": "fake-deployment"})).To(Succeed())
+		Expect(diskService.Delete("fake-disk")).To(Succeed())
+		Expect(snapshotService.Delete("fake-snapshot")).To(Succeed())
+		Expect(firewallService.Create("fake-firewall", "fake-network", "fake-tag")).To(Succeed())
+		Expect(firewallService.Delete("fake-firewall")).To(Succeed())
+		Expect(imageService.Delete("fake-image")).To(Succeed())
+
+		Expect(requests).NotTo(BeEmpty())
+		Expect(mutatingRequests()).To(BeEmpty())
+	})
+})
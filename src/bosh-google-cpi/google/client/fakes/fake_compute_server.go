@@ -0,0 +1,120 @@
+package fakes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/storage/v1"
+)
+
+// FakeComputeServer emulates the Compute Engine API for service tests.
+// Handlers are registered by method and path, requests without a handler are
+// answered with a 404 error, like the API answers for missing resources.
+// Handlers run one at a time.
+type FakeComputeServer struct {
+	server *httptest.Server
+
+	mutex    sync.Mutex
+	handlers map[string]http.HandlerFunc
+	requests []string
+}
+
+// NewFakeComputeServer starts a server without any handler.
+func NewFakeComputeServer() *FakeComputeServer {
+	s := &FakeComputeServer{handlers: map[string]http.HandlerFunc{}}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+func (s *FakeComputeServer) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the server.
+func (s *FakeComputeServer) URL() string {
+	return s.server.URL
+}
+
+// Client returns an HTTP client for the server.
+func (s *FakeComputeServer) Client() *http.Client {
+	return s.server.Client()
+}
+
+// Handle registers the handler of method requests to path, replacing any
+// handler registered before.
+func (s *FakeComputeServer) Handle(method string, path string, handler http.HandlerFunc) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[method+" "+path] = handler
+}
+
+// Respond registers a handler answering method requests to path with body.
+func (s *FakeComputeServer) Respond(method string, path string, body string) {
+	s.Handle(method, path, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	})
+}
+
+// Requests returns the method and path of the requests served so far.
+func (s *FakeComputeServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+// ComputeService returns a v1 compute service calling the server.
+func (s *FakeComputeServer) ComputeService() *compute.Service {
+	computeService, err := compute.New(s.Client())
+	if err != nil {
+		panic(err)
+	}
+	computeService.BasePath = s.URL() + "/"
+	return computeService
+}
+
+// ComputeBetaService returns a beta compute service calling the server,
+// under the same paths as the v1 service.
+func (s *FakeComputeServer) ComputeBetaService() *computebeta.Service {
+	computeServiceB, err := computebeta.New(s.Client())
+	if err != nil {
+		panic(err)
+	}
+	computeServiceB.BasePath = s.URL() + "/"
+	return computeServiceB
+}
+
+// StorageService returns a storage service calling the server under
+// /storage/.
+func (s *FakeComputeServer) StorageService() *storage.Service {
+	storageService, err := storage.New(s.Client())
+	if err != nil {
+		panic(err)
+	}
+	storageService.BasePath = s.URL() + "/storage/"
+	return storageService
+}
+
+// WriteError answers the request with an error of the API.
+func WriteError(w http.ResponseWriter, code int, message string) {
+	w.WriteHeader(code)
+	fmt.Fprintf(w, `{"error": {"code": %d, "message": %q}}`, code, message)
+}
+
+func (s *FakeComputeServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+	w.Header().Set("Content-Type", "application/json")
+
+	handler, ok := s.handlers[r.Method+" "+r.URL.Path]
+	if !ok {
+		WriteError(w, http.StatusNotFound, "not found")
+		return
+	}
+	handler(w, r)
+}
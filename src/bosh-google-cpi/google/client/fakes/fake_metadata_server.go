@@ -0,0 +1,89 @@
+package fakes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+)
+
+const metadataHostEnv = "GCE_METADATA_HOST"
+
+// FakeMetadataServer emulates the GCE metadata server so the application
+// default credentials path can be exercised without reaching GCP. Once
+// started it is advertised through GCE_METADATA_HOST, which makes the
+// metadata package consider the process to be running on GCE.
+type FakeMetadataServer struct {
+	ProjectID   string
+	AccessToken string
+
+	server       *httptest.Server
+	previousHost string
+	hadHost      bool
+
+	mutex    sync.Mutex
+	requests []string
+}
+
+func NewFakeMetadataServer() *FakeMetadataServer {
+	return &FakeMetadataServer{
+		ProjectID:   "fake-project",
+		AccessToken: "fake-access-token",
+	}
+}
+
+// Start serves the canned metadata and points GCE_METADATA_HOST at it.
+func (s *FakeMetadataServer) Start() {
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	s.previousHost, s.hadHost = os.LookupEnv(metadataHostEnv)
+	os.Setenv(metadataHostEnv, s.Host())
+}
+
+// Close stops the server and restores GCE_METADATA_HOST.
+func (s *FakeMetadataServer) Close() {
+	if s.hadHost {
+		os.Setenv(metadataHostEnv, s.previousHost)
+	} else {
+		os.Unsetenv(metadataHostEnv)
+	}
+	s.server.Close()
+}
+
+// Host returns the host:port the server listens on.
+func (s *FakeMetadataServer) Host() string {
+	return strings.TrimPrefix(s.server.URL, "http://")
+}
+
+// Requests returns the metadata paths requested so far.
+func (s *FakeMetadataServer) Requests() []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]string(nil), s.requests...)
+}
+
+func (s *FakeMetadataServer) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	s.requests = append(s.requests, r.URL.Path)
+	s.mutex.Unlock()
+
+	if r.Header.Get("Metadata-Flavor") != "Google" {
+		http.Error(w, "Missing Metadata-Flavor header", http.StatusForbidden)
+		return
+	}
+	w.Header().Set("Metadata-Flavor", "Google")
+
+	switch strings.TrimPrefix(r.URL.Path, "/computeMetadata/v1/") {
+	case "project/project-id":
+		w.Write([]byte(s.ProjectID))
+	case "instance/service-accounts/default/token":
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": s.AccessToken,
+			"expires_in":   3600,
+			"token_type":   "Bearer",
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
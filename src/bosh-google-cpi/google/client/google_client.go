@@ -27,6 +27,9 @@ const (
 	// Configuration for retrier.
 	retries         = 12
 	firstRetrySleep = 50 * time.Millisecond
+
+	// Stemcell tarball uploads log their progress every 30 seconds by default.
+	defaultUploadProgressInterval = 30 * time.Second
 )
 
 type GoogleClient struct {
@@ -46,6 +49,10 @@ func NewGoogleClient(
 	userAgent := config.GetUserAgent()
 
 	if config.JSONKey != "" {
+		if err := validateJSONKey(config.JSONKey); err != nil {
+			return GoogleClient{}, bosherr.WrapError(err, "Reading Google JSON Key")
+		}
+
 		computeJwtConf, err := oauthgoogle.JWTConfigFromJSON([]byte(config.JSONKey), computeScope)
 		if err != nil {
 			return GoogleClient{}, bosherr.WrapError(err, "Reading Google JSON Key")
@@ -72,6 +79,23 @@ func NewGoogleClient(
 		}
 	}
 
+	// Log each API request, only when asked to as it is verbose
+	if config.LogHTTP() {
+		computeClient.Transport = NewLoggingTransport(computeClient.Transport, config.DebugHTTPBodies, logger)
+		storageClient.Transport = NewLoggingTransport(storageClient.Transport, config.DebugHTTPBodies, logger)
+	}
+
+	// Pace compute requests under the configured rate, if any
+	if config.MaxQPS > 0 {
+		computeClient.Transport = NewRateLimitTransport(computeClient.Transport, config.MaxQPS)
+	}
+
+	// Skip the mutating requests when dry running, whichever call sends them
+	if config.DryRun {
+		computeClient.Transport = NewDryRunTransport(computeClient.Transport, logger)
+		storageClient.Transport = NewDryRunTransport(storageClient.Transport, logger)
+	}
+
 	// Custom RoundTripper for retries
 	computeRetrier := &RetryTransport{
 		Base:            computeClient.Transport,
@@ -92,13 +116,19 @@ func NewGoogleClient(
 	}
 	computeServiceB.UserAgent = userAgent
 
-	// Custom RoundTripper for retries
+	// Custom RoundTripper for retries, tuned apart from the compute one
 	storageRetrier := &RetryTransport{
 		Base:            storageClient.Transport,
 		MaxRetries:      retries,
 		FirstRetrySleep: firstRetrySleep,
 		logger:          logger,
 	}
+	if config.StorageMaxRetries != nil {
+		storageRetrier.MaxRetries = *config.StorageMaxRetries
+	}
+	if config.StorageRetryBackoffMs > 0 {
+		storageRetrier.FirstRetrySleep = time.Duration(config.StorageRetryBackoffMs) * time.Millisecond
+	}
 	storageClient.Transport = storageRetrier
 	storageService, err := storage.New(storageClient)
 	if err != nil {
@@ -119,6 +149,23 @@ func (c GoogleClient) Project() string {
 	return c.Config.Project
 }
 
+// NetworkProject is the project networks and subnetworks are looked up in,
+// unless a network names its own project.
+func (c GoogleClient) NetworkProject() string {
+	if c.Config.NetworkProject != "" {
+		return c.Config.NetworkProject
+	}
+	return c.Config.Project
+}
+
+// ImageProject is the project stemcell images are created and looked up in.
+func (c GoogleClient) ImageProject() string {
+	if c.Config.ImageProject != "" {
+		return c.Config.ImageProject
+	}
+	return c.Config.Project
+}
+
 func (c GoogleClient) DefaultRootDiskSizeGb() int {
 	return c.Config.DefaultRootDiskSizeGb
 }
@@ -127,6 +174,131 @@ func (c GoogleClient) DefaultRootDiskType() string {
 	return c.Config.DefaultRootDiskType
 }
 
+func (c GoogleClient) StopStartOnReboot() bool {
+	return c.Config.StopStartOnReboot()
+}
+
+func (c GoogleClient) DefaultServiceScopes() []string {
+	return c.Config.DefaultServiceScopes
+}
+
+func (c GoogleClient) DefaultNetworkTier() string {
+	return c.Config.DefaultNetworkTier
+}
+
+func (c GoogleClient) DefaultTags() []string {
+	return c.Config.DefaultTags
+}
+
+func (c GoogleClient) DisableExternalIP() bool {
+	return c.Config.DisableExternalIP
+}
+
+func (c GoogleClient) UseBetaAPI() bool {
+	return c.Config.UseBetaAPI
+}
+
+// FeatureAPIVersion returns the compute API version feature is pinned to,
+// or an empty string when the CPI picks it.
+func (c GoogleClient) FeatureAPIVersion(feature string) string {
+	return c.Config.FeatureAPIVersions[feature]
+}
+
+func (c GoogleClient) SnapshotGuestFlush() bool {
+	return c.Config.SnapshotGuestFlush
+}
+
+func (c GoogleClient) SnapshotKmsKeyName() string {
+	return c.Config.SnapshotKmsKeyName
+}
+
+func (c GoogleClient) DirectorUUID() string {
+	return c.Config.DirectorUUID
+}
+
+func (c GoogleClient) DefaultDescription() string {
+	return c.Config.DefaultDescription
+}
+
+func (c GoogleClient) DryRun() bool {
+	return c.Config.DryRun
+}
+
+func (c GoogleClient) DeploymentIsolation() bool {
+	return c.Config.DeploymentIsolation
+}
+
+func (c GoogleClient) GracefulShutdownTimeout() time.Duration {
+	return time.Duration(c.Config.GracefulShutdownTimeout) * time.Second
+}
+
+func (c GoogleClient) StemcellBucket() string {
+	return c.Config.StemcellBucket()
+}
+
+func (c GoogleClient) UploadProgressInterval() time.Duration {
+	if c.Config.UploadProgressInterval == 0 {
+		return defaultUploadProgressInterval
+	}
+	return time.Duration(c.Config.UploadProgressInterval) * time.Second
+}
+
+func (c GoogleClient) UniformBucketLevelAccess() bool {
+	return c.Config.UniformBucketLevelAccess
+}
+
+func (c GoogleClient) StemcellDeprecationState() string {
+	return c.Config.StemcellDeprecationState
+}
+
+func (c GoogleClient) MetadataKeyPrefix() string {
+	return c.Config.MetadataKeyPrefix
+}
+
+func (c GoogleClient) WaitForAttachedDisk() bool {
+	return c.Config.WaitForAttachedDisk
+}
+
+func (c GoogleClient) RebootWaitForRunning() bool {
+	return c.Config.RebootWaitForRunning
+}
+
+func (c GoogleClient) RebootGracePeriod() time.Duration {
+	return time.Duration(c.Config.RebootGracePeriod) * time.Second
+}
+
+func (c GoogleClient) OperationPollInterval() time.Duration {
+	return time.Duration(c.Config.OperationPollInterval) * time.Second
+}
+
+func (c GoogleClient) StuckOperationThreshold() time.Duration {
+	return time.Duration(c.Config.StuckOperationThreshold) * time.Second
+}
+
+func (c GoogleClient) OperationTimeout() time.Duration {
+	return time.Duration(c.Config.OperationTimeout) * time.Second
+}
+
+func (c GoogleClient) CreateTimeout() time.Duration {
+	return time.Duration(c.Config.CreateTimeout) * time.Second
+}
+
+func (c GoogleClient) DeleteTimeout() time.Duration {
+	return time.Duration(c.Config.DeleteTimeout) * time.Second
+}
+
+func (c GoogleClient) AttachTimeout() time.Duration {
+	return time.Duration(c.Config.AttachTimeout) * time.Second
+}
+
+func (c GoogleClient) SnapshotTimeout() time.Duration {
+	return time.Duration(c.Config.SnapshotTimeout) * time.Second
+}
+
+func (c GoogleClient) AbortStuckOperations() bool {
+	return c.Config.AbortStuckOperations
+}
+
 func (c GoogleClient) ComputeService() *compute.Service {
 	return c.computeService
 }
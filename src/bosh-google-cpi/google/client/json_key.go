@@ -0,0 +1,37 @@
+package client
+
+import (
+	"encoding/json"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+const serviceAccountKeyType = "service_account"
+
+type jsonKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// validateJSONKey checks that key is a service account key, so a truncated
+// or wrong key fails with an error naming the problem rather than an oauth
+// error.
+func validateJSONKey(key string) error {
+	var k jsonKey
+	if err := json.Unmarshal([]byte(key), &k); err != nil {
+		return bosherr.WrapError(err, "Google JSON Key is not valid JSON")
+	}
+
+	if k.Type != serviceAccountKeyType {
+		return bosherr.Errorf("Google JSON Key 'type' must be '%s', got '%s'", serviceAccountKeyType, k.Type)
+	}
+	if k.ClientEmail == "" {
+		return bosherr.Error("Google JSON Key is missing 'client_email'")
+	}
+	if k.PrivateKey == "" {
+		return bosherr.Error("Google JSON Key is missing 'private_key'")
+	}
+
+	return nil
+}
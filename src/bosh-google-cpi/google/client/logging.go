@@ -0,0 +1,77 @@
+package client
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const (
+	loggingLogTag = "LoggingTransport"
+
+	// Bodies are only logged up to this size, stemcell uploads and large
+	// list responses would otherwise flood the log.
+	maxLoggedBodySize = 16 * 1024
+)
+
+// LoggingTransport logs the method, URL, status and duration of each request
+// made through it, and optionally the bodies. Credentials are redacted.
+type LoggingTransport struct {
+	Base      http.RoundTripper
+	LogBodies bool
+	logger    boshlog.Logger
+}
+
+// NewLoggingTransport returns a transport logging the requests made through
+// base at debug level.
+func NewLoggingTransport(base http.RoundTripper, logBodies bool, logger boshlog.Logger) *LoggingTransport {
+	return &LoggingTransport{
+		Base:      base,
+		LogBodies: logBodies,
+		logger:    logger,
+	}
+}
+
+func (lt *LoggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if lt.LogBodies && req.Body != nil {
+		var body []byte
+		body, req.Body = peekBody(req.Body)
+		lt.logger.Debug(loggingLogTag, "Request %s body: %s", RedactRequest(req), Redact(string(body)))
+	}
+
+	start := time.Now()
+	resp, err := lt.Base.RoundTrip(req)
+	duration := time.Since(start)
+	if err != nil {
+		lt.logger.Debug(loggingLogTag, "%s %s failed after %s: %s", req.Method, RedactURL(req.URL), duration, Redact(err.Error()))
+		return resp, err
+	}
+
+	lt.logger.Debug(loggingLogTag, "%s %s %d in %s", req.Method, RedactURL(req.URL), resp.StatusCode, duration)
+	if lt.LogBodies && resp.Body != nil {
+		var body []byte
+		body, resp.Body = peekBody(resp.Body)
+		lt.logger.Debug(loggingLogTag, "Response %s body: %s", RedactResponse(resp), Redact(string(body)))
+	}
+
+	return resp, nil
+}
+
+// peekBody reads up to maxLoggedBodySize bytes of body and returns them with
+// a body reading the whole content again.
+func peekBody(body io.ReadCloser) ([]byte, io.ReadCloser) {
+	peeked, _ := ioutil.ReadAll(io.LimitReader(body, maxLoggedBodySize))
+	return peeked, readCloser{
+		Reader: io.MultiReader(bytes.NewReader(peeked), body),
+		Closer: body,
+	}
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,70 @@
+package client
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LoggingTransport", func() {
+	var (
+		out    *bytes.Buffer
+		ts     *httptest.Server
+		client http.Client
+	)
+
+	BeforeEach(func() {
+		out = &bytes.Buffer{}
+		ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"received": ` + string(body) + `, "access_token": "fake-response-token"}`))
+		}))
+	})
+
+	AfterEach(func() {
+		ts.Close()
+	})
+
+	It("logs the method, URL, status and duration of requests", func() {
+		client = http.Client{Transport: NewLoggingTransport(http.DefaultTransport, false, boshlog.NewWriterLogger(boshlog.LevelDebug, out))}
+
+		resp, err := client.Post(ts.URL+"/fake-path?access_token=fake-query-token", "application/json", strings.NewReader(`{"name": "fake-name"}`))
+		Expect(err).ToNot(HaveOccurred())
+		defer resp.Body.Close()
+
+		Expect(out.String()).To(MatchRegexp(`POST http://[^ ]+/fake-path\?access_token=<redacted> 201 in [0-9.]+[µnm]?s`))
+		Expect(out.String()).NotTo(ContainSubstring("fake-query-token"))
+		Expect(out.String()).NotTo(ContainSubstring("fake-name"))
+	})
+
+	It("logs redacted bodies when asked to and leaves them readable", func() {
+		client = http.Client{Transport: NewLoggingTransport(http.DefaultTransport, true, boshlog.NewWriterLogger(boshlog.LevelDebug, out))}
+
+		resp, err := client.Post(ts.URL, "application/json", strings.NewReader(`{"name": "fake-name"}`))
+		Expect(err).ToNot(HaveOccurred())
+		body, err := ioutil.ReadAll(resp.Body)
+		Expect(err).ToNot(HaveOccurred())
+		resp.Body.Close()
+
+		Expect(string(body)).To(Equal(`{"received": {"name": "fake-name"}, "access_token": "fake-response-token"}`))
+		Expect(out.String()).To(ContainSubstring(`body: {"name": "fake-name"}`))
+		Expect(out.String()).To(ContainSubstring(`"access_token": "<redacted>"`))
+		Expect(out.String()).NotTo(ContainSubstring("fake-response-token"))
+	})
+
+	It("logs failed requests", func() {
+		client = http.Client{Transport: NewLoggingTransport(&errorTransport{}, false, boshlog.NewWriterLogger(boshlog.LevelDebug, out))}
+
+		_, err := client.Get("http://0.0.0.0/fake-path")
+		Expect(err).To(HaveOccurred())
+		Expect(out.String()).To(ContainSubstring("GET http://0.0.0.0/fake-path failed after"))
+	})
+})
@@ -0,0 +1,57 @@
+package client
+
+import (
+	"net/http"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// clock abstracts time so pacing can be tested without sleeping.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// RateLimitTransport paces outbound requests under the rate allowed by its
+// Limiter. Requests block until they are allowed rather than failing, unless
+// their context is done first.
+type RateLimitTransport struct {
+	Base    http.RoundTripper
+	Limiter *rate.Limiter
+	clock   clock
+}
+
+// NewRateLimitTransport returns a transport allowing at most maxQPS requests
+// per second through base.
+func NewRateLimitTransport(base http.RoundTripper, maxQPS float64) *RateLimitTransport {
+	burst := int(maxQPS)
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimitTransport{
+		Base:    base,
+		Limiter: rate.NewLimiter(rate.Limit(maxQPS), burst),
+		clock:   realClock{},
+	}
+}
+
+func (rt *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := rt.clock.Now()
+	reservation := rt.Limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		select {
+		case <-rt.clock.After(delay):
+		case <-req.Context().Done():
+			reservation.CancelAt(rt.clock.Now())
+			return nil, req.Context().Err()
+		}
+	}
+
+	return rt.Base.RoundTrip(req)
+}
@@ -0,0 +1,89 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeClock struct {
+	now    time.Time
+	waits  []time.Duration
+	blocks bool
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.waits = append(c.waits, d)
+	ch := make(chan time.Time, 1)
+	if !c.blocks {
+		c.now = c.now.Add(d)
+		ch <- c.now
+	}
+	return ch
+}
+
+type countingTransport struct {
+	requests int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.requests++
+	return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+}
+
+var _ = Describe("RateLimitTransport", func() {
+	var (
+		base      *countingTransport
+		clock     *fakeClock
+		transport *RateLimitTransport
+	)
+
+	BeforeEach(func() {
+		base = &countingTransport{}
+		clock = &fakeClock{now: time.Unix(0, 0)}
+		transport = NewRateLimitTransport(base, 2)
+		transport.clock = clock
+	})
+
+	It("paces requests to the configured rate", func() {
+		for i := 0; i < 5; i++ {
+			req, _ := http.NewRequest("GET", "http://0.0.0.0", nil)
+			_, err := transport.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		Expect(base.requests).To(Equal(5))
+		// The first 2 requests use the burst, the rest are paced at 2 QPS
+		Expect(clock.waits).To(Equal([]time.Duration{
+			500 * time.Millisecond,
+			500 * time.Millisecond,
+			500 * time.Millisecond,
+		}))
+	})
+
+	It("stops waiting when the request context is done", func() {
+		for i := 0; i < 2; i++ {
+			req, _ := http.NewRequest("GET", "http://0.0.0.0", nil)
+			_, err := transport.RoundTrip(req)
+			Expect(err).ToNot(HaveOccurred())
+		}
+
+		clock.blocks = true
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		req, _ := http.NewRequest("GET", "http://0.0.0.0", nil)
+		_, err := transport.RoundTrip(req.WithContext(ctx))
+		Expect(err).To(Equal(context.Canceled))
+		Expect(base.requests).To(Equal(2))
+	})
+
+	It("uses a burst of at least one request", func() {
+		transport = NewRateLimitTransport(base, 0.5)
+		Expect(transport.Limiter.Burst()).To(Equal(1))
+	})
+})
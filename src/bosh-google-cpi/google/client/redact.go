@@ -0,0 +1,106 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Redacted replaces credentials in anything logged by this package.
+const Redacted = "<redacted>"
+
+var (
+	sensitiveHeaders = []string{
+		"Authorization",
+		"Proxy-Authorization",
+	}
+
+	sensitiveQueryParams = []string{
+		"access_token",
+		"client_secret",
+		"id_token",
+		"key",
+		"refresh_token",
+		"token",
+	}
+
+	// JSON key material and OAuth2 token responses
+	sensitiveJSONPattern = regexp.MustCompile(`("(?:private_key|private_key_id|client_secret|access_token|refresh_token|id_token)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+	bearerPattern        = regexp.MustCompile(`(?i)(Bearer\s+)[^\s",]+`)
+	queryTokenPattern    = regexp.MustCompile(`([?&](?:` + strings.Join(sensitiveQueryParams, "|") + `)=)[^&\s"]+`)
+)
+
+// Redact strips bearer tokens, query string tokens and JSON key material
+// from s.
+func Redact(s string) string {
+	s = sensitiveJSONPattern.ReplaceAllString(s, `${1}"`+Redacted+`"`)
+	s = bearerPattern.ReplaceAllString(s, "${1}"+Redacted)
+	s = queryTokenPattern.ReplaceAllString(s, "${1}"+Redacted)
+	return s
+}
+
+// RedactURL returns u as a string with its password and query string tokens
+// redacted.
+func RedactURL(u *url.URL) string {
+	if u == nil {
+		return ""
+	}
+
+	redactedURL := *u
+	if _, ok := redactedURL.User.Password(); ok {
+		redactedURL.User = url.UserPassword(redactedURL.User.Username(), Redacted)
+	}
+
+	query := redactedURL.Query()
+	for _, param := range sensitiveQueryParams {
+		if _, ok := query[param]; ok {
+			query.Set(param, Redacted)
+		}
+	}
+	redactedURL.RawQuery = query.Encode()
+
+	// Keep the placeholder readable rather than percent encoded
+	return strings.Replace(redactedURL.String(), url.QueryEscape(Redacted), Redacted, -1)
+}
+
+// RedactHeader returns a copy of h with its credentials redacted.
+func RedactHeader(h http.Header) http.Header {
+	redactedHeader := make(http.Header, len(h))
+	for k, v := range h {
+		redactedHeader[k] = append([]string(nil), v...)
+	}
+
+	for _, k := range sensitiveHeaders {
+		if _, ok := redactedHeader[k]; ok {
+			redactedHeader.Set(k, Redacted)
+		}
+	}
+	return redactedHeader
+}
+
+// RedactRequest describes req, including its headers, without credentials.
+func RedactRequest(req *http.Request) string {
+	return fmt.Sprintf("%s %s %s", req.Method, RedactURL(req.URL), formatHeader(RedactHeader(req.Header)))
+}
+
+// RedactResponse describes resp, including its headers, without credentials.
+func RedactResponse(resp *http.Response) string {
+	return fmt.Sprintf("%s %s", resp.Status, formatHeader(RedactHeader(resp.Header)))
+}
+
+func formatHeader(h http.Header) string {
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0, len(keys))
+	for _, k := range keys {
+		fields = append(fields, fmt.Sprintf("%s: %s", k, strings.Join(h[k], ", ")))
+	}
+	return "[" + strings.Join(fields, "; ") + "]"
+}
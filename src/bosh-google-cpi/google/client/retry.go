@@ -57,7 +57,7 @@ func (rt *RetryTransport) try(req *http.Request) (resp *http.Response, err error
 
 		sleep := func() {
 			d := rt.FirstRetrySleep << uint64(try)
-			rt.logger.Info(retryLogTag, "Retrying request (%d/%d) after %s", try, rt.MaxRetries, d)
+			rt.logger.Info(retryLogTag, "Retrying request %s %s (%d/%d) after %s", req.Method, RedactURL(req.URL), try, rt.MaxRetries, d)
 			time.Sleep(d)
 		}
 
@@ -4,6 +4,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
+	"bytes"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -160,4 +161,27 @@ var _ = Describe("RetryTransport", func() {
 			Expect(res.StatusCode).To(Equal(http.StatusOK))
 		})
 	})
+
+	Describe("Logging", func() {
+		It("redacts query string tokens from retried requests", func() {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer ts.Close()
+
+			out := &bytes.Buffer{}
+			client := http.Client{
+				Transport: &RetryTransport{
+					Base:       http.DefaultTransport,
+					MaxRetries: 1,
+					logger:     boshlog.NewWriterLogger(boshlog.LevelInfo, out),
+				},
+			}
+
+			_, err := client.Get(ts.URL + "/?access_token=fake-token")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out.String()).To(ContainSubstring("access_token=<redacted>"))
+			Expect(out.String()).NotTo(ContainSubstring("fake-token"))
+		})
+	})
 })
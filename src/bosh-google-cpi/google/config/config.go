@@ -1,17 +1,207 @@
 package config
 
 import (
+	"regexp"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
 )
 
 var cpiRelease string
 
+// Service scopes are either the full scope URL or its name, such as
+// devstorage.read_only.
+var serviceScopeRe = regexp.MustCompile(`^(https://www\.googleapis\.com/auth/)?[a-z][a-z0-9._-]*$`)
+
+// Network tags must be valid RFC1035 names.
+var tagRe = regexp.MustCompile(`^[a-z](?:[-a-z0-9]{0,61}[a-z0-9])?$`)
+
+// Metadata key prefixes must leave room for the keys they prefix, which are
+// up to 128 letters, digits, dashes and underscores.
+var metadataKeyPrefixRe = regexp.MustCompile(`^[a-zA-Z0-9_-]{0,64}$`)
+
+// Bucket names are 3 to 63 lowercase letters, digits, dashes, underscores
+// and dots, starting and ending with a letter or a digit.
+var bucketNameRe = regexp.MustCompile(`^[a-z0-9][-_.a-z0-9]{1,61}[a-z0-9]$`)
+
+// Network tiers supported by the default_network_tier setting.
+const (
+	NetworkTierPremium  = "PREMIUM"
+	NetworkTierStandard = "STANDARD"
+)
+
+// Compute API versions the feature_api_versions setting pins features to.
+const (
+	APIVersionV1   = "v1"
+	APIVersionBeta = "beta"
+)
+
+// Features whose compute API version the feature_api_versions setting pins:
+// the API instances and disks are created with.
+const (
+	FeatureInstances = "instances"
+	FeatureDisks     = "disks"
+)
+
+// Deprecation states supported by the stemcell_deprecation_state setting.
+const (
+	DeprecationStateActive     = "ACTIVE"
+	DeprecationStateDeprecated = "DEPRECATED"
+	DeprecationStateObsolete   = "OBSOLETE"
+)
+
+// Disk types the default_root_disk_type setting accepts, as instance boot
+// disks can only be of these types.
+var knownRootDiskTypes = map[string]bool{
+	"pd-standard":        true,
+	"pd-balanced":        true,
+	"pd-ssd":             true,
+	"pd-extreme":         true,
+	"hyperdisk-balanced": true,
+}
+
+// Reboot methods supported by the reboot_vm action. RESET (HARD) resets the
+// running instance in place, STOP_START (SOFT) stops and then starts it so
+// the guest re-reads its metadata. The reboot_method VM cloud property
+// overrides the configured method for a VM.
+const (
+	RebootMethodReset     = "RESET"
+	RebootMethodHard      = "HARD"
+	RebootMethodStopStart = "STOP_START"
+	RebootMethodSoft      = "SOFT"
+)
+
 type Config struct {
-	Project               string `json:"project"`
-	UserAgentPrefix       string `json:"user_agent_prefix"`
-	JSONKey               string `json:"json_key"`
-	DefaultRootDiskSizeGb int    `json:"default_root_disk_size_gb"`
-	DefaultRootDiskType   string `json:"default_root_disk_type"`
+	Project               string  `json:"project"`
+	NetworkProject        string  `json:"network_project"`
+	ImageProject          string  `json:"image_project"`
+	UserAgentPrefix       string  `json:"user_agent_prefix"`
+	UserAgentSuffix       string  `json:"user_agent_suffix"`
+	JSONKey               string  `json:"json_key"`
+	DefaultRootDiskSizeGb int     `json:"default_root_disk_size_gb"`
+	DefaultRootDiskType   string  `json:"default_root_disk_type"`
+	RebootMethod          string  `json:"reboot_method"`
+	MaxQPS                float64 `json:"max_qps"`
+	DryRun                bool    `json:"dry_run"`
+	DebugHTTP             bool    `json:"debug_http"`
+	DebugHTTPBodies       bool    `json:"debug_http_bodies"`
+
+	// DirectorUUID is the UUID of the director sending the request, from
+	// the request context.
+	DirectorUUID string `json:"director_uuid"`
+
+	// DefaultDescription is the description of the VMs, disks and images
+	// whose cloud properties do not set one. It may use the
+	// {director_uuid} and {deployment} placeholders.
+	DefaultDescription string `json:"default_description"`
+
+	// UseBetaAPI enables the features only the compute beta API supports,
+	// such as network tiers. They are refused when it is not set.
+	UseBetaAPI bool `json:"use_beta_api"`
+
+	// FeatureAPIVersions pins the compute API version, v1 or beta, a
+	// feature uses instead of letting the CPI pick it. Pinning a feature to
+	// beta requires UseBetaAPI.
+	FeatureAPIVersions map[string]string `json:"feature_api_versions"`
+
+	// DeploymentIsolation tags the VMs of each deployment and only allows
+	// traffic between them through a firewall rule per deployment.
+	DeploymentIsolation bool `json:"deployment_isolation"`
+
+	// GracefulShutdownTimeout is the number of seconds instances are given
+	// to shut down before being deleted. Instances are deleted right away
+	// when it is zero.
+	GracefulShutdownTimeout int `json:"graceful_shutdown_timeout"`
+
+	// SnapshotGuestFlush asks the guest to flush its buffers before disks
+	// are snapshotted, for application-consistent snapshots.
+	SnapshotGuestFlush bool `json:"snapshot_guest_flush"`
+
+	// SnapshotKmsKeyName is the Cloud KMS key snapshots are encrypted with,
+	// instead of the key of their disk. It requires UseBetaAPI.
+	SnapshotKmsKeyName string `json:"snapshot_kms_key_name"`
+
+	// DefaultServiceScopes are the service scopes of VMs whose cloud
+	// properties do not set service_scopes.
+	DefaultServiceScopes []string `json:"default_service_scopes"`
+
+	// DefaultNetworkTier is the network tier of VMs whose network does not
+	// set network_tier. DefaultTags are added to the tags of every VM.
+	DefaultNetworkTier string   `json:"default_network_tier"`
+	DefaultTags        []string `json:"default_tags"`
+
+	// DisableExternalIP ignores the ephemeral_external_ip of networks, so
+	// VMs only get an ephemeral external IP when their cloud properties set
+	// ephemeral_external_ip.
+	DisableExternalIP bool `json:"disable_external_ip"`
+
+	// OperationPollInterval is the number of seconds between polls of a
+	// running operation, once the first quicker polls are done. The default
+	// is used when it is zero.
+	OperationPollInterval int `json:"operation_poll_interval"`
+
+	// StuckOperationThreshold is the number of seconds an operation may stay
+	// at the same progress before a warning is logged, or before it is
+	// aborted when AbortStuckOperations is set. Zero disables the check.
+	StuckOperationThreshold int  `json:"stuck_operation_threshold"`
+	AbortStuckOperations    bool `json:"abort_stuck_operations"`
+
+	// OperationTimeout is the number of seconds operations are waited for,
+	// and CreateTimeout, DeleteTimeout, AttachTimeout and SnapshotTimeout
+	// the number of seconds the operations creating, deleting, attaching or
+	// detaching and snapshotting resources are. Zero timeouts fall back to
+	// OperationTimeout, and OperationTimeout to the default.
+	OperationTimeout int `json:"operation_timeout"`
+	CreateTimeout    int `json:"create_timeout"`
+	DeleteTimeout    int `json:"delete_timeout"`
+	AttachTimeout    int `json:"attach_timeout"`
+	SnapshotTimeout  int `json:"snapshot_timeout"`
+
+	// RebootWaitForRunning makes reboot_vm wait for the instance to be
+	// RUNNING again, and then RebootGracePeriod more seconds, before
+	// returning.
+	RebootWaitForRunning bool `json:"reboot_wait_for_running"`
+	RebootGracePeriod    int  `json:"reboot_grace_period"`
+
+	// WaitForAttachedDisk makes attach_disk wait for the disk to show in the
+	// instance disks with its device name before returning.
+	WaitForAttachedDisk bool `json:"wait_for_attached_disk"`
+
+	// StorageMaxRetries and StorageRetryBackoffMs tune the retries of Google
+	// Cloud Storage requests apart from the compute ones: the number of
+	// retries, and the milliseconds slept before the first one, doubled
+	// for each next one. The number of retries defaults to the compute one
+	// when unset, so zero disables retries, and the backoff defaults when
+	// it is zero.
+	StorageMaxRetries     *int `json:"storage_max_retries"`
+	StorageRetryBackoffMs int  `json:"storage_retry_backoff_ms"`
+
+	// MetadataKeyPrefix is prepended to the keys of the instance metadata
+	// items set from the director VM metadata, so they do not clash with
+	// the keys set by other tooling.
+	MetadataKeyPrefix string `json:"metadata_key_prefix"`
+
+	// StemcellBucketName is the bucket stemcell tarballs are uploaded to,
+	// created the first time it is used. StemcellBucketSuffix is appended to
+	// it, so directors sharing a configuration each get their own bucket.
+	// Each tarball is uploaded to a temporary bucket when it is not set.
+	StemcellBucketName   string `json:"stemcell_bucket_name"`
+	StemcellBucketSuffix string `json:"stemcell_bucket_suffix"`
+
+	// UniformBucketLevelAccess uploads stemcell tarballs without object
+	// ACLs, for buckets with uniform bucket-level access.
+	UniformBucketLevelAccess bool `json:"uniform_bucket_level_access"`
+
+	// UploadProgressInterval is the least number of seconds between two
+	// progress lines of a stemcell tarball upload. The default is used when
+	// it is zero.
+	UploadProgressInterval int `json:"upload_progress_interval"`
+
+	// StemcellDeprecationState is set on the previous latest image of the
+	// family of a new stemcell, with the new stemcell as its replacement.
+	// The previous image is left alone when it is empty.
+	StemcellDeprecationState string `json:"stemcell_deprecation_state"`
 }
 
 func (c Config) GetUserAgent() string {
@@ -19,15 +209,155 @@ func (c Config) GetUserAgent() string {
 		cpiRelease = "dev"
 	}
 	userAgent := "bosh-google-cpi/" + cpiRelease
-	if c.UserAgentPrefix == "" {
-		return userAgent
+	if c.UserAgentPrefix != "" {
+		userAgent = c.UserAgentPrefix + " " + userAgent
+	}
+	if c.UserAgentSuffix != "" {
+		userAgent = userAgent + " " + c.UserAgentSuffix
+	}
+	return userAgent
+}
+
+// StopStartOnReboot reports whether reboots must stop and start the instance
+// rather than resetting it. Resetting is the default.
+func (c Config) StopStartOnReboot() bool {
+	return IsStopStartRebootMethod(c.RebootMethod)
+}
+
+// IsRebootMethod reports whether method is a supported reboot method. An
+// empty method selects the default.
+func IsRebootMethod(method string) bool {
+	switch method {
+	case "", RebootMethodReset, RebootMethodHard, RebootMethodStopStart, RebootMethodSoft:
+		return true
 	}
-	return c.UserAgentPrefix + " " + userAgent
+	return false
+}
+
+// IsStopStartRebootMethod reports whether method stops and starts the
+// instance rather than resetting it.
+func IsStopStartRebootMethod(method string) bool {
+	return method == RebootMethodStopStart || method == RebootMethodSoft
+}
+
+// StemcellBucket returns the name of the stemcell bucket, suffixed with the
+// stemcell bucket suffix, or an empty name when there is none.
+func (c Config) StemcellBucket() string {
+	if c.StemcellBucketName == "" || c.StemcellBucketSuffix == "" {
+		return c.StemcellBucketName
+	}
+	return c.StemcellBucketName + "-" + c.StemcellBucketSuffix
+}
+
+// LogHTTP reports whether API requests must be logged. Logging bodies
+// implies logging the requests.
+func (c Config) LogHTTP() bool {
+	return c.DebugHTTP || c.DebugHTTPBodies
 }
 
 func (c Config) Validate() error {
 	if c.Project == "" {
 		return bosherr.Error("Must provide a non-empty Project")
 	}
+	if c.MaxQPS < 0 {
+		return bosherr.Error("MaxQPS must not be negative")
+	}
+	if c.GracefulShutdownTimeout < 0 {
+		return bosherr.Error("GracefulShutdownTimeout must not be negative")
+	}
+	if !metadataKeyPrefixRe.MatchString(c.MetadataKeyPrefix) {
+		return bosherr.Errorf("Invalid MetadataKeyPrefix %q", c.MetadataKeyPrefix)
+	}
+	if c.SnapshotKmsKeyName != "" {
+		if !util.IsKmsKeyName(c.SnapshotKmsKeyName) {
+			return bosherr.Errorf("Invalid SnapshotKmsKeyName %q, expected projects/<project>/locations/<location>/keyRings/<key-ring>/cryptoKeys/<key>", c.SnapshotKmsKeyName)
+		}
+		if !c.UseBetaAPI {
+			return bosherr.Error("SnapshotKmsKeyName requires UseBetaAPI")
+		}
+	}
+	for feature, version := range c.FeatureAPIVersions {
+		switch feature {
+		case FeatureInstances, FeatureDisks:
+		default:
+			return bosherr.Errorf("Unsupported FeatureAPIVersions feature %q", feature)
+		}
+		switch version {
+		case APIVersionV1:
+		case APIVersionBeta:
+			if !c.UseBetaAPI {
+				return bosherr.Errorf("FeatureAPIVersions pins %q to the beta API, which requires UseBetaAPI", feature)
+			}
+		default:
+			return bosherr.Errorf("Unsupported FeatureAPIVersions version %q for %q, must be %q or %q", version, feature, APIVersionV1, APIVersionBeta)
+		}
+	}
+	if c.StemcellBucketSuffix != "" && c.StemcellBucketName == "" {
+		return bosherr.Error("StemcellBucketSuffix requires StemcellBucketName")
+	}
+	if bucket := c.StemcellBucket(); bucket != "" && !bucketNameRe.MatchString(bucket) {
+		return bosherr.Errorf("Invalid stemcell bucket name %q", bucket)
+	}
+	if c.StorageMaxRetries != nil && *c.StorageMaxRetries < 0 {
+		return bosherr.Error("StorageMaxRetries must not be negative")
+	}
+	if c.StorageRetryBackoffMs < 0 {
+		return bosherr.Error("StorageRetryBackoffMs must not be negative")
+	}
+	if len(c.DefaultDescription) > util.MaxDescriptionLength {
+		return bosherr.Errorf("DefaultDescription must be at most %d characters long", util.MaxDescriptionLength)
+	}
+	if c.UploadProgressInterval < 0 {
+		return bosherr.Error("UploadProgressInterval must not be negative")
+	}
+	if c.RebootGracePeriod < 0 {
+		return bosherr.Error("RebootGracePeriod must not be negative")
+	}
+	if c.RebootGracePeriod > 0 && !c.RebootWaitForRunning {
+		return bosherr.Error("RebootGracePeriod requires RebootWaitForRunning")
+	}
+	for _, scope := range c.DefaultServiceScopes {
+		if !serviceScopeRe.MatchString(scope) {
+			return bosherr.Errorf("Invalid DefaultServiceScopes scope %q", scope)
+		}
+	}
+	if c.DefaultRootDiskType != "" && !knownRootDiskTypes[c.DefaultRootDiskType] {
+		return bosherr.Errorf("Unknown DefaultRootDiskType %q", c.DefaultRootDiskType)
+	}
+	switch c.DefaultNetworkTier {
+	case "", NetworkTierPremium, NetworkTierStandard:
+	default:
+		return bosherr.Errorf("Unsupported DefaultNetworkTier %q", c.DefaultNetworkTier)
+	}
+	switch c.StemcellDeprecationState {
+	case "", DeprecationStateActive, DeprecationStateDeprecated, DeprecationStateObsolete:
+	default:
+		return bosherr.Errorf("Unsupported StemcellDeprecationState %q", c.StemcellDeprecationState)
+	}
+	for _, tag := range c.DefaultTags {
+		if !tagRe.MatchString(tag) {
+			return bosherr.Errorf("Invalid DefaultTags tag %q", tag)
+		}
+	}
+	if c.OperationPollInterval < 0 {
+		return bosherr.Error("OperationPollInterval must not be negative")
+	}
+	if c.StuckOperationThreshold < 0 {
+		return bosherr.Error("StuckOperationThreshold must not be negative")
+	}
+	for name, timeout := range map[string]int{
+		"OperationTimeout": c.OperationTimeout,
+		"CreateTimeout":    c.CreateTimeout,
+		"DeleteTimeout":    c.DeleteTimeout,
+		"AttachTimeout":    c.AttachTimeout,
+		"SnapshotTimeout":  c.SnapshotTimeout,
+	} {
+		if timeout < 0 {
+			return bosherr.Errorf("%s must not be negative", name)
+		}
+	}
+	if !IsRebootMethod(c.RebootMethod) {
+		return bosherr.Errorf("Unsupported RebootMethod %q", c.RebootMethod)
+	}
 	return nil
 }
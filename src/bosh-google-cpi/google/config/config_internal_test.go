@@ -11,6 +11,10 @@ var _ = Describe("Config", func() {
 	)
 
 	Describe("UserAgent", func() {
+		BeforeEach(func() {
+			config = Config{}
+		})
+
 		It("returns correct user agent string with release, without prefix", func() {
 			config.UserAgentPrefix = ""
 			cpiRelease = "0.0.1"
@@ -39,5 +43,21 @@ var _ = Describe("Config", func() {
 			userAgent := config.GetUserAgent()
 			Expect(userAgent).To(Equal("bosh-google-cpi/dev"))
 		})
+		It("returns correct user agent string with release, with prefix and suffix", func() {
+			config.UserAgentPrefix = "Kubo/0.0.2"
+			config.UserAgentSuffix = "fake-application/1.0"
+			cpiRelease = "0.0.1"
+
+			userAgent := config.GetUserAgent()
+			Expect(userAgent).To(Equal("Kubo/0.0.2 bosh-google-cpi/0.0.1 fake-application/1.0"))
+		})
+		It("returns correct user agent string with release, with suffix only", func() {
+			config.UserAgentPrefix = ""
+			config.UserAgentSuffix = "fake-application/1.0"
+			cpiRelease = "0.0.1"
+
+			userAgent := config.GetUserAgent()
+			Expect(userAgent).To(Equal("bosh-google-cpi/0.0.1 fake-application/1.0"))
+		})
 	})
 })
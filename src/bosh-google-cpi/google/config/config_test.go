@@ -1,6 +1,8 @@
 package config_test
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
@@ -33,5 +35,268 @@ var _ = Describe("Config", func() {
 			Expect(err).To(HaveOccurred())
 			Expect(err.Error()).To(ContainSubstring("Must provide a non-empty Project"))
 		})
+
+		It("returns error if MaxQPS is negative", func() {
+			config.MaxQPS = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("MaxQPS must not be negative"))
+		})
+
+		It("accepts default service scope names and URLs", func() {
+			config.DefaultServiceScopes = []string{"devstorage.read_only", "https://www.googleapis.com/auth/cloud-platform"}
+
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		It("returns error if a default service scope is malformed", func() {
+			config.DefaultServiceScopes = []string{"cloud platform"}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Invalid DefaultServiceScopes scope "cloud platform"`))
+		})
+
+		It("returns error if DefaultNetworkTier is not supported", func() {
+			config.DefaultNetworkTier = "fake-tier"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Unsupported DefaultNetworkTier "fake-tier"`))
+		})
+
+		It("returns error if StemcellDeprecationState is not supported", func() {
+			config.StemcellDeprecationState = "DELETED"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Unsupported StemcellDeprecationState "DELETED"`))
+		})
+
+		It("returns error if a default tag is malformed", func() {
+			config.DefaultTags = []string{"Fake_Tag"}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Invalid DefaultTags tag "Fake_Tag"`))
+		})
+
+		It("returns error if OperationPollInterval is negative", func() {
+			config.OperationPollInterval = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("OperationPollInterval must not be negative"))
+		})
+
+		It("returns error if StuckOperationThreshold is negative", func() {
+			config.StuckOperationThreshold = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("StuckOperationThreshold must not be negative"))
+		})
+
+		It("returns error if GracefulShutdownTimeout is negative", func() {
+			config.GracefulShutdownTimeout = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("GracefulShutdownTimeout must not be negative"))
+		})
+
+		It("does not return error if DefaultRootDiskType is a known disk type", func() {
+			config.DefaultRootDiskType = "pd-ssd"
+
+			Expect(config.Validate()).To(Succeed())
+		})
+
+		It("returns error if DefaultRootDiskType is not a known disk type", func() {
+			config.DefaultRootDiskType = "pd-fast"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unknown DefaultRootDiskType \"pd-fast\""))
+		})
+
+		It("returns error if MetadataKeyPrefix is not a valid metadata key", func() {
+			config.MetadataKeyPrefix = "bosh."
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid MetadataKeyPrefix \"bosh.\""))
+		})
+
+		It("returns error if SnapshotKmsKeyName is not a KMS key name", func() {
+			config.UseBetaAPI = true
+			config.SnapshotKmsKeyName = "fake-key"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid SnapshotKmsKeyName \"fake-key\""))
+		})
+
+		It("returns error if SnapshotKmsKeyName is set without UseBetaAPI", func() {
+			config.SnapshotKmsKeyName = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-key"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SnapshotKmsKeyName requires UseBetaAPI"))
+		})
+
+		It("returns error if StemcellBucketSuffix is set without StemcellBucketName", func() {
+			config.StemcellBucketSuffix = "fake-director"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("StemcellBucketSuffix requires StemcellBucketName"))
+		})
+
+		It("returns error if the stemcell bucket name is invalid", func() {
+			config.StemcellBucketName = "Fake-Stemcells"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Invalid stemcell bucket name \"Fake-Stemcells\""))
+		})
+
+		It("returns error if StorageMaxRetries is negative", func() {
+			storageMaxRetries := -1
+			config.StorageMaxRetries = &storageMaxRetries
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("StorageMaxRetries must not be negative"))
+		})
+
+		It("returns error if StorageRetryBackoffMs is negative", func() {
+			config.StorageRetryBackoffMs = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("StorageRetryBackoffMs must not be negative"))
+		})
+
+		It("returns error if an operation timeout is negative", func() {
+			config.SnapshotTimeout = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("SnapshotTimeout must not be negative"))
+		})
+
+		It("returns error if RebootGracePeriod is negative", func() {
+			config.RebootWaitForRunning = true
+			config.RebootGracePeriod = -1
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RebootGracePeriod must not be negative"))
+		})
+
+		It("does not return error if FeatureAPIVersions pins supported features", func() {
+			config.UseBetaAPI = true
+			config.FeatureAPIVersions = map[string]string{FeatureInstances: APIVersionBeta, FeatureDisks: APIVersionV1}
+
+			err := config.Validate()
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("returns error if FeatureAPIVersions pins an unsupported feature", func() {
+			config.FeatureAPIVersions = map[string]string{"fake-feature": APIVersionV1}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Unsupported FeatureAPIVersions feature "fake-feature"`))
+		})
+
+		It("returns error if FeatureAPIVersions pins an unsupported version", func() {
+			config.FeatureAPIVersions = map[string]string{FeatureDisks: "alpha"}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`Unsupported FeatureAPIVersions version "alpha" for "disks"`))
+		})
+
+		It("returns error if FeatureAPIVersions pins a feature to beta without UseBetaAPI", func() {
+			config.FeatureAPIVersions = map[string]string{FeatureInstances: APIVersionBeta}
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring(`FeatureAPIVersions pins "instances" to the beta API, which requires UseBetaAPI`))
+		})
+
+		It("returns error if DefaultDescription is too long", func() {
+			config.DefaultDescription = strings.Repeat("a", 2049)
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("DefaultDescription must be at most 2048 characters long"))
+		})
+
+		It("returns error if RebootGracePeriod is set without RebootWaitForRunning", func() {
+			config.RebootGracePeriod = 30
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("RebootGracePeriod requires RebootWaitForRunning"))
+		})
+
+		It("does not return error if RebootMethod is supported", func() {
+			for _, method := range []string{RebootMethodReset, RebootMethodHard, RebootMethodStopStart, RebootMethodSoft} {
+				config.RebootMethod = method
+
+				err := config.Validate()
+				Expect(err).ToNot(HaveOccurred())
+			}
+		})
+
+		It("returns error if RebootMethod is not supported", func() {
+			config.RebootMethod = "fake-reboot-method"
+
+			err := config.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unsupported RebootMethod \"fake-reboot-method\""))
+		})
+	})
+
+	Describe("StopStartOnReboot", func() {
+		It("resets by default", func() {
+			Expect(Config{}.StopStartOnReboot()).To(BeFalse())
+			Expect(Config{RebootMethod: RebootMethodReset}.StopStartOnReboot()).To(BeFalse())
+			Expect(Config{RebootMethod: RebootMethodHard}.StopStartOnReboot()).To(BeFalse())
+		})
+
+		It("stops and starts for STOP_START and SOFT", func() {
+			Expect(Config{RebootMethod: RebootMethodStopStart}.StopStartOnReboot()).To(BeTrue())
+			Expect(Config{RebootMethod: RebootMethodSoft}.StopStartOnReboot()).To(BeTrue())
+		})
+	})
+
+	Describe("StemcellBucket", func() {
+		It("is empty by default", func() {
+			Expect(Config{}.StemcellBucket()).To(BeEmpty())
+		})
+
+		It("is the stemcell bucket name without a suffix", func() {
+			Expect(Config{StemcellBucketName: "fake-stemcells"}.StemcellBucket()).To(Equal("fake-stemcells"))
+		})
+
+		It("appends the suffix to the stemcell bucket name", func() {
+			config := Config{StemcellBucketName: "fake-stemcells", StemcellBucketSuffix: "fake-director"}
+			Expect(config.StemcellBucket()).To(Equal("fake-stemcells-fake-director"))
+		})
+	})
+
+	Describe("LogHTTP", func() {
+		It("is disabled by default", func() {
+			Expect(Config{}.LogHTTP()).To(BeFalse())
+		})
+
+		It("is enabled by DebugHTTP or DebugHTTPBodies", func() {
+			Expect(Config{DebugHTTP: true}.LogHTTP()).To(BeTrue())
+			Expect(Config{DebugHTTPBodies: true}.LogHTTP()).To(BeTrue())
+		})
 	})
 })
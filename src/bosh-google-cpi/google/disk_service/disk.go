@@ -1,8 +1,34 @@
 package disk
 
+// EphemeralLabelKey marks disks created alongside a VM. These disks share the
+// lifecycle of the VM and are deleted with it, unlike BOSH persistent disks.
+const EphemeralLabelKey = "bosh-ephemeral-disk"
+
+// MovedFromLabelKey records the disk a disk was moved from, by recreating it
+// from a snapshot in another zone.
+const MovedFromLabelKey = "bosh-moved-from"
+
+const (
+	ModeReadWrite = "READ_WRITE"
+	ModeReadOnly  = "READ_ONLY"
+)
+
 type Disk struct {
 	Name     string
 	SelfLink string
 	Status   string
 	Zone     string
+	Region   string
+	Type     string
+	SizeGb   int64
+	Labels   map[string]string
+	Users    []string
+
+	CreationTimestamp string
+	LabelFingerprint  string
+}
+
+func (d Disk) Ephemeral() bool {
+	_, ok := d.Labels[EphemeralLabelKey]
+	return ok
 }
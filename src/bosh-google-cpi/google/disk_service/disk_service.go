@@ -1,7 +1,13 @@
 package disk
 
 type Service interface {
-	Create(size int, diskType string, zone string) (string, error)
+	Create(size int, diskType string, zone string, labels map[string]string, description string) (string, error)
+	CreateFromSnapshot(snapshotLink string, size int, diskType string, zone string, labels map[string]string, description string) (string, error)
 	Delete(id string) error
+	DeleteInRegion(id string, region string) error
 	Find(id string, zone string) (Disk, bool, error)
+	FindInRegion(id string, region string) (Disk, bool, error)
+	FindByLabels(labels map[string]string) ([]Disk, error)
+	SetLabels(id string, zone string, labels map[string]string) error
+	SetLabelsInRegion(id string, region string, labels map[string]string) error
 }
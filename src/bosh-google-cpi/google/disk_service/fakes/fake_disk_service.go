@@ -5,36 +5,123 @@ import (
 )
 
 type FakeDiskService struct {
-	CreateCalled   bool
-	CreateErr      error
-	CreateID       string
-	CreateSize     int
-	CreateDiskType string
-	CreateZone     string
+	CreateCalled      bool
+	CreateErr         error
+	CreateID          string
+	CreateSize        int
+	CreateDiskType    string
+	CreateZone        string
+	CreateLabels      map[string]string
+	CreateDescription string
+
+	CreateFromSnapshotCalled   bool
+	CreateFromSnapshotSnapshot string
 
 	DeleteCalled bool
 	DeleteErr    error
+	DeleteIDs    []string
+
+	DeleteInRegionCalled bool
+	DeleteInRegionID     string
+	DeleteInRegionRegion string
+	DeleteInRegionErr    error
 
 	FindCalled bool
+	FindID     string
+	FindZone   string
 	FindFound  bool
 	FindDisk   disk.Disk
+	FindDisks  map[string]disk.Disk
 	FindErr    error
+
+	FindInRegionCalled bool
+	FindInRegionID     string
+	FindInRegionRegion string
+	FindInRegionFound  bool
+	FindInRegionErr    error
+
+	FindByLabelsCalled bool
+	FindByLabelsLabels map[string]string
+	FindByLabelsDisks  []disk.Disk
+	FindByLabelsErr    error
+
+	SetLabelsCalled bool
+	SetLabelsID     string
+	SetLabelsZone   string
+	SetLabelsLabels map[string]string
+	SetLabelsErr    error
+
+	SetLabelsInRegionCalled bool
+	SetLabelsInRegionID     string
+	SetLabelsInRegionRegion string
+	SetLabelsInRegionLabels map[string]string
+	SetLabelsInRegionErr    error
 }
 
-func (d *FakeDiskService) Create(size int, diskType string, zone string) (string, error) {
+func (d *FakeDiskService) Create(size int, diskType string, zone string, labels map[string]string, description string) (string, error) {
 	d.CreateCalled = true
 	d.CreateSize = size
 	d.CreateDiskType = diskType
 	d.CreateZone = zone
+	d.CreateLabels = labels
+	d.CreateDescription = description
 	return d.CreateID, d.CreateErr
 }
 
+func (d *FakeDiskService) CreateFromSnapshot(snapshotLink string, size int, diskType string, zone string, labels map[string]string, description string) (string, error) {
+	d.CreateFromSnapshotCalled = true
+	d.CreateFromSnapshotSnapshot = snapshotLink
+	return d.Create(size, diskType, zone, labels, description)
+}
+
 func (d *FakeDiskService) Delete(id string) error {
 	d.DeleteCalled = true
+	d.DeleteIDs = append(d.DeleteIDs, id)
 	return d.DeleteErr
 }
 
+func (d *FakeDiskService) DeleteInRegion(id string, region string) error {
+	d.DeleteInRegionCalled = true
+	d.DeleteInRegionID = id
+	d.DeleteInRegionRegion = region
+	return d.DeleteInRegionErr
+}
+
 func (d *FakeDiskService) Find(id string, zone string) (disk.Disk, bool, error) {
 	d.FindCalled = true
+	d.FindID = id
+	d.FindZone = zone
+	if foundDisk, ok := d.FindDisks[id]; ok {
+		return foundDisk, true, d.FindErr
+	}
 	return d.FindDisk, d.FindFound, d.FindErr
 }
+
+func (d *FakeDiskService) FindInRegion(id string, region string) (disk.Disk, bool, error) {
+	d.FindInRegionCalled = true
+	d.FindInRegionID = id
+	d.FindInRegionRegion = region
+	return disk.Disk{}, d.FindInRegionFound, d.FindInRegionErr
+}
+
+func (d *FakeDiskService) FindByLabels(labels map[string]string) ([]disk.Disk, error) {
+	d.FindByLabelsCalled = true
+	d.FindByLabelsLabels = labels
+	return d.FindByLabelsDisks, d.FindByLabelsErr
+}
+
+func (d *FakeDiskService) SetLabels(id string, zone string, labels map[string]string) error {
+	d.SetLabelsCalled = true
+	d.SetLabelsID = id
+	d.SetLabelsZone = zone
+	d.SetLabelsLabels = labels
+	return d.SetLabelsErr
+}
+
+func (d *FakeDiskService) SetLabelsInRegion(id string, region string, labels map[string]string) error {
+	d.SetLabelsInRegionCalled = true
+	d.SetLabelsInRegionID = id
+	d.SetLabelsInRegionRegion = region
+	d.SetLabelsInRegionLabels = labels
+	return d.SetLabelsInRegionErr
+}
@@ -1,10 +1,13 @@
 package disk
 
 import (
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	"bosh-google-cpi/google/operation_service"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -14,26 +17,43 @@ const googleDiskDescription = "Disk managed by BOSH"
 const googleDiskReadyStatus = "READY"
 const googleDiskFailedStatus = "FAILED"
 
+const googleDiskReadyPollInterval = time.Second
+
 type GoogleDiskService struct {
 	project          string
 	computeService   *compute.Service
+	computeServiceB  *computebeta.Service
 	operationService operation.Service
 	uuidGen          boshuuid.Generator
 	logger           boshlog.Logger
+	dryRun           bool
+	apiVersion       string
+
+	// readyTimeout bounds how long Create waits for new disks to be ready.
+	readyTimeout time.Duration
 }
 
 func NewGoogleDiskService(
 	project string,
 	computeService *compute.Service,
+	computeServiceB *computebeta.Service,
 	operationService operation.Service,
 	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
+	dryRun bool,
+	apiVersion string,
+	readyTimeout time.Duration,
 ) GoogleDiskService {
 	return GoogleDiskService{
 		project:          project,
 		computeService:   computeService,
+		computeServiceB:  computeServiceB,
 		operationService: operationService,
 		uuidGen:          uuidGen,
 		logger:           logger,
+		dryRun:           dryRun,
+		apiVersion:       apiVersion,
+
+		readyTimeout: readyTimeout,
 	}
 }
@@ -2,45 +2,139 @@ package disk
 
 import (
 	"fmt"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"bosh-google-cpi/google/config"
 	"bosh-google-cpi/util"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
 
-func (d GoogleDiskService) Create(size int, diskType string, zone string) (string, error) {
+// Create creates an empty disk. The disk gets the default description when
+// description is empty.
+func (d GoogleDiskService) Create(size int, diskType string, zone string, labels map[string]string, description string) (string, error) {
+	return d.create("", size, diskType, zone, labels, description)
+}
+
+// CreateFromSnapshot creates a disk restored from a snapshot. The disk must
+// be at least as large as the disk the snapshot was taken from.
+func (d GoogleDiskService) CreateFromSnapshot(snapshotLink string, size int, diskType string, zone string, labels map[string]string, description string) (string, error) {
+	return d.create(snapshotLink, size, diskType, zone, labels, description)
+}
+
+func (d GoogleDiskService) create(snapshotLink string, size int, diskType string, zone string, labels map[string]string, description string) (string, error) {
 	uuidStr, err := d.uuidGen.Generate()
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Generating random Google Disk name")
 	}
 
+	if description == "" {
+		description = googleDiskDescription
+	}
+
 	disk := &compute.Disk{
 		Name:        fmt.Sprintf("%s-%s", googleDiskNamePrefix, uuidStr),
-		Description: googleDiskDescription,
+		Description: description,
 		SizeGb:      int64(size),
+		Labels:      labels,
+
+		SourceSnapshot: snapshotLink,
 	}
 
 	if diskType != "" {
 		disk.Type = diskType
 	}
 
+	if d.dryRun {
+		d.logger.Info(googleDiskServiceLogTag, "Dry run, not creating Google Disk with params: %#v", disk)
+		return disk.Name, nil
+	}
+
 	d.logger.Debug(googleDiskServiceLogTag, "Creating Google Disk with params: %#v", disk)
-	operation, err := d.computeService.Disks.Insert(d.project, util.ResourceSplitter(zone), disk).Do()
+	operation, err := d.insert(disk, zone)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Failed to create Google Disk")
 	}
 
 	if _, err = d.operationService.Waiter(operation, zone, ""); err != nil {
-		d.cleanUp(disk.Name)
+		d.cleanUp(disk.Name, zone)
 		return "", bosherr.WrapErrorf(err, "Failed to create Google Disk")
 	}
 
+	// The insert operation can be done while the disk is still being
+	// created, and attaching it then fails
+	created, err := d.waitForReady(disk.Name, zone)
+	if err != nil {
+		d.cleanUp(disk.Name, zone)
+		return "", bosherr.WrapErrorf(err, "Failed to create Google Disk")
+	}
+
+	d.logger.Info(googleDiskServiceLogTag, "Created Google Disk '%s' of %d GB in zone '%s'", created.Name, created.SizeGb, util.ResourceSplitter(zone))
 	return disk.Name, nil
 }
 
-func (d GoogleDiskService) cleanUp(id string) {
-	if err := d.Delete(id); err != nil {
-		d.logger.Debug(googleDiskServiceLogTag, "Failed cleaning up Google Disk '%s': %#v", id, err)
+// insert creates the disk through the beta API when disks are pinned to it,
+// and through the v1 API otherwise.
+func (d GoogleDiskService) insert(disk *compute.Disk, zone string) (*compute.Operation, error) {
+	if d.apiVersion != config.APIVersionBeta {
+		return d.computeService.Disks.Insert(d.project, util.ResourceSplitter(zone), disk).Do()
+	}
+
+	diskB := &computebeta.Disk{}
+	if err := util.ConvertResource(disk, diskB); err != nil {
+		return nil, err
+	}
+	operationB, err := d.computeServiceB.Disks.Insert(d.project, util.ResourceSplitter(zone), diskB).Do()
+	if err != nil {
+		return nil, err
+	}
+
+	// The operation is the same resource in both APIs, so the insert is
+	// waited for like any other
+	operation := &compute.Operation{}
+	if err := util.ConvertResource(operationB, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (d GoogleDiskService) waitForReady(id string, zone string) (Disk, error) {
+	deadline := time.Now().Add(d.readyTimeout)
+	for {
+		disk, found, err := d.Find(id, zone)
+		if err != nil {
+			return Disk{}, err
+		}
+		if !found {
+			return Disk{}, bosherr.Errorf("Google Disk '%s' does not exist", id)
+		}
+
+		switch disk.Status {
+		case googleDiskReadyStatus:
+			return disk, nil
+		case googleDiskFailedStatus:
+			return Disk{}, bosherr.Errorf("Google Disk '%s' failed to be created", id)
+		}
+
+		if time.Now().After(deadline) {
+			return Disk{}, bosherr.Errorf("Timed out after %v waiting for Google Disk '%s' to be ready, status is '%s'", d.readyTimeout, id, disk.Status)
+		}
+
+		d.logger.Debug(googleDiskServiceLogTag, "Google Disk '%s' is '%s', waiting for it to be ready", id, disk.Status)
+		time.Sleep(googleDiskReadyPollInterval)
+	}
+}
+
+// cleanUp deletes a disk that failed to be created whatever its status, as
+// Delete refuses disks that are still being created.
+func (d GoogleDiskService) cleanUp(id string, zone string) {
+	disk, found, err := d.Find(id, zone)
+	if err == nil && found {
+		err = d.deleteZonal(disk)
+	}
+	if err != nil {
+		d.logger.Warn(googleDiskServiceLogTag, "Failed cleaning up Google Disk '%s', it has to be deleted manually: %#v", id, err)
 	}
 }
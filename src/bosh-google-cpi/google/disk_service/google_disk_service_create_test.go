@@ -0,0 +1,182 @@
+package disk_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/config"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService Create", func() {
+	const diskPath = "/fake-project/zones/fake-zone/disks/disk-fake-uuid"
+
+	var (
+		server   *clientfakes.FakeComputeServer
+		requests []string
+		statuses []string
+		inserted compute.Disk
+
+		computeService  *compute.Service
+		computeServiceB *computebeta.Service
+		apiVersion      string
+		readyTimeout    time.Duration
+		diskService     GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		inserted = compute.Disk{}
+		statuses = []string{"CREATING", "READY"}
+		apiVersion = ""
+		readyTimeout = time.Minute
+		server = clientfakes.NewFakeComputeServer()
+		insert := func(request string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				Expect(json.Unmarshal(body, &inserted)).To(Succeed())
+				requests = append(requests, request)
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			}
+		}
+		server.Handle("POST", "/fake-project/zones/fake-zone/disks", insert("INSERT"))
+		server.Handle("POST", "/beta/fake-project/zones/fake-zone/disks", insert("BETA_INSERT"))
+		server.Handle("GET", diskPath, func(w http.ResponseWriter, r *http.Request) {
+			status := statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			requests = append(requests, status)
+			fmt.Fprintf(w, `{"name": "disk-fake-uuid", "zone": "fake-zone", "status": "%s"}`, status)
+		})
+		server.Handle("GET", "/fake-project/aggregated/disks", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"items": {"zones/fake-zone": {"disks": [{"name": "disk-fake-uuid", "zone": "fake-zone", "status": "%s"}]}}}`, statuses[0])
+		})
+		server.Handle("DELETE", diskPath, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "DELETE")
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		computeService = server.ComputeService()
+		computeServiceB = server.ComputeBetaService()
+		computeServiceB.BasePath = server.URL() + "/beta/"
+	})
+
+	JustBeforeEach(func() {
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			computeService,
+			computeServiceB,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			apiVersion,
+			readyTimeout,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("restores the disk from a snapshot", func() {
+		diskID, err := diskService.CreateFromSnapshot("fake-snapshot-self-link", 32, "fake-disk-type", "fake-zone", nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diskID).To(Equal("disk-fake-uuid"))
+		Expect(inserted.SourceSnapshot).To(Equal("fake-snapshot-self-link"))
+		Expect(inserted.SizeGb).To(Equal(int64(32)))
+	})
+
+	It("describes the disk as managed by BOSH by default", func() {
+		_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Description).To(Equal("Disk managed by BOSH"))
+	})
+
+	It("sets the description of the disk", func() {
+		_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "fake-description")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Description).To(Equal("fake-description"))
+	})
+
+	It("waits for the disk to be ready", func() {
+		diskID, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diskID).To(Equal("disk-fake-uuid"))
+		Expect(requests).To(Equal([]string{"INSERT", "CREATING", "READY"}))
+	})
+
+	Context("with a ready timeout", func() {
+		BeforeEach(func() {
+			readyTimeout = 0
+		})
+
+		It("returns an error and cleans up if the disk is not ready in time", func() {
+			statuses = []string{"CREATING"}
+
+			_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out after 0s waiting for Google Disk 'disk-fake-uuid' to be ready, status is 'CREATING'"))
+			Expect(requests).To(ContainElement("DELETE"))
+		})
+	})
+
+	It("returns right away if the disk is already ready", func() {
+		statuses = []string{"READY"}
+
+		_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"INSERT", "READY"}))
+	})
+
+	It("returns an error and cleans up if the disk fails to be created", func() {
+		statuses = []string{"FAILED"}
+
+		_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Google Disk 'disk-fake-uuid' failed to be created"))
+		Expect(requests).To(ContainElement("DELETE"))
+	})
+
+	Context("when disks are pinned to the beta API", func() {
+		BeforeEach(func() {
+			apiVersion = config.APIVersionBeta
+		})
+
+		It("creates the disk through the beta API", func() {
+			diskID, err := diskService.Create(32, "fake-disk-type", "fake-zone", map[string]string{"fake-label": "fake-value"}, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskID).To(Equal("disk-fake-uuid"))
+			Expect(requests).To(Equal([]string{"BETA_INSERT", "CREATING", "READY"}))
+			Expect(inserted.Labels).To(Equal(map[string]string{"fake-label": "fake-value"}))
+			Expect(inserted.Type).To(Equal("fake-disk-type"))
+		})
+	})
+
+	Context("when disks are pinned to the v1 API", func() {
+		BeforeEach(func() {
+			apiVersion = config.APIVersionV1
+		})
+
+		It("creates the disk through the v1 API", func() {
+			_, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"INSERT", "CREATING", "READY"}))
+		})
+	})
+})
@@ -5,28 +5,65 @@ import (
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/util"
+	"google.golang.org/api/googleapi"
 )
 
 func (d GoogleDiskService) Delete(id string) error {
-	disk, found, err := d.Find(id, "")
+	return d.delete(id, func() (Disk, bool, error) {
+		return d.Find(id, "")
+	}, d.deleteZonal)
+}
+
+func (d GoogleDiskService) deleteZonal(disk Disk) error {
+	operation, err := d.computeService.Disks.Delete(d.project, util.ResourceSplitter(disk.Zone), disk.Name).Do()
+	if err != nil {
+		return err
+	}
+	_, err = d.operationService.Waiter(operation, disk.Zone, "")
+	return err
+}
+
+// DeleteInRegion deletes a regional disk like Delete. Regional disks are only
+// exposed by the beta API.
+func (d GoogleDiskService) DeleteInRegion(id string, region string) error {
+	return d.delete(id, func() (Disk, bool, error) {
+		return d.FindInRegion(id, region)
+	}, func(disk Disk) error {
+		operation, err := d.computeServiceB.RegionDisks.Delete(d.project, util.ResourceSplitter(region), id).Do()
+		if err != nil {
+			return err
+		}
+		_, err = d.operationService.WaiterB(operation, "", region)
+		return err
+	})
+}
+
+func (d GoogleDiskService) delete(id string, find func() (Disk, bool, error), del func(Disk) error) error {
+	if d.dryRun {
+		d.logger.Warn(googleDiskServiceLogTag, "Dry run, not deleting Google Disk '%s'", id)
+		return nil
+	}
+
+	disk, found, err := find()
 	if err != nil {
 		return err
 	}
 	if !found {
+		d.logger.Info(googleDiskServiceLogTag, "Google Disk '%s' does not exist, it may have already been deleted", id)
 		return api.NewDiskNotFoundError(id, false)
 	}
 
 	if disk.Status != googleDiskReadyStatus && disk.Status != googleDiskFailedStatus {
-		return bosherr.WrapErrorf(err, "Cannot delete Google Disk '%s', status is '%s'", id, disk.Status)
+		return bosherr.Errorf("Cannot delete Google Disk '%s', status is '%s'", id, disk.Status)
 	}
 
 	d.logger.Debug(googleDiskServiceLogTag, "Deleting Google Disk '%s'", id)
-	operation, err := d.computeService.Disks.Delete(d.project, util.ResourceSplitter(disk.Zone), id).Do()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to delete Google Disk '%s'", id)
-	}
-
-	if _, err = d.operationService.Waiter(operation, disk.Zone, ""); err != nil {
+	if err := del(disk); err != nil {
+		// The disk was deleted since it was found
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			d.logger.Info(googleDiskServiceLogTag, "Google Disk '%s' does not exist, it may have already been deleted", id)
+			return api.NewDiskNotFoundError(id, false)
+		}
 		return bosherr.WrapErrorf(err, "Failed to delete Google Disk '%s'", id)
 	}
 
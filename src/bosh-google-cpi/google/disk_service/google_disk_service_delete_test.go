@@ -0,0 +1,134 @@
+package disk_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	"bosh-google-cpi/api"
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService Delete", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		diskListed   bool
+		status       string
+		deleteStatus int
+
+		operationService *operationfakes.FakeOperationService
+		diskService      GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		diskListed = true
+		status = "READY"
+		deleteStatus = http.StatusOK
+		operationService = &operationfakes.FakeOperationService{}
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/disks", func(w http.ResponseWriter, r *http.Request) {
+			if diskListed {
+				fmt.Fprintf(w, `{"items": {"zones/fake-zone": {"disks": [{"name": "fake-disk", "zone": "fake-zone", "status": "%s"}]}}}`, status)
+				return
+			}
+			fmt.Fprint(w, `{"items": {}}`)
+		})
+		server.Handle("GET", "/fake-project/regions/fake-region/disks/fake-disk", func(w http.ResponseWriter, r *http.Request) {
+			if !diskListed {
+				clientfakes.WriteError(w, http.StatusNotFound, "not found")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-disk", "region": "fake-region", "status": "READY"}`)
+		})
+		for _, diskPath := range []string{"/fake-project/zones/fake-zone/disks/fake-disk", "/fake-project/regions/fake-region/disks/fake-disk"} {
+			server.Handle("DELETE", diskPath, func(w http.ResponseWriter, r *http.Request) {
+				if deleteStatus != http.StatusOK {
+					clientfakes.WriteError(w, deleteStatus, "fake-error")
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			})
+		}
+
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			server.ComputeService(),
+			server.ComputeBetaService(),
+			operationService,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("deletes the disk", func() {
+		Expect(diskService.Delete("fake-disk")).To(Succeed())
+		Expect(operationService.WaiterCalled).To(BeTrue())
+	})
+
+	It("returns a DiskNotFoundError if the disk does not exist", func() {
+		diskListed = false
+
+		err := diskService.Delete("fake-disk")
+		Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+	})
+
+	It("returns a DiskNotFoundError if the disk is deleted meanwhile", func() {
+		deleteStatus = http.StatusNotFound
+
+		err := diskService.Delete("fake-disk")
+		Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+	})
+
+	It("does not delete a disk that is still being created", func() {
+		status = "CREATING"
+
+		err := diskService.Delete("fake-disk")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Cannot delete Google Disk 'fake-disk', status is 'CREATING'"))
+		Expect(operationService.WaiterCalled).To(BeFalse())
+	})
+
+	It("returns other errors", func() {
+		deleteStatus = http.StatusForbidden
+
+		err := diskService.Delete("fake-disk")
+		Expect(err).To(HaveOccurred())
+		Expect(err).NotTo(BeAssignableToTypeOf(api.DiskNotFoundError{}))
+		Expect(err.Error()).To(ContainSubstring("Failed to delete Google Disk 'fake-disk'"))
+	})
+	Describe("DeleteInRegion", func() {
+		It("deletes the regional disk", func() {
+			Expect(diskService.DeleteInRegion("fake-disk", "fake-region")).To(Succeed())
+			Expect(operationService.WaiterBCalled).To(BeTrue())
+		})
+
+		It("returns a DiskNotFoundError if the regional disk does not exist", func() {
+			diskListed = false
+
+			err := diskService.DeleteInRegion("fake-disk", "fake-region")
+			Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+		})
+
+		It("returns a DiskNotFoundError if the regional disk is deleted meanwhile", func() {
+			deleteStatus = http.StatusNotFound
+
+			err := diskService.DeleteInRegion("fake-disk", "fake-region")
+			Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+		})
+	})
+})
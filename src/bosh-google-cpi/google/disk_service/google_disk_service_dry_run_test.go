@@ -0,0 +1,57 @@
+package disk_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService in dry run", func() {
+	var (
+		server *clientfakes.FakeComputeServer
+
+		diskService GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		server = clientfakes.NewFakeComputeServer()
+
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			true,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Create", func() {
+		It("returns a disk name without inserting the disk", func() {
+			diskID, err := diskService.Create(32, "fake-disk-type", "fake-zone", nil, "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diskID).To(Equal("disk-fake-uuid"))
+			Expect(server.Requests()).To(BeEmpty())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("does not call the API", func() {
+			Expect(diskService.Delete("fake-disk-id")).To(Succeed())
+			Expect(server.Requests()).To(BeEmpty())
+		})
+	})
+})
@@ -6,6 +6,7 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/util"
+	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
 )
 
@@ -21,13 +22,7 @@ func (d GoogleDiskService) Find(id string, zone string) (Disk, bool, error) {
 		for _, diskItems := range disks.Items {
 			for _, diskItem := range diskItems.Disks {
 				// Return the first disk (it can only be 1 disk with the same name across all zones)
-				disk := Disk{
-					Name:     diskItem.Name,
-					SelfLink: diskItem.SelfLink,
-					Status:   diskItem.Status,
-					Zone:     diskItem.Zone,
-				}
-				return disk, true, nil
+				return newDisk(diskItem), true, nil
 			}
 		}
 
@@ -44,11 +39,49 @@ func (d GoogleDiskService) Find(id string, zone string) (Disk, bool, error) {
 		return Disk{}, false, bosherr.WrapErrorf(err, "Failed to find Google Disk '%s' in zone '%s'", id, util.ResourceSplitter(zone))
 	}
 
-	disk := Disk{
+	return newDisk(diskItem), true, nil
+}
+
+// FindInRegion finds a regional disk. Regional disks are only exposed by the
+// beta API, and are not listed by Find.
+func (d GoogleDiskService) FindInRegion(id string, region string) (Disk, bool, error) {
+	d.logger.Debug(googleDiskServiceLogTag, "Finding Google Disk '%s' in region '%s'", id, region)
+	diskItem, err := d.computeServiceB.RegionDisks.Get(d.project, util.ResourceSplitter(region), id).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			return Disk{}, false, nil
+		}
+
+		return Disk{}, false, bosherr.WrapErrorf(err, "Failed to find Google Disk '%s' in region '%s'", id, util.ResourceSplitter(region))
+	}
+
+	return Disk{
+		Name:     diskItem.Name,
+		SelfLink: diskItem.SelfLink,
+		Status:   diskItem.Status,
+		Region:   diskItem.Region,
+		Type:     diskItem.Type,
+		SizeGb:   diskItem.SizeGb,
+		Labels:   diskItem.Labels,
+		Users:    diskItem.Users,
+
+		CreationTimestamp: diskItem.CreationTimestamp,
+		LabelFingerprint:  diskItem.LabelFingerprint,
+	}, true, nil
+}
+
+func newDisk(diskItem *compute.Disk) Disk {
+	return Disk{
 		Name:     diskItem.Name,
 		SelfLink: diskItem.SelfLink,
 		Status:   diskItem.Status,
 		Zone:     diskItem.Zone,
+		Type:     diskItem.Type,
+		SizeGb:   diskItem.SizeGb,
+		Labels:   diskItem.Labels,
+		Users:    diskItem.Users,
+
+		CreationTimestamp: diskItem.CreationTimestamp,
+		LabelFingerprint:  diskItem.LabelFingerprint,
 	}
-	return disk, true, nil
 }
@@ -0,0 +1,37 @@
+package disk
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+// FindByLabels finds the disks carrying all the labels, in every zone.
+func (d GoogleDiskService) FindByLabels(labels map[string]string) ([]Disk, error) {
+	var found []Disk
+
+	d.logger.Debug(googleDiskServiceLogTag, "Finding Google Disks labelled '%v'", labels)
+	call := d.computeService.Disks.AggregatedList(d.project).Filter(util.LabelsFilter(labels))
+	for {
+		disks, err := call.Do()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Failed to find Google Disks labelled '%v'", labels)
+		}
+
+		for _, diskItems := range disks.Items {
+			for _, diskItem := range diskItems.Disks {
+				// The filter is applied by the API, this only guards against
+				// filters it would ignore
+				if !util.HasLabels(diskItem.Labels, labels) {
+					continue
+				}
+				found = append(found, newDisk(diskItem))
+			}
+		}
+
+		if disks.NextPageToken == "" {
+			return found, nil
+		}
+		call = call.PageToken(disks.NextPageToken)
+	}
+}
@@ -0,0 +1,71 @@
+package disk_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService FindByLabels", func() {
+	var (
+		server  *clientfakes.FakeComputeServer
+		filters []string
+
+		diskService GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		filters = nil
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/disks", func(w http.ResponseWriter, r *http.Request) {
+			filters = append(filters, r.URL.Query().Get("filter"))
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items": {"zones/fake-zone": {"disks": [
+					{"name": "fake-managed-disk", "zone": "fake-zone", "labels": {"director": "fake-director"}, "creationTimestamp": "fake-timestamp"},
+					{"name": "fake-unlabelled-disk", "zone": "fake-zone"}
+				]}}, "nextPageToken": "fake-page-token"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items": {"zones/fake-other-zone": {"disks": [
+				{"name": "fake-other-managed-disk", "zone": "fake-other-zone", "labels": {"director": "fake-director"}},
+				{"name": "fake-other-director-disk", "zone": "fake-other-zone", "labels": {"director": "fake-other-director"}}
+			]}}}`)
+		})
+
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the disks of every page carrying the labels", func() {
+		disks, err := diskService.FindByLabels(map[string]string{"director": "fake-director"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filters).To(Equal([]string{"(labels.director eq fake-director)", "(labels.director eq fake-director)"}))
+		Expect(disks).To(HaveLen(2))
+		Expect(disks[0].Name).To(Equal("fake-managed-disk"))
+		Expect(disks[0].CreationTimestamp).To(Equal("fake-timestamp"))
+		Expect(disks[1].Name).To(Equal("fake-other-managed-disk"))
+	})
+})
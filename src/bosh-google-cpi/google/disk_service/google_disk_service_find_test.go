@@ -0,0 +1,78 @@
+package disk_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService FindInRegion", func() {
+	var (
+		server      *clientfakes.FakeComputeServer
+		getStatus   int
+		diskService GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		getStatus = http.StatusOK
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/regions/fake-region/disks/fake-disk", func(w http.ResponseWriter, r *http.Request) {
+			if getStatus != http.StatusOK {
+				clientfakes.WriteError(w, getStatus, "fake-error")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-disk", "region": "fake-region", "status": "READY", "sizeGb": "10"}`)
+		})
+
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			nil,
+			server.ComputeBetaService(),
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("finds the regional disk", func() {
+		disk, found, err := diskService.FindInRegion("fake-disk", "fake-region")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeTrue())
+		Expect(disk.Name).To(Equal("fake-disk"))
+		Expect(disk.Region).To(Equal("fake-region"))
+		Expect(disk.SizeGb).To(Equal(int64(10)))
+	})
+
+	It("returns false if the regional disk does not exist", func() {
+		getStatus = http.StatusNotFound
+
+		_, found, err := diskService.FindInRegion("fake-disk", "fake-region")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(found).To(BeFalse())
+	})
+
+	It("returns other errors", func() {
+		getStatus = http.StatusForbidden
+
+		_, _, err := diskService.FindInRegion("fake-disk", "fake-region")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Failed to find Google Disk 'fake-disk' in region 'fake-region'"))
+	})
+})
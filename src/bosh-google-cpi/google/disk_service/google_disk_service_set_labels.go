@@ -0,0 +1,98 @@
+package disk
+
+import (
+	"net/http"
+	"reflect"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/util"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Labels updates are guarded by the label fingerprint. When another update
+// lands between reading the disk and writing it back the request is
+// rejected, so the disk is read again and the update retried.
+const setLabelsMaxAttempts = 3
+
+// SetLabels updates the labels of a zonal disk, looked up across all zones
+// when zone is empty. Labels cleared with an empty value are removed, and
+// the other labels of the disk are preserved.
+func (d GoogleDiskService) SetLabels(id string, zone string, labels map[string]string) error {
+	return d.setLabels(id, labels, func() (Disk, bool, error) {
+		return d.Find(id, zone)
+	}, func(disk Disk, request *compute.ZoneSetLabelsRequest) error {
+		operation, err := d.computeService.Disks.SetLabels(d.project, util.ResourceSplitter(disk.Zone), id, request).Do()
+		if err != nil {
+			return err
+		}
+		_, err = d.operationService.Waiter(operation, disk.Zone, "")
+		return err
+	})
+}
+
+// SetLabelsInRegion updates the labels of a regional disk like SetLabels.
+// Regional disks are only exposed by the beta API.
+func (d GoogleDiskService) SetLabelsInRegion(id string, region string, labels map[string]string) error {
+	return d.setLabels(id, labels, func() (Disk, bool, error) {
+		return d.FindInRegion(id, region)
+	}, func(disk Disk, request *compute.ZoneSetLabelsRequest) error {
+		requestB := &computebeta.RegionSetLabelsRequest{
+			LabelFingerprint: request.LabelFingerprint,
+			Labels:           request.Labels,
+		}
+		operation, err := d.computeServiceB.RegionDisks.SetLabels(d.project, util.ResourceSplitter(region), id, requestB).Do()
+		if err != nil {
+			return err
+		}
+		_, err = d.operationService.WaiterB(operation, "", region)
+		return err
+	})
+}
+
+func (d GoogleDiskService) setLabels(id string, labels map[string]string, find func() (Disk, bool, error), set func(Disk, *compute.ZoneSetLabelsRequest) error) error {
+	for attempt := 1; ; attempt++ {
+		disk, found, err := find()
+		if err != nil {
+			return err
+		}
+		if !found {
+			return api.NewDiskNotFoundError(id, false)
+		}
+
+		// Preserve the labels set by other tooling, such as the ephemeral
+		// and mode labels of the CPI
+		labelsMap := make(map[string]string)
+		for k, v := range disk.Labels {
+			labelsMap[k] = v
+		}
+		for k, v := range labels {
+			if v == "" {
+				delete(labelsMap, k)
+				continue
+			}
+			labelsMap[k] = v
+		}
+		if reflect.DeepEqual(labelsMap, disk.Labels) || len(labelsMap)+len(disk.Labels) == 0 {
+			return nil
+		}
+
+		d.logger.Debug(googleDiskServiceLogTag, "Setting labels for Google Disk '%s'", id)
+		err = set(disk, &compute.ZoneSetLabelsRequest{
+			LabelFingerprint: disk.LabelFingerprint,
+			Labels:           labelsMap,
+		})
+		if err != nil {
+			if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusPreconditionFailed && attempt < setLabelsMaxAttempts {
+				d.logger.Debug(googleDiskServiceLogTag, "Label fingerprint for Google Disk '%s' changed, retrying (%d/%d)", id, attempt, setLabelsMaxAttempts)
+				continue
+			}
+			return bosherr.WrapErrorf(err, "Failed to set labels for Google Disk '%s'", id)
+		}
+
+		return nil
+	}
+}
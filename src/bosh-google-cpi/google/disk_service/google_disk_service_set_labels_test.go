@@ -0,0 +1,154 @@
+package disk_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	"bosh-google-cpi/api"
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/disk_service"
+)
+
+var _ = Describe("GoogleDiskService SetLabels", func() {
+	var (
+		server           *clientfakes.FakeComputeServer
+		diskFound        bool
+		fingerprints     []string
+		conflicts        int
+		setRequests      []map[string]interface{}
+		operationService *operationfakes.FakeOperationService
+		diskService      GoogleDiskService
+	)
+
+	BeforeEach(func() {
+		diskFound = true
+		fingerprints = []string{"fake-fingerprint"}
+		conflicts = 0
+		setRequests = nil
+		operationService = &operationfakes.FakeOperationService{}
+		server = clientfakes.NewFakeComputeServer()
+		for _, diskPath := range []string{"/fake-project/zones/fake-zone/disks/fake-disk", "/fake-project/regions/fake-region/disks/fake-disk"} {
+			server.Handle("GET", diskPath, func(w http.ResponseWriter, r *http.Request) {
+				if !diskFound {
+					clientfakes.WriteError(w, http.StatusNotFound, "not found")
+					return
+				}
+				fingerprint := fingerprints[0]
+				if len(fingerprints) > 1 {
+					fingerprints = fingerprints[1:]
+				}
+				fmt.Fprintf(w, `{"name": "fake-disk", "zone": "fake-zone", "region": "fake-region", "labels": {"ephemeral": "false", "director": "fake-director"}, "labelFingerprint": %q}`, fingerprint)
+			})
+			server.Handle("POST", diskPath+"/setLabels", func(w http.ResponseWriter, r *http.Request) {
+				var request map[string]interface{}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).To(Succeed())
+				setRequests = append(setRequests, request)
+				if conflicts > 0 {
+					conflicts--
+					clientfakes.WriteError(w, http.StatusPreconditionFailed, "Labels fingerprint either invalid or resource labels have changed")
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			})
+		}
+
+		diskService = NewGoogleDiskService(
+			"fake-project",
+			server.ComputeService(),
+			server.ComputeBetaService(),
+			operationService,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("with a zonal disk", func() {
+		It("merges the labels with the labels of the disk", func() {
+			err := diskService.SetLabels("fake-disk", "fake-zone", map[string]string{"deployment": "fake-deployment", "director": ""})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setRequests).To(Equal([]map[string]interface{}{{
+				"labelFingerprint": "fake-fingerprint",
+				"labels":           map[string]interface{}{"ephemeral": "false", "deployment": "fake-deployment"},
+			}}))
+			Expect(operationService.WaiterCalled).To(BeTrue())
+		})
+
+		It("does not update the disk when the labels are unchanged", func() {
+			err := diskService.SetLabels("fake-disk", "fake-zone", map[string]string{"director": "fake-director", "deployment": ""})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setRequests).To(BeEmpty())
+		})
+
+		It("reads the disk again and retries when the label fingerprint changed", func() {
+			fingerprints = []string{"fake-fingerprint", "fake-new-fingerprint"}
+			conflicts = 1
+
+			err := diskService.SetLabels("fake-disk", "fake-zone", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setRequests).To(HaveLen(2))
+			Expect(setRequests[0]["labelFingerprint"]).To(Equal("fake-fingerprint"))
+			Expect(setRequests[1]["labelFingerprint"]).To(Equal("fake-new-fingerprint"))
+		})
+
+		It("returns an error when the label fingerprint keeps changing", func() {
+			conflicts = 3
+
+			err := diskService.SetLabels("fake-disk", "fake-zone", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to set labels for Google Disk 'fake-disk'"))
+			Expect(setRequests).To(HaveLen(3))
+		})
+
+		It("returns a disk not found error when the disk does not exist", func() {
+			diskFound = false
+
+			err := diskService.SetLabels("fake-disk", "fake-zone", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+		})
+	})
+
+	Context("with a regional disk", func() {
+		It("merges the labels with the labels of the disk", func() {
+			err := diskService.SetLabelsInRegion("fake-disk", "fake-region", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setRequests).To(Equal([]map[string]interface{}{{
+				"labelFingerprint": "fake-fingerprint",
+				"labels":           map[string]interface{}{"ephemeral": "false", "director": "fake-director", "deployment": "fake-deployment"},
+			}}))
+			Expect(operationService.WaiterBCalled).To(BeTrue())
+		})
+
+		It("reads the disk again and retries when the label fingerprint changed", func() {
+			fingerprints = []string{"fake-fingerprint", "fake-new-fingerprint"}
+			conflicts = 1
+
+			err := diskService.SetLabelsInRegion("fake-disk", "fake-region", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(setRequests).To(HaveLen(2))
+			Expect(setRequests[1]["labelFingerprint"]).To(Equal("fake-new-fingerprint"))
+		})
+
+		It("returns a disk not found error when the disk does not exist", func() {
+			diskFound = false
+
+			err := diskService.SetLabelsInRegion("fake-disk", "fake-region", map[string]string{"deployment": "fake-deployment"})
+			Expect(err).To(Equal(api.NewDiskNotFoundError("fake-disk", false)))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+package fakes
+
+import (
+	"bosh-google-cpi/google/firewall_service"
+)
+
+type FakeFirewallService struct {
+	CreateCalled  bool
+	CreateErr     error
+	CreateID      string
+	CreateNetwork string
+	CreateTag     string
+
+	DeleteCalled bool
+	DeleteErr    error
+	DeleteID     string
+
+	FindCalled   bool
+	FindFound    bool
+	FindFirewall firewall.Firewall
+	FindErr      error
+}
+
+func (f *FakeFirewallService) Create(id string, network string, tag string) error {
+	f.CreateCalled = true
+	f.CreateID = id
+	f.CreateNetwork = network
+	f.CreateTag = tag
+	return f.CreateErr
+}
+
+func (f *FakeFirewallService) Delete(id string) error {
+	f.DeleteCalled = true
+	f.DeleteID = id
+	return f.DeleteErr
+}
+
+func (f *FakeFirewallService) Find(id string) (firewall.Firewall, bool, error) {
+	f.FindCalled = true
+	return f.FindFirewall, f.FindFound, f.FindErr
+}
@@ -0,0 +1,50 @@
+package firewall
+
+import (
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+)
+
+const deploymentTagPrefix = "bosh-deployment-"
+const maxTagLength = 63
+
+var invalidTagCharsRe = regexp.MustCompile("[^a-z0-9-]+")
+
+type Firewall struct {
+	Name        string
+	Description string
+	Network     string
+	SourceTags  []string
+	TargetTags  []string
+}
+
+func (f Firewall) IsManaged() bool {
+	return f.Description == googleFirewallDescription
+}
+
+// DeploymentTag returns the network tag isolating a deployment, which also
+// names its firewall rule. Deployment names that are not valid tags as they
+// are get a hash of the name appended, so they can not collide.
+func DeploymentTag(deployment string) string {
+	name := strings.ToLower(deployment)
+	name = strings.Trim(invalidTagCharsRe.ReplaceAllString(name, "-"), "-")
+
+	tag := deploymentTagPrefix + name
+	if name == deployment && len(tag) <= maxTagLength {
+		return tag
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(deployment))
+	suffix := fmt.Sprintf("-%08x", hash.Sum32())
+	if len(tag)+len(suffix) > maxTagLength {
+		tag = strings.TrimRight(tag[:maxTagLength-len(suffix)], "-")
+	}
+	return tag + suffix
+}
+
+func IsDeploymentTag(tag string) bool {
+	return strings.HasPrefix(tag, deploymentTagPrefix)
+}
@@ -0,0 +1,7 @@
+package firewall
+
+type Service interface {
+	Create(id string, network string, tag string) error
+	Delete(id string) error
+	Find(id string) (Firewall, bool, error)
+}
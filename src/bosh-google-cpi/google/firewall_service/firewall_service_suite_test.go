@@ -0,0 +1,13 @@
+package firewall_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestFirewallService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Firewall Service Suite")
+}
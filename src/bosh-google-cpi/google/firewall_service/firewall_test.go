@@ -0,0 +1,39 @@
+package firewall_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/firewall_service"
+)
+
+var _ = Describe("Firewall", func() {
+	Describe("DeploymentTag", func() {
+		It("prefixes deployment names that are valid tags", func() {
+			Expect(DeploymentTag("cf")).To(Equal("bosh-deployment-cf"))
+		})
+
+		It("hashes deployment names that are not valid tags, so they do not collide", func() {
+			tag := DeploymentTag("my_cf")
+			Expect(tag).To(MatchRegexp(`^bosh-deployment-my-cf-[0-9a-f]{8}$`))
+			Expect(tag).NotTo(Equal(DeploymentTag("my-cf")))
+			Expect(tag).NotTo(Equal(DeploymentTag("My_cf")))
+		})
+
+		It("truncates long deployment names", func() {
+			tag := DeploymentTag(strings.Repeat("a", 80))
+			Expect(len(tag)).To(BeNumerically("<=", 63))
+			Expect(tag).To(MatchRegexp(`^bosh-deployment-a+-[0-9a-f]{8}$`))
+			Expect(tag).NotTo(Equal(DeploymentTag(strings.Repeat("a", 81))))
+		})
+	})
+
+	Describe("IsDeploymentTag", func() {
+		It("recognizes deployment tags", func() {
+			Expect(IsDeploymentTag(DeploymentTag("cf"))).To(BeTrue())
+			Expect(IsDeploymentTag("fake-tag")).To(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,32 @@
+package firewall
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"bosh-google-cpi/google/operation_service"
+	"google.golang.org/api/compute/v1"
+)
+
+const googleFirewallServiceLogTag = "GoogleFirewallService"
+const googleFirewallDescription = "Firewall rule managed by BOSH"
+
+type GoogleFirewallService struct {
+	project          string
+	computeService   *compute.Service
+	operationService operation.Service
+	logger           boshlog.Logger
+}
+
+func NewGoogleFirewallService(
+	project string,
+	computeService *compute.Service,
+	operationService operation.Service,
+	logger boshlog.Logger,
+) GoogleFirewallService {
+	return GoogleFirewallService{
+		project:          project,
+		computeService:   computeService,
+		operationService: operationService,
+		logger:           logger,
+	}
+}
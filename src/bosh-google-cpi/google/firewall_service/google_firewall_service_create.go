@@ -0,0 +1,40 @@
+package firewall
+
+import (
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
+)
+
+// Create creates a firewall rule allowing all traffic between the instances
+// tagged with tag, and no other.
+func (f GoogleFirewallService) Create(id string, network string, tag string) error {
+	firewall := &compute.Firewall{
+		Name:        id,
+		Description: googleFirewallDescription,
+		Network:     network,
+		Direction:   "INGRESS",
+		Allowed:     []*compute.FirewallAllowed{{IPProtocol: "all"}},
+		SourceTags:  []string{tag},
+		TargetTags:  []string{tag},
+	}
+
+	f.logger.Debug(googleFirewallServiceLogTag, "Creating Google Firewall with params: %#v", firewall)
+	operation, err := f.computeService.Firewalls.Insert(f.project, firewall).Do()
+	if err != nil {
+		// Another VM of the deployment created it meanwhile
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusConflict {
+			return nil
+		}
+		return bosherr.WrapErrorf(err, "Failed to create Google Firewall '%s'", id)
+	}
+
+	if _, err = f.operationService.Waiter(operation, "", ""); err != nil {
+		return bosherr.WrapErrorf(err, "Failed to create Google Firewall '%s'", id)
+	}
+
+	return nil
+}
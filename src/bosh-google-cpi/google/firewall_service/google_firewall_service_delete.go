@@ -0,0 +1,27 @@
+package firewall
+
+import (
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"google.golang.org/api/googleapi"
+)
+
+func (f GoogleFirewallService) Delete(id string) error {
+	f.logger.Debug(googleFirewallServiceLogTag, "Deleting Google Firewall '%s'", id)
+	operation, err := f.computeService.Firewalls.Delete(f.project, id).Do()
+	if err != nil {
+		// Another VM of the deployment deleted it meanwhile
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return nil
+		}
+		return bosherr.WrapErrorf(err, "Failed to delete Google Firewall '%s'", id)
+	}
+
+	if _, err = f.operationService.Waiter(operation, "", ""); err != nil {
+		return bosherr.WrapErrorf(err, "Failed to delete Google Firewall '%s'", id)
+	}
+
+	return nil
+}
@@ -0,0 +1,30 @@
+package firewall
+
+import (
+	"net/http"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"google.golang.org/api/googleapi"
+)
+
+func (f GoogleFirewallService) Find(id string) (Firewall, bool, error) {
+	f.logger.Debug(googleFirewallServiceLogTag, "Finding Google Firewall '%s'", id)
+	firewallItem, err := f.computeService.Firewalls.Get(f.project, id).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusNotFound {
+			return Firewall{}, false, nil
+		}
+
+		return Firewall{}, false, bosherr.WrapErrorf(err, "Failed to find Google Firewall '%s'", id)
+	}
+
+	firewall := Firewall{
+		Name:        firewallItem.Name,
+		Description: firewallItem.Description,
+		Network:     firewallItem.Network,
+		SourceTags:  firewallItem.SourceTags,
+		TargetTags:  firewallItem.TargetTags,
+	}
+	return firewall, true, nil
+}
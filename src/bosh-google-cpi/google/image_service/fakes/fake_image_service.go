@@ -11,12 +11,21 @@ type FakeImageService struct {
 	CreateFromURLSourceURL   string
 	CreateFromURLSourceSha1  string
 	CreateFromURLDescription string
+	CreateFromURLProperties  image.Properties
 
 	CreateFromTarballCalled      bool
 	CreateFromTarballErr         error
 	CreateFromTarballID          string
 	CreateFromTarballImagePath   string
 	CreateFromTarballDescription string
+	CreateFromTarballProperties  image.Properties
+
+	CreateFromDiskCalled      bool
+	CreateFromDiskErr         error
+	CreateFromDiskID          string
+	CreateFromDiskDiskLink    string
+	CreateFromDiskDescription string
+	CreateFromDiskProperties  image.Properties
 
 	DeleteCalled bool
 	DeleteErr    error
@@ -25,23 +34,39 @@ type FakeImageService struct {
 	FindFound  bool
 	FindImage  image.Image
 	FindErr    error
+
+	FindByFamilyCalled bool
+	FindByFamilyFamily string
+	FindByFamilyFound  bool
+	FindByFamilyImage  image.Image
+	FindByFamilyErr    error
 }
 
-func (i *FakeImageService) CreateFromURL(sourceURL string, sourceSha1 string, description string) (string, error) {
+func (i *FakeImageService) CreateFromURL(sourceURL string, sourceSha1 string, description string, props image.Properties) (string, error) {
 	i.CreateFromURLCalled = true
 	i.CreateFromURLSourceURL = sourceURL
 	i.CreateFromURLSourceSha1 = sourceSha1
 	i.CreateFromURLDescription = description
+	i.CreateFromURLProperties = props
 	return i.CreateFromURLID, i.CreateFromURLErr
 }
 
-func (i *FakeImageService) CreateFromTarball(imagePath string, description string) (string, error) {
+func (i *FakeImageService) CreateFromTarball(imagePath string, description string, props image.Properties) (string, error) {
 	i.CreateFromTarballCalled = true
 	i.CreateFromTarballImagePath = imagePath
 	i.CreateFromTarballDescription = description
+	i.CreateFromTarballProperties = props
 	return i.CreateFromTarballID, i.CreateFromTarballErr
 }
 
+func (i *FakeImageService) CreateFromDisk(diskLink string, description string, props image.Properties) (string, error) {
+	i.CreateFromDiskCalled = true
+	i.CreateFromDiskDiskLink = diskLink
+	i.CreateFromDiskDescription = description
+	i.CreateFromDiskProperties = props
+	return i.CreateFromDiskID, i.CreateFromDiskErr
+}
+
 func (i *FakeImageService) Delete(id string) error {
 	i.DeleteCalled = true
 	return i.DeleteErr
@@ -51,3 +76,9 @@ func (i *FakeImageService) Find(id string) (image.Image, bool, error) {
 	i.FindCalled = true
 	return i.FindImage, i.FindFound, i.FindErr
 }
+
+func (i *FakeImageService) FindByFamily(family string) (image.Image, bool, error) {
+	i.FindByFamilyCalled = true
+	i.FindByFamilyFamily = family
+	return i.FindByFamilyImage, i.FindByFamilyFound, i.FindByFamilyErr
+}
@@ -1,6 +1,8 @@
 package image
 
 import (
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
@@ -22,6 +24,18 @@ type GoogleImageService struct {
 	operationService operation.Service
 	uuidGen          boshuuid.Generator
 	logger           boshlog.Logger
+
+	// stemcellBucket is the bucket stemcell tarballs are uploaded to. Each
+	// tarball is uploaded to a temporary bucket when it is empty.
+	stemcellBucket string
+
+	// uniformBucketLevelAccess leaves out the object ACLs, which buckets
+	// with uniform bucket-level access refuse.
+	uniformBucketLevelAccess bool
+
+	// uploadProgressInterval is the least time between two progress lines
+	// of a stemcell tarball upload.
+	uploadProgressInterval time.Duration
 }
 
 func NewGoogleImageService(
@@ -31,6 +45,9 @@ func NewGoogleImageService(
 	operationService operation.Service,
 	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
+	stemcellBucket string,
+	uniformBucketLevelAccess bool,
+	uploadProgressInterval time.Duration,
 ) GoogleImageService {
 	return GoogleImageService{
 		project:          project,
@@ -39,5 +56,9 @@ func NewGoogleImageService(
 		operationService: operationService,
 		uuidGen:          uuidGen,
 		logger:           logger,
+
+		stemcellBucket:           stemcellBucket,
+		uniformBucketLevelAccess: uniformBucketLevelAccess,
+		uploadProgressInterval:   uploadProgressInterval,
 	}
 }
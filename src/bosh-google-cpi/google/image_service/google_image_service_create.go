@@ -7,6 +7,7 @@ import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/storage/v1"
 )
 
@@ -16,14 +17,14 @@ func (i GoogleImageService) cleanUp(id string) {
 	}
 }
 
-func (i GoogleImageService) CreateFromURL(sourceURL string, sourceSha1 string, description string) (string, error) {
+func (i GoogleImageService) CreateFromURL(sourceURL string, sourceSha1 string, description string, props Properties) (string, error) {
 	uuidStr, err := i.uuidGen.Generate()
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Generating random Google Image name")
 	}
 
 	imageName := fmt.Sprintf("%s-%s", googleImageNamePrefix, uuidStr)
-	image, err := i.create(imageName, description, sourceURL, sourceSha1)
+	image, err := i.create(imageName, description, sourceURL, sourceSha1, props)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Creating Google Image from URL")
 	}
@@ -31,56 +32,77 @@ func (i GoogleImageService) CreateFromURL(sourceURL string, sourceSha1 string, d
 	return image, nil
 }
 
-func (i GoogleImageService) CreateFromTarball(imagePath string, description string) (string, error) {
-	uuidStr, err := i.uuidGen.Generate()
+// CreateFromTarball uploads the raw disk tarball of a google-rawdisk stemcell
+// and creates the image from it. The image file of google-light stemcells is
+// empty, as their image already exists, so it is rejected before uploading.
+func (i GoogleImageService) CreateFromTarball(imagePath string, description string, props Properties) (string, error) {
+	imageFile, err := os.Open(imagePath)
 	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Generating random Google Image name")
+		return "", bosherr.WrapErrorf(err, "Reading stemcell image file")
 	}
+	defer imageFile.Close()
 
-	// Create a temporary bucket
-	imageName := fmt.Sprintf("%s-%s", googleImageNamePrefix, uuidStr)
-	bucket := &storage.Bucket{
-		Name: imageName,
+	imageInfo, err := imageFile.Stat()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Reading stemcell image file")
 	}
-
-	i.logger.Debug(googleImageServiceLogTag, "Creating Google Storage Bucket with params: %#v", bucket)
-	if _, err = i.storageService.Buckets.Insert(i.project, bucket).Do(); err != nil {
-		return "", bosherr.WrapErrorf(err, "Creating Google Storage Bucket")
+	if imageInfo.Size() == 0 {
+		return "", bosherr.Errorf("Stemcell image file '%s' is empty, google-light stemcells must set 'image_url' or 'source_url'", imagePath)
 	}
-	defer i.deleteBucket(imageName)
 
-	// Upload the image file to the previously created bucket
-	objectName := fmt.Sprintf("%s.tar.gz", imageName)
+	uuidStr, err := i.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating random Google Image name")
+	}
 
-	var objectAccessControl []*storage.ObjectAccessControl
-	objectAcl := &storage.ObjectAccessControl{
-		Bucket: imageName,
-		Entity: "allUsers",
-		Object: objectName,
-		Role:   "READER",
+	// Use the stemcell bucket, or else a temporary bucket
+	imageName := fmt.Sprintf("%s-%s", googleImageNamePrefix, uuidStr)
+	bucketName := i.stemcellBucket
+	if bucketName == "" {
+		bucketName = imageName
+		if err = i.createBucket(bucketName); err != nil {
+			return "", err
+		}
+		defer i.deleteBucket(bucketName)
+	} else if err = i.ensureBucket(bucketName); err != nil {
+		return "", err
 	}
-	objectAccessControl = append(objectAccessControl, objectAcl)
 
+	// Upload the image file to the bucket
+	objectName := tarballObjectName(imageName)
+
+	// The metadata is part of the upload request, so the object never
+	// exists without it
 	object := &storage.Object{
-		Name: objectName,
-		Acl:  objectAccessControl,
+		Name:     objectName,
+		Metadata: props.TarballMetadata,
 	}
 
-	imageFile, err := os.Open(imagePath)
-	if err != nil {
-		return "", bosherr.WrapErrorf(err, "Reading stemcell image file")
+	// Buckets with uniform bucket-level access refuse object ACLs
+	if !i.uniformBucketLevelAccess {
+		object.Acl = []*storage.ObjectAccessControl{
+			{
+				Bucket: bucketName,
+				Entity: "allUsers",
+				Object: objectName,
+				Role:   "READER",
+			},
+		}
 	}
-	defer imageFile.Close()
 
 	i.logger.Debug(googleImageServiceLogTag, "Creating Google Storage Object with params: %#v", object)
-	imageObject, err := i.storageService.Objects.Insert(imageName, object).Media(imageFile).Do()
+	progress := newUploadProgress(i.logger, objectName, imageInfo.Size(), i.uploadProgressInterval)
+	imageObject, err := i.storageService.Objects.Insert(bucketName, object).
+		Media(imageFile, googleapi.ChunkSize(uploadChunkSize)).
+		ProgressUpdater(progress.update).
+		Do()
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Creating Google Storage Object")
 	}
-	defer i.deleteObject(imageName, objectName)
+	defer i.deleteObject(bucketName, objectName)
 
 	// Create the image
-	image, err := i.create(imageName, description, imageObject.MediaLink, "")
+	image, err := i.create(imageName, description, imageObject.MediaLink, "", props)
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Creating Google Image from Tarball")
 	}
@@ -88,20 +110,63 @@ func (i GoogleImageService) CreateFromTarball(imagePath string, description stri
 	return image, nil
 }
 
-func (i GoogleImageService) create(name string, description string, sourceURL string, sourceSha1 string) (string, error) {
+// CreateFromDisk creates an image from an existing disk, such as the boot
+// disk of a stopped VM.
+func (i GoogleImageService) CreateFromDisk(diskLink string, description string, props Properties) (string, error) {
+	uuidStr, err := i.uuidGen.Generate()
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Generating random Google Image name")
+	}
+
+	image := &compute.Image{
+		Name:       fmt.Sprintf("%s-%s", googleImageNamePrefix, uuidStr),
+		SourceDisk: diskLink,
+	}
+	name, err := i.insert(image, description, props)
+	if err != nil {
+		return "", bosherr.WrapErrorf(err, "Creating Google Image from Disk")
+	}
+
+	return name, nil
+}
+
+func (i GoogleImageService) create(name string, description string, sourceURL string, sourceSha1 string, props Properties) (string, error) {
+	image := &compute.Image{
+		Name: name,
+		RawDisk: &compute.ImageRawDisk{
+			Source:       sourceURL,
+			Sha1Checksum: sourceSha1,
+		},
+	}
+
+	return i.insert(image, description, props)
+}
+
+func (i GoogleImageService) insert(image *compute.Image, description string, props Properties) (string, error) {
 	if description == "" {
 		description = googleImageDescription
 	}
 
-	rawdisk := &compute.ImageRawDisk{
-		Source:       sourceURL,
-		Sha1Checksum: sourceSha1,
+	var guestOsFeatures []*compute.GuestOsFeature
+	for _, feature := range props.GuestOsFeatures {
+		guestOsFeatures = append(guestOsFeatures, &compute.GuestOsFeature{Type: feature})
 	}
 
-	image := &compute.Image{
-		Name:        name,
-		Description: description,
-		RawDisk:     rawdisk,
+	image.Description = description
+	image.GuestOsFeatures = guestOsFeatures
+	image.Licenses = props.Licenses
+	image.Family = props.Family
+	image.Labels = props.Labels
+
+	// Find the image the new one replaces as the latest of its family
+	var previous Image
+	var replacesPrevious bool
+	if props.Family != "" && props.DeprecationState != "" {
+		var err error
+		previous, replacesPrevious, err = i.FindByFamily(props.Family)
+		if err != nil {
+			return "", err
+		}
 	}
 
 	i.logger.Debug(googleImageServiceLogTag, "Creating Google Image with params: %#v", image)
@@ -115,9 +180,79 @@ func (i GoogleImageService) create(name string, description string, sourceURL st
 		return "", bosherr.WrapErrorf(err, "Failed to create Google Image")
 	}
 
+	// The image is created by now, so failing to deprecate the previous one
+	// does not fail the creation
+	if replacesPrevious && previous.Name != image.Name {
+		if err := i.deprecate(previous.Name, props.DeprecationState, image.Name); err != nil {
+			i.logger.Warn(googleImageServiceLogTag, "Failed to set Google Image '%s' of family '%s' %s: %v", previous.Name, props.Family, props.DeprecationState, err)
+		}
+	}
+
 	return image.Name, nil
 }
 
+// deprecate sets the deprecation state of the image, pointing at its
+// replacement image.
+func (i GoogleImageService) deprecate(id string, state string, replacementID string) error {
+	deprecationStatus := &compute.DeprecationStatus{
+		State:       state,
+		Replacement: fmt.Sprintf("%s%s/global/images/%s", i.computeService.BasePath, i.project, replacementID),
+	}
+
+	i.logger.Debug(googleImageServiceLogTag, "Setting Google Image '%s' %s, replaced by Google Image '%s'", id, state, replacementID)
+	operation, err := i.computeService.Images.Deprecate(i.project, id, deprecationStatus).Do()
+	if err != nil {
+		return err
+	}
+
+	_, err = i.operationService.Waiter(operation, "", "")
+	return err
+}
+
+func tarballObjectName(imageName string) string {
+	return fmt.Sprintf("%s.tar.gz", imageName)
+}
+
+func (i GoogleImageService) createBucket(bucketName string) error {
+	bucket := &storage.Bucket{
+		Name: bucketName,
+	}
+
+	i.logger.Debug(googleImageServiceLogTag, "Creating Google Storage Bucket with params: %#v", bucket)
+	if _, err := i.storageService.Buckets.Insert(i.project, bucket).Do(); err != nil {
+		return bosherr.WrapErrorf(err, "Creating Google Storage Bucket")
+	}
+
+	return nil
+}
+
+// ensureBucket creates the stemcell bucket the first time it is used. The
+// bucket is kept for the next stemcells.
+func (i GoogleImageService) ensureBucket(bucketName string) error {
+	_, err := i.storageService.Buckets.Get(bucketName).Do()
+	if err == nil {
+		return nil
+	}
+	if gerr, ok := err.(*googleapi.Error); !ok || gerr.Code != 404 {
+		return bosherr.WrapErrorf(err, "Finding Google Storage Bucket '%s'", bucketName)
+	}
+
+	bucket := &storage.Bucket{
+		Name: bucketName,
+	}
+
+	i.logger.Debug(googleImageServiceLogTag, "Creating Google Storage Bucket with params: %#v", bucket)
+	if _, err := i.storageService.Buckets.Insert(i.project, bucket).Do(); err != nil {
+		// Another CPI run created the bucket in the meantime
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 409 {
+			return nil
+		}
+		return bosherr.WrapErrorf(err, "Creating Google Storage Bucket")
+	}
+
+	return nil
+}
+
 func (i GoogleImageService) deleteObject(bucketName string, objectName string) error {
 	i.logger.Debug(googleImageServiceLogTag, "Deleting Google Storage Object '%s' from Google Storage Bucket '%s'", objectName, bucketName)
 	if err := i.storageService.Objects.Delete(bucketName, objectName).Do(); err != nil {
@@ -0,0 +1,279 @@
+package image_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/operation_service"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/image_service"
+)
+
+var _ = Describe("GoogleImageService CreateFromURL", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		inserted     compute.Image
+		imageService GoogleImageService
+	)
+
+	BeforeEach(func() {
+		inserted = compute.Image{}
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("POST", "/fake-project/global/images", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			Expect(json.Unmarshal(body, &inserted)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		imageService = NewGoogleImageService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			"",
+			false,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the image", func() {
+		id, err := imageService.CreateFromURL("fake-source-url", "fake-source-sha1", "fake-description", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("stemcell-fake-uuid"))
+		Expect(inserted.RawDisk.Source).To(Equal("fake-source-url"))
+		Expect(inserted.GuestOsFeatures).To(BeEmpty())
+		Expect(inserted.Licenses).To(BeEmpty())
+	})
+
+	It("attaches the guest OS features and licenses to the image", func() {
+		_, err := imageService.CreateFromURL("fake-source-url", "fake-source-sha1", "fake-description", Properties{
+			GuestOsFeatures: []string{GuestOsFeatureUEFICompatible, GuestOsFeatureGVNIC},
+			Licenses:        []string{"fake-license"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var features []string
+		for _, feature := range inserted.GuestOsFeatures {
+			features = append(features, feature.Type)
+		}
+		Expect(features).To(Equal([]string{"UEFI_COMPATIBLE", "GVNIC"}))
+		Expect(inserted.Licenses).To(Equal([]string{"fake-license"}))
+	})
+})
+
+var _ = Describe("GoogleImageService CreateFromDisk", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		inserted     compute.Image
+		imageService GoogleImageService
+	)
+
+	BeforeEach(func() {
+		inserted = compute.Image{}
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("POST", "/fake-project/global/images", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			Expect(json.Unmarshal(body, &inserted)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		imageService = NewGoogleImageService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			"",
+			false,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the image from the disk with its family and labels", func() {
+		id, err := imageService.CreateFromDisk("fake-disk-self-link", "", Properties{
+			Family: "fake-family",
+			Labels: map[string]string{"fake-key": "fake-value"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("stemcell-fake-uuid"))
+		Expect(inserted.Name).To(Equal("stemcell-fake-uuid"))
+		Expect(inserted.SourceDisk).To(Equal("fake-disk-self-link"))
+		Expect(inserted.RawDisk).To(BeNil())
+		Expect(inserted.Description).To(Equal("Image managed by BOSH"))
+		Expect(inserted.Family).To(Equal("fake-family"))
+		Expect(inserted.Labels).To(Equal(map[string]string{"fake-key": "fake-value"}))
+	})
+})
+
+var _ = Describe("GoogleImageService family deprecation", func() {
+	var (
+		server         *clientfakes.FakeComputeServer
+		previousExists bool
+		deprecateFails bool
+		deprecated     compute.DeprecationStatus
+		imageService   GoogleImageService
+	)
+
+	BeforeEach(func() {
+		previousExists = true
+		deprecateFails = false
+		deprecated = compute.DeprecationStatus{}
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/global/images/family/fake-family", func(w http.ResponseWriter, r *http.Request) {
+			if !previousExists {
+				clientfakes.WriteError(w, http.StatusNotFound, "not found")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-previous-image", "status": "READY"}`)
+		})
+		server.Respond("POST", "/fake-project/global/images", `{"name": "fake-operation", "status": "DONE"}`)
+		server.Handle("POST", "/fake-project/global/images/fake-previous-image/deprecate", func(w http.ResponseWriter, r *http.Request) {
+			if deprecateFails {
+				clientfakes.WriteError(w, http.StatusNotFound, "not found")
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			Expect(json.Unmarshal(body, &deprecated)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-deprecate-operation", "status": "DONE"}`)
+		})
+
+		imageService = NewGoogleImageService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			"",
+			false,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	for _, state := range []string{"ACTIVE", "DEPRECATED", "OBSOLETE"} {
+		state := state
+
+		It("sets the previous image of the family "+state+" with the new image as its replacement", func() {
+			_, err := imageService.CreateFromURL("fake-source-url", "", "", Properties{Family: "fake-family", DeprecationState: state})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.Requests()).To(Equal([]string{
+				"GET /fake-project/global/images/family/fake-family",
+				"POST /fake-project/global/images",
+				"POST /fake-project/global/images/fake-previous-image/deprecate",
+			}))
+			Expect(deprecated.State).To(Equal(state))
+			Expect(deprecated.Replacement).To(Equal(server.URL() + "/fake-project/global/images/stemcell-fake-uuid"))
+		})
+	}
+
+	It("leaves the previous image alone without a deprecation state", func() {
+		_, err := imageService.CreateFromURL("fake-source-url", "", "", Properties{Family: "fake-family"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.Requests()).To(Equal([]string{"POST /fake-project/global/images"}))
+	})
+
+	It("does not deprecate anything for the first image of the family", func() {
+		previousExists = false
+
+		_, err := imageService.CreateFromURL("fake-source-url", "", "", Properties{Family: "fake-family", DeprecationState: "DEPRECATED"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.Requests()).To(Equal([]string{
+			"GET /fake-project/global/images/family/fake-family",
+			"POST /fake-project/global/images",
+		}))
+	})
+
+	It("creates the image even if the previous one fails to be deprecated", func() {
+		deprecateFails = true
+
+		id, err := imageService.CreateFromURL("fake-source-url", "", "", Properties{Family: "fake-family", DeprecationState: "DEPRECATED"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("stemcell-fake-uuid"))
+	})
+})
+
+var _ = Describe("Properties", func() {
+	It("accepts known guest OS features", func() {
+		Expect(Properties{GuestOsFeatures: []string{"UEFI_COMPATIBLE", "VIRTIO_SCSI_MULTIQUEUE", "GVNIC"}}.Validate()).To(Succeed())
+	})
+
+	It("rejects unknown guest OS features", func() {
+		err := Properties{GuestOsFeatures: []string{"uefi_compatible"}}.Validate()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Unknown guest OS feature 'uefi_compatible'"))
+	})
+
+	It("rejects empty licenses", func() {
+		Expect(Properties{Licenses: []string{""}}.Validate()).NotTo(Succeed())
+	})
+})
+
+var _ = Describe("GoogleImageService with an image project", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		polled       []string
+		imageService GoogleImageService
+	)
+
+	BeforeEach(func() {
+		polled = nil
+		server = clientfakes.NewFakeComputeServer()
+		server.Respond("POST", "/fake-image-project/global/images", `{"name": "fake-operation", "status": "RUNNING", "selfLink": "https://www.googleapis.com/compute/v1/projects/fake-image-project/global/operations/fake-operation"}`)
+		server.Handle("GET", "/fake-image-project/global/operations/fake-operation", func(w http.ResponseWriter, r *http.Request) {
+			polled = append(polled, r.URL.Path)
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+		computeService := server.ComputeService()
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		imageService = NewGoogleImageService(
+			"fake-image-project",
+			computeService,
+			nil,
+			operation.NewGoogleOperationService("fake-project", computeService, nil, logger, time.Millisecond, 0, false, operation.Timeouts{}),
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			logger,
+			"",
+			false,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("waits for the image operation in the image project", func() {
+		id, err := imageService.CreateFromURL("fake-source-url", "fake-source-sha1", "fake-description", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("stemcell-fake-uuid"))
+		Expect(polled).To(Equal([]string{"/fake-image-project/global/operations/fake-operation"}))
+	})
+})
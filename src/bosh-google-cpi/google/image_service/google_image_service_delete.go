@@ -2,9 +2,31 @@ package image
 
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"google.golang.org/api/googleapi"
 )
 
 func (i GoogleImageService) Delete(id string) error {
+	if err := i.deleteImage(id); err != nil {
+		return err
+	}
+
+	// Tarballs are deleted from the stemcell bucket once their image is
+	// created, remove the ones left behind by an interrupted create
+	if i.stemcellBucket != "" {
+		objectName := tarballObjectName(id)
+		i.logger.Debug(googleImageServiceLogTag, "Deleting Google Storage Object '%s' from Google Storage Bucket '%s'", objectName, i.stemcellBucket)
+		if err := i.storageService.Objects.Delete(i.stemcellBucket, objectName).Do(); err != nil {
+			if gerr, ok := err.(*googleapi.Error); !ok || gerr.Code != 404 {
+				return bosherr.WrapErrorf(err, "Deleting Google Storage Object '%s'", objectName)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (i GoogleImageService) deleteImage(id string) error {
 	image, found, err := i.Find(id)
 	if err != nil {
 		return err
@@ -3,6 +3,7 @@ package image
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
 )
 
@@ -13,14 +14,43 @@ func (i GoogleImageService) Find(id string) (Image, bool, error) {
 		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
 			return Image{}, false, nil
 		}
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 {
+			return Image{}, false, bosherr.WrapErrorf(err, "Service account has no access to Google Image '%s' in project '%s'", id, i.project)
+		}
 
 		return Image{}, false, bosherr.WrapErrorf(err, "Failed to find Google Image '%s'", id)
 	}
 
+	return newImage(imageItem), true, nil
+}
+
+// FindByFamily finds the latest non-deprecated image of an image family.
+func (i GoogleImageService) FindByFamily(family string) (Image, bool, error) {
+	i.logger.Debug(googleImageServiceLogTag, "Finding latest Google Image of family '%s'", family)
+	imageItem, err := i.computeService.Images.GetFromFamily(i.project, family).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			return Image{}, false, nil
+		}
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 {
+			return Image{}, false, bosherr.WrapErrorf(err, "Service account has no access to Google Image family '%s' in project '%s'", family, i.project)
+		}
+
+		return Image{}, false, bosherr.WrapErrorf(err, "Failed to find Google Image family '%s'", family)
+	}
+
+	return newImage(imageItem), true, nil
+}
+
+func newImage(imageItem *compute.Image) Image {
 	image := Image{
 		Name:     imageItem.Name,
 		SelfLink: imageItem.SelfLink,
 		Status:   imageItem.Status,
+		Labels:   imageItem.Labels,
+	}
+	for _, feature := range imageItem.GuestOsFeatures {
+		image.GuestOsFeatures = append(image.GuestOsFeatures, feature.Type)
 	}
-	return image, true, nil
+	return image
 }
@@ -0,0 +1,98 @@
+package image_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/image_service"
+)
+
+var _ = Describe("GoogleImageService", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		status       int
+		imageService GoogleImageService
+	)
+
+	BeforeEach(func() {
+		status = http.StatusOK
+		server = clientfakes.NewFakeComputeServer()
+		for _, imagePath := range []string{"/fake-image-project/global/images/fake-image", "/fake-image-project/global/images/family/fake-family"} {
+			server.Handle("GET", imagePath, func(w http.ResponseWriter, r *http.Request) {
+				if status != http.StatusOK {
+					clientfakes.WriteError(w, status, "fake-error")
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-image", "selfLink": "fake-image-self-link", "status": "READY"}`)
+			})
+		}
+
+		imageService = NewGoogleImageService(
+			"fake-image-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			"",
+			false,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Find", func() {
+		It("looks up the image in the image project", func() {
+			image, found, err := imageService.Find("fake-image")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(image.SelfLink).To(Equal("fake-image-self-link"))
+			Expect(server.Requests()).To(Equal([]string{"GET /fake-image-project/global/images/fake-image"}))
+		})
+
+		It("does not find missing images", func() {
+			status = http.StatusNotFound
+
+			_, found, err := imageService.Find("fake-image")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+
+		It("returns an error naming the project the service account cannot access", func() {
+			status = http.StatusForbidden
+
+			_, _, err := imageService.Find("fake-image")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Service account has no access to Google Image 'fake-image' in project 'fake-image-project'"))
+		})
+	})
+
+	Describe("FindByFamily", func() {
+		It("looks up the latest image of the family in the image project", func() {
+			image, found, err := imageService.FindByFamily("fake-family")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(image.SelfLink).To(Equal("fake-image-self-link"))
+			Expect(server.Requests()).To(Equal([]string{"GET /fake-image-project/global/images/family/fake-family"}))
+		})
+
+		It("does not find missing families", func() {
+			status = http.StatusNotFound
+
+			_, found, err := imageService.FindByFamily("fake-family")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeFalse())
+		})
+	})
+})
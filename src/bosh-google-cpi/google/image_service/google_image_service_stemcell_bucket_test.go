@@ -0,0 +1,173 @@
+package image_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/storage/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/image_service"
+)
+
+var _ = Describe("GoogleImageService with a stemcell bucket", func() {
+	const bucketPath = "/storage/b/fake-stemcells-fake-director"
+
+	var (
+		server       *clientfakes.FakeComputeServer
+		bucketExists bool
+		uploadBody   string
+		inserted     compute.Image
+		imagePath    string
+
+		computeService           *compute.Service
+		storageService           *storage.Service
+		uniformBucketLevelAccess bool
+		imageService             GoogleImageService
+	)
+
+	BeforeEach(func() {
+		bucketExists = true
+		uploadBody = ""
+		inserted = compute.Image{}
+		uniformBucketLevelAccess = false
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", bucketPath, func(w http.ResponseWriter, r *http.Request) {
+			if !bucketExists {
+				clientfakes.WriteError(w, http.StatusNotFound, "not found")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-stemcells-fake-director"}`)
+		})
+		server.Handle("POST", "/storage/b", func(w http.ResponseWriter, r *http.Request) {
+			bucketExists = true
+			fmt.Fprint(w, `{"name": "fake-stemcells-fake-director"}`)
+		})
+		server.Handle("POST", bucketPath+"/o", func(w http.ResponseWriter, r *http.Request) {
+			body, _ := ioutil.ReadAll(r.Body)
+			uploadBody = string(body)
+			fmt.Fprint(w, `{"name": "stemcell-fake-uuid.tar.gz", "mediaLink": "fake-media-link"}`)
+		})
+		server.Handle("POST", "/fake-project/global/images", func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewDecoder(r.Body).Decode(&inserted)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+		server.Respond("GET", "/fake-project/global/images/stemcell-fake-uuid", `{"name": "stemcell-fake-uuid", "status": "READY"}`)
+		server.Respond("DELETE", "/fake-project/global/images/stemcell-fake-uuid", `{"name": "fake-operation", "status": "DONE"}`)
+		server.Handle("DELETE", bucketPath+"/o/stemcell-fake-uuid.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		computeService = server.ComputeService()
+		storageService = server.StorageService()
+
+		imageFile, err := ioutil.TempFile("", "fake-image")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = imageFile.WriteString("fake-raw-disk-tarball")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(imageFile.Close()).To(Succeed())
+		imagePath = imageFile.Name()
+	})
+
+	JustBeforeEach(func() {
+		imageService = NewGoogleImageService(
+			"fake-project",
+			computeService,
+			storageService,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			"fake-stemcells-fake-director",
+			uniformBucketLevelAccess,
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Remove(imagePath)
+	})
+
+	It("uploads the tarball to the stemcell bucket and keeps the bucket", func() {
+		id, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(id).To(Equal("stemcell-fake-uuid"))
+		Expect(server.Requests()).To(Equal([]string{
+			"GET " + bucketPath,
+			"POST " + bucketPath + "/o",
+			"POST /fake-project/global/images",
+			"DELETE " + bucketPath + "/o/stemcell-fake-uuid.tar.gz",
+		}))
+		Expect(uploadBody).To(ContainSubstring(`"acl":[{"bucket":"fake-stemcells-fake-director","entity":"allUsers"`))
+	})
+
+	It("creates the image from the uploaded raw disk tarball", func() {
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploadBody).To(ContainSubstring("fake-raw-disk-tarball"))
+		Expect(inserted.RawDisk).NotTo(BeNil())
+		Expect(inserted.RawDisk.Source).To(Equal("fake-media-link"))
+	})
+
+	It("rejects the empty image file of a light stemcell before uploading it", func() {
+		Expect(ioutil.WriteFile(imagePath, nil, 0644)).To(Succeed())
+
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("is empty, google-light stemcells must set 'image_url' or 'source_url'"))
+		Expect(server.Requests()).To(BeEmpty())
+	})
+
+	Context("with uniform bucket-level access", func() {
+		BeforeEach(func() {
+			uniformBucketLevelAccess = true
+		})
+
+		It("uploads the tarball without object ACLs", func() {
+			_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uploadBody).To(ContainSubstring(`"name":"stemcell-fake-uuid.tar.gz"`))
+			Expect(uploadBody).NotTo(ContainSubstring(`"acl"`))
+			for _, request := range server.Requests() {
+				Expect(request).NotTo(ContainSubstring("/acl"))
+			}
+		})
+	})
+
+	It("uploads the tarball with its metadata", func() {
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{
+			TarballMetadata: map[string]string{TarballStemcellNameKey: "fake-stemcell-name"},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(uploadBody).To(ContainSubstring(`"metadata":{"bosh-stemcell-name":"fake-stemcell-name"}`))
+	})
+
+	It("creates the stemcell bucket the first time it is used", func() {
+		bucketExists = false
+
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(server.Requests()).To(ContainElement("POST /storage/b"))
+		Expect(server.Requests()).NotTo(ContainElement("DELETE " + bucketPath))
+	})
+
+	It("deletes the tarball left in the stemcell bucket along with the image", func() {
+		Expect(imageService.Delete("stemcell-fake-uuid")).To(Succeed())
+		Expect(server.Requests()).To(Equal([]string{
+			"GET /fake-project/global/images/stemcell-fake-uuid",
+			"DELETE /fake-project/global/images/stemcell-fake-uuid",
+			"DELETE " + bucketPath + "/o/stemcell-fake-uuid.tar.gz",
+		}))
+	})
+})
@@ -0,0 +1,43 @@
+package image
+
+import (
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	"google.golang.org/api/googleapi"
+)
+
+// Stemcell tarballs are uploaded in chunks of uploadChunkSize bytes. Tarballs
+// that fit in a single chunk are uploaded in one request and log no progress.
+var uploadChunkSize = googleapi.DefaultUploadChunkSize
+
+// uploadProgress logs the bytes of an upload committed so far, at most once
+// per interval, and once the last chunk is committed.
+type uploadProgress struct {
+	logger     boshlog.Logger
+	objectName string
+	size       int64
+	interval   time.Duration
+	lastLogged time.Time
+}
+
+func newUploadProgress(logger boshlog.Logger, objectName string, size int64, interval time.Duration) *uploadProgress {
+	return &uploadProgress{
+		logger:     logger,
+		objectName: objectName,
+		size:       size,
+		interval:   interval,
+		lastLogged: time.Now(),
+	}
+}
+
+// update is the progress updater of the upload. The total it is given is
+// unknown for uploads from a reader, so the size of the tarball is used.
+func (p *uploadProgress) update(current, _ int64) {
+	if current < p.size && time.Since(p.lastLogged) < p.interval {
+		return
+	}
+	p.lastLogged = time.Now()
+
+	p.logger.Info(googleImageServiceLogTag, "Uploaded %d of %d bytes (%d%%) of Google Storage Object '%s'", current, p.size, current*100/p.size, p.objectName)
+}
@@ -0,0 +1,118 @@
+package image
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"google.golang.org/api/googleapi"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GoogleImageService upload progress", func() {
+	var (
+		server    *clientfakes.FakeComputeServer
+		chunks    []string
+		logBuffer *bytes.Buffer
+		imagePath string
+
+		imageService GoogleImageService
+	)
+
+	BeforeEach(func() {
+		chunks = nil
+		uploadChunkSize = googleapi.MinUploadChunkSize
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("POST", "/storage/b/fake-stemcells/o", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Query().Get("uploadType") == "resumable" {
+				w.Header().Set("Location", server.URL()+"/fake-upload-session")
+				return
+			}
+			fmt.Fprint(w, `{"name": "stemcell-fake-uuid.tar.gz", "mediaLink": "fake-media-link"}`)
+		})
+		server.Handle("POST", "/fake-upload-session", func(w http.ResponseWriter, r *http.Request) {
+			ioutil.ReadAll(r.Body)
+			contentRange := r.Header.Get("Content-Range")
+			chunks = append(chunks, contentRange)
+			if strings.HasSuffix(contentRange, "/*") {
+				w.Header().Set("X-Http-Status-Code-Override", "308")
+				return
+			}
+			fmt.Fprint(w, `{"name": "stemcell-fake-uuid.tar.gz", "mediaLink": "fake-media-link"}`)
+		})
+		server.Respond("GET", "/storage/b/fake-stemcells", `{"name": "fake-stemcells"}`)
+		server.Respond("POST", "/fake-project/global/images", `{"name": "fake-operation", "status": "DONE"}`)
+		server.Handle("DELETE", "/storage/b/fake-stemcells/o/stemcell-fake-uuid.tar.gz", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})
+
+		logBuffer = &bytes.Buffer{}
+		imageService = NewGoogleImageService(
+			"fake-project",
+			server.ComputeService(),
+			server.StorageService(),
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewWriterLogger(boshlog.LevelInfo, logBuffer),
+			"fake-stemcells",
+			true,
+			0,
+		)
+
+		imageFile, err := ioutil.TempFile("", "fake-image")
+		Expect(err).NotTo(HaveOccurred())
+		imagePath = imageFile.Name()
+	})
+
+	AfterEach(func() {
+		server.Close()
+		os.Remove(imagePath)
+		uploadChunkSize = googleapi.DefaultUploadChunkSize
+	})
+
+	writeImage := func(size int) {
+		Expect(ioutil.WriteFile(imagePath, bytes.Repeat([]byte("x"), size), 0644)).To(Succeed())
+	}
+
+	It("logs the committed bytes of each chunk of a multi-chunk upload", func() {
+		writeImage(2*googleapi.MinUploadChunkSize + 1024)
+
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunks).To(HaveLen(3))
+		Expect(logBuffer.String()).To(ContainSubstring("Uploaded 262144 of 525312 bytes (49%) of Google Storage Object 'stemcell-fake-uuid.tar.gz'"))
+		Expect(logBuffer.String()).To(ContainSubstring("Uploaded 524288 of 525312 bytes (99%)"))
+		Expect(logBuffer.String()).To(ContainSubstring("Uploaded 525312 of 525312 bytes (100%)"))
+	})
+
+	It("logs at most once per interval until the upload completes", func() {
+		imageService.uploadProgressInterval = time.Hour
+		writeImage(2*googleapi.MinUploadChunkSize + 1024)
+
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunks).To(HaveLen(3))
+		Expect(strings.Count(logBuffer.String(), "Uploaded ")).To(Equal(1))
+		Expect(logBuffer.String()).To(ContainSubstring("Uploaded 525312 of 525312 bytes (100%)"))
+	})
+
+	It("stays quiet for an upload that fits in a single chunk", func() {
+		writeImage(1024)
+
+		_, err := imageService.CreateFromTarball(imagePath, "", Properties{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(chunks).To(BeEmpty())
+		Expect(logBuffer.String()).NotTo(ContainSubstring("Uploaded "))
+	})
+})
@@ -1,7 +1,93 @@
 package image
 
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
 type Image struct {
-	Name     string
-	SelfLink string
-	Status   string
+	Name            string
+	SelfLink        string
+	Status          string
+	GuestOsFeatures []string
+	Labels          map[string]string
+}
+
+// GPUDriverLabelKey labels the images of stemcells that ship the GPU driver.
+const GPUDriverLabelKey = "bosh-gpu-driver"
+
+// Metadata keys of uploaded stemcell tarballs, recording where they come
+// from.
+const (
+	TarballStemcellNameKey    = "bosh-stemcell-name"
+	TarballStemcellVersionKey = "bosh-stemcell-version"
+	TarballDirectorUUIDKey    = "bosh-director-uuid"
+)
+
+// Guest OS features that can be enabled on images.
+const (
+	GuestOsFeatureGVNIC                = "GVNIC"
+	GuestOsFeatureMultiIPSubnet        = "MULTI_IP_SUBNET"
+	GuestOsFeatureSecureBoot           = "SECURE_BOOT"
+	GuestOsFeatureSEVCapable           = "SEV_CAPABLE"
+	GuestOsFeatureUEFICompatible       = "UEFI_COMPATIBLE"
+	GuestOsFeatureVirtioSCSIMultiqueue = "VIRTIO_SCSI_MULTIQUEUE"
+	GuestOsFeatureWindows              = "WINDOWS"
+)
+
+var knownGuestOsFeatures = map[string]bool{
+	GuestOsFeatureGVNIC:                true,
+	GuestOsFeatureMultiIPSubnet:        true,
+	GuestOsFeatureSecureBoot:           true,
+	GuestOsFeatureSEVCapable:           true,
+	GuestOsFeatureUEFICompatible:       true,
+	GuestOsFeatureVirtioSCSIMultiqueue: true,
+	GuestOsFeatureWindows:              true,
+}
+
+// Properties are the optional properties of a created image.
+type Properties struct {
+	GuestOsFeatures []string
+	Licenses        []string
+	Family          string
+	Labels          map[string]string
+
+	// DeprecationState is set on the previous latest image of Family once
+	// the image is created, with the image as its replacement. The previous
+	// image is left alone when it is empty.
+	DeprecationState string
+
+	// TarballMetadata is the custom metadata of the stemcell tarball object
+	// uploaded to create the image from a tarball.
+	TarballMetadata map[string]string
+}
+
+func (p Properties) Validate() error {
+	for _, feature := range p.GuestOsFeatures {
+		if !knownGuestOsFeatures[feature] {
+			return bosherr.Errorf("Unknown guest OS feature '%s'", feature)
+		}
+	}
+
+	for _, license := range p.Licenses {
+		if license == "" {
+			return bosherr.Error("Licenses must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// GPUReady reports whether the image was labelled as shipping the GPU driver.
+func (i Image) GPUReady() bool {
+	return i.Labels[GPUDriverLabelKey] == "true"
+}
+
+// HasGuestOsFeature reports whether the image was created with feature.
+func (i Image) HasGuestOsFeature(feature string) bool {
+	for _, f := range i.GuestOsFeatures {
+		if f == feature {
+			return true
+		}
+	}
+	return false
 }
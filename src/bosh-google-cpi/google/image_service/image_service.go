@@ -1,8 +1,10 @@
 package image
 
 type Service interface {
-	CreateFromURL(sourceURL string, sourceSha1 string, description string) (string, error)
-	CreateFromTarball(imagePath string, description string) (string, error)
+	CreateFromURL(sourceURL string, sourceSha1 string, description string, props Properties) (string, error)
+	CreateFromTarball(imagePath string, description string, props Properties) (string, error)
+	CreateFromDisk(diskLink string, description string, props Properties) (string, error)
 	Delete(id string) error
 	Find(id string) (Image, bool, error)
+	FindByFamily(family string) (Image, bool, error)
 }
@@ -1,6 +1,8 @@
 package fakes
 
 import (
+	"time"
+
 	"bosh-google-cpi/google/instance_service"
 	"google.golang.org/api/compute/v1"
 )
@@ -13,6 +15,8 @@ type FakeInstanceService struct {
 	AttachDiskErr        error
 	AttachDiskDeviceName string
 	AttachDiskDevicePath string
+	AttachDiskMode       string
+	AttachDiskName       string
 
 	AttachedDisksCalled bool
 	AttachedDisksErr    error
@@ -22,34 +26,69 @@ type FakeInstanceService struct {
 
 	CreateCalled           bool
 	CreateErr              error
+	CreateErrs             []error
 	CreateID               string
 	CreateVMProps          *instance.Properties
 	CreateNetworks         instance.Networks
 	CreateRegistryEndpoint string
+	CreateZones            []string
 
-	DeleteCalled bool
-	DeleteErr    error
+	DeleteCalled        bool
+	DeleteErr           error
+	DeleteDetachedDisks []string
 
 	DeleteAccessConfigCalled bool
 	DeleteAccessConfigErr    error
 
 	DetachDiskCalled bool
 	DetachDiskErr    error
+	DetachDiskIDs    []string
+
+	FindCalled    bool
+	FindFound     bool
+	FindInstance  *compute.Instance
+	FindErr       error
+	FindInstances map[string]*compute.Instance
+
+	FindByTagCalled bool
+	FindByTagTag    string
+	FindByTagNames  []string
+	FindByTagErr    error
 
-	FindCalled   bool
-	FindFound    bool
-	FindInstance *compute.Instance
-	FindErr      error
+	FindByLabelsCalled    bool
+	FindByLabelsLabels    instance.Labels
+	FindByLabelsInstances []*compute.Instance
+	FindByLabelsErr       error
 
 	RebootCalled bool
 	RebootErr    error
 
+	RebootMethodCalled bool
+	RebootMethodName   string
+	RebootMethodErr    error
+
+	WaitForAttachedDiskCalled     bool
+	WaitForAttachedDiskErr        error
+	WaitForAttachedDiskDevicePath string
+	WaitForAttachedDiskTimeout    time.Duration
+
+	WaitForRunningCalled  bool
+	WaitForRunningErr     error
+	WaitForRunningTimeout time.Duration
+
 	SetMetadataCalled     bool
 	SetMetadataErr        error
 	SetMetadataVMMetadata instance.Metadata
 
 	SetTagsCalled bool
 	SetTagsErr    error
+	SetTagsTags   *compute.Tags
+
+	StartCalled bool
+	StartErr    error
+
+	StopCalled bool
+	StopErr    error
 
 	UpdateNetworkConfigurationCalled bool
 	UpdateNetworkConfigurationErr    error
@@ -60,8 +99,10 @@ func (i *FakeInstanceService) AddAccessConfig(id string, zone string, networkInt
 	return i.AddAccessConfigErr
 }
 
-func (i *FakeInstanceService) AttachDisk(id string, diskLink string) (string, string, error) {
+func (i *FakeInstanceService) AttachDisk(id string, diskLink string, mode string, deviceName string) (string, string, error) {
 	i.AttachDiskCalled = true
+	i.AttachDiskMode = mode
+	i.AttachDiskName = deviceName
 	return i.AttachDiskDeviceName, i.AttachDiskDevicePath, i.AttachDiskErr
 }
 
@@ -80,11 +121,18 @@ func (i *FakeInstanceService) Create(vmProps *instance.Properties, networks inst
 	i.CreateVMProps = vmProps
 	i.CreateNetworks = networks
 	i.CreateRegistryEndpoint = registryEndpoint
+	i.CreateZones = append(i.CreateZones, vmProps.Zone)
+	if len(i.CreateErrs) > 0 {
+		err := i.CreateErrs[0]
+		i.CreateErrs = i.CreateErrs[1:]
+		return i.CreateID, err
+	}
 	return i.CreateID, i.CreateErr
 }
 
 func (i *FakeInstanceService) Delete(id string) error {
 	i.DeleteCalled = true
+	i.DeleteDetachedDisks = append([]string(nil), i.DetachDiskIDs...)
 	return i.DeleteErr
 }
 
@@ -95,19 +143,52 @@ func (i *FakeInstanceService) DeleteAccessConfig(id string, zone string, network
 
 func (i *FakeInstanceService) DetachDisk(id string, diskID string) error {
 	i.DetachDiskCalled = true
+	i.DetachDiskIDs = append(i.DetachDiskIDs, diskID)
 	return i.DetachDiskErr
 }
 
 func (i *FakeInstanceService) Find(id string, zone string) (*compute.Instance, bool, error) {
 	i.FindCalled = true
+	if foundInstance, ok := i.FindInstances[id]; ok {
+		return foundInstance, true, i.FindErr
+	}
 	return i.FindInstance, i.FindFound, i.FindErr
 }
 
+func (i *FakeInstanceService) FindByTag(tag string) ([]string, error) {
+	i.FindByTagCalled = true
+	i.FindByTagTag = tag
+	return i.FindByTagNames, i.FindByTagErr
+}
+
+func (i *FakeInstanceService) FindByLabels(labels instance.Labels) ([]*compute.Instance, error) {
+	i.FindByLabelsCalled = true
+	i.FindByLabelsLabels = labels
+	return i.FindByLabelsInstances, i.FindByLabelsErr
+}
+
 func (i *FakeInstanceService) Reboot(id string) error {
 	i.RebootCalled = true
 	return i.RebootErr
 }
 
+func (i *FakeInstanceService) RebootMethod(id string) (string, error) {
+	i.RebootMethodCalled = true
+	return i.RebootMethodName, i.RebootMethodErr
+}
+
+func (i *FakeInstanceService) WaitForAttachedDisk(id string, diskLink string, deviceName string, timeout time.Duration) (string, error) {
+	i.WaitForAttachedDiskCalled = true
+	i.WaitForAttachedDiskTimeout = timeout
+	return i.WaitForAttachedDiskDevicePath, i.WaitForAttachedDiskErr
+}
+
+func (i *FakeInstanceService) WaitForRunning(id string, timeout time.Duration) error {
+	i.WaitForRunningCalled = true
+	i.WaitForRunningTimeout = timeout
+	return i.WaitForRunningErr
+}
+
 func (i *FakeInstanceService) SetMetadata(id string, vmMetadata instance.Metadata) error {
 	i.SetMetadataCalled = true
 	i.SetMetadataVMMetadata = vmMetadata
@@ -116,9 +197,20 @@ func (i *FakeInstanceService) SetMetadata(id string, vmMetadata instance.Metadat
 
 func (i *FakeInstanceService) SetTags(id string, zone string, instanceTags *compute.Tags) error {
 	i.SetTagsCalled = true
+	i.SetTagsTags = instanceTags
 	return i.SetTagsErr
 }
 
+func (i *FakeInstanceService) Start(id string) error {
+	i.StartCalled = true
+	return i.StartErr
+}
+
+func (i *FakeInstanceService) Stop(id string) error {
+	i.StopCalled = true
+	return i.StopErr
+}
+
 func (i *FakeInstanceService) UpdateNetworkConfiguration(id string, networks instance.Networks) error {
 	i.UpdateNetworkConfigurationCalled = true
 	return i.UpdateNetworkConfigurationErr
@@ -1,11 +1,15 @@
 package instance
 
 import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	"bosh-google-cpi/google/address_service"
 	"bosh-google-cpi/google/backendservice_service"
+	"bosh-google-cpi/google/config"
 	"bosh-google-cpi/google/network_service"
 	"bosh-google-cpi/google/operation_service"
 	"bosh-google-cpi/google/subnetwork_service"
@@ -30,6 +34,12 @@ type GoogleInstanceService struct {
 	targetPoolService     targetpool.Service
 	uuidGen               boshuuid.Generator
 	logger                boshlog.Logger
+	dryRun                bool
+	useBetaAPI            bool
+	metadataKeyPrefix     string
+	apiVersion            string
+
+	gracefulShutdownTimeout time.Duration
 }
 
 func NewGoogleInstanceService(
@@ -44,6 +54,11 @@ func NewGoogleInstanceService(
 	targetPoolService targetpool.Service,
 	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
+	dryRun bool,
+	gracefulShutdownTimeout time.Duration,
+	useBetaAPI bool,
+	metadataKeyPrefix string,
+	apiVersion string,
 ) GoogleInstanceService {
 	return GoogleInstanceService{
 		project:               project,
@@ -57,7 +72,34 @@ func NewGoogleInstanceService(
 		targetPoolService:     targetPoolService,
 		uuidGen:               uuidGen,
 		logger:                logger,
+		dryRun:                dryRun,
+		useBetaAPI:            useBetaAPI,
+		metadataKeyPrefix:     metadataKeyPrefix,
+		apiVersion:            apiVersion,
+
+		gracefulShutdownTimeout: gracefulShutdownTimeout,
+	}
+}
+
+// requireBetaAPI fails when a feature only the beta API supports is used
+// while the beta API is not enabled.
+func (i GoogleInstanceService) requireBetaAPI(feature string) error {
+	if i.useBetaAPI {
+		return nil
+	}
+	return bosherr.Errorf("%s requires the beta API, enable 'use_beta_api' to use it", feature)
+}
+
+// requireBetaInsert fails when a feature only the beta API supports is used
+// while instances can not be inserted through the beta API.
+func (i GoogleInstanceService) requireBetaInsert(feature string) error {
+	if err := i.requireBetaAPI(feature); err != nil {
+		return err
+	}
+	if i.apiVersion == config.APIVersionV1 {
+		return bosherr.Errorf("%s requires the beta API, but instances are pinned to the '%s' API", feature, config.APIVersionV1)
 	}
+	return nil
 }
 
 type GoogleUserData struct {
@@ -2,6 +2,7 @@ package instance
 
 import (
 	"fmt"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
@@ -11,10 +12,26 @@ import (
 )
 
 const googleDiskPathPrefix = "/dev/sd"
+
+// Disks are linked as /dev/disk/by-id/google-<device name> in the guest.
+const googleDiskByIDPrefix = "/dev/disk/by-id/google-"
+
 const googleDiskPathSuffix = "abcdefghijklmnopqrstuvwxyz"
+const attachedDiskPollInterval = time.Second
+
+// AttachDisk attaches the disk with deviceName, which defaults to the disk
+// name, and returns the device name and path.
+func (i GoogleInstanceService) AttachDisk(id string, diskLink string, mode string, deviceName string) (string, string, error) {
+	var devicePath string
 
-func (i GoogleInstanceService) AttachDisk(id string, diskLink string) (string, string, error) {
-	var deviceName, devicePath string
+	if deviceName == "" {
+		deviceName = util.ResourceSplitter(diskLink)
+	}
+
+	if i.dryRun {
+		i.logger.Warn(googleInstanceServiceLogTag, "Dry run, not attaching Google Disk '%s' to Google Instance '%s'", util.ResourceSplitter(diskLink), id)
+		return deviceName, devicePath, nil
+	}
 
 	// Find the instance
 	instance, found, err := i.Find(id, "")
@@ -25,16 +42,22 @@ func (i GoogleInstanceService) AttachDisk(id string, diskLink string) (string, s
 		return deviceName, devicePath, api.NewVMNotFoundError(id)
 	}
 
-	deviceName = util.ResourceSplitter(diskLink)
+	// Device names must be unique on the instance
+	for _, attachedDisk := range instance.Disks {
+		if attachedDisk.DeviceName == deviceName {
+			return deviceName, devicePath, bosherr.Errorf("Device name '%s' is already used by Google Disk '%s' of Google Instance '%s'", deviceName, util.ResourceSplitter(attachedDisk.Source), id)
+		}
+	}
+
 	disk := &compute.AttachedDisk{
 		DeviceName: deviceName,
-		Mode:       "READ_WRITE",
+		Mode:       mode,
 		Source:     diskLink,
 		Type:       "PERSISTENT",
 	}
 
 	// Attach the disk
-	i.logger.Debug(googleInstanceServiceLogTag, "Attaching Google Disk '%s' to Google Instance '%s'", util.ResourceSplitter(diskLink), id)
+	i.logger.Debug(googleInstanceServiceLogTag, "Attaching Google Disk '%s' to Google Instance '%s' in mode '%s'", util.ResourceSplitter(diskLink), id, mode)
 	operation, err := i.computeService.Instances.AttachDisk(i.project, util.ResourceSplitter(instance.Zone), id, disk).Do()
 	if err != nil {
 		return deviceName, devicePath, bosherr.WrapErrorf(err, "Failed to attach Google Disk '%s' to Google Instance '%s'", util.ResourceSplitter(diskLink), id)
@@ -53,13 +76,48 @@ func (i GoogleInstanceService) AttachDisk(id string, diskLink string) (string, s
 		return deviceName, devicePath, api.NewVMNotFoundError(id)
 	}
 
-	// Look up for the device index
+	devicePath, _ = attachedDevicePath(instance, diskLink, deviceName)
+	return deviceName, devicePath, nil
+}
+
+// WaitForAttachedDisk polls the instance until the disk shows in its disks
+// with deviceName, for up to timeout, and returns the device path.
+func (i GoogleInstanceService) WaitForAttachedDisk(id string, diskLink string, deviceName string, timeout time.Duration) (string, error) {
+	if i.dryRun {
+		return "", nil
+	}
+
+	i.logger.Debug(googleInstanceServiceLogTag, "Waiting up to %v for Google Disk '%s' to be attached to Google Instance '%s' as device '%s'", timeout, util.ResourceSplitter(diskLink), id, deviceName)
+	deadline := time.Now().Add(timeout)
+	for {
+		instance, found, err := i.Find(id, "")
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", api.NewVMNotFoundError(id)
+		}
+		if devicePath, attached := attachedDevicePath(instance, diskLink, deviceName); attached {
+			return devicePath, nil
+		}
+
+		if time.Now().Add(attachedDiskPollInterval).After(deadline) {
+			return "", bosherr.Errorf("Timed out after %v waiting for Google Disk '%s' to be attached to Google Instance '%s' as device '%s'", timeout, util.ResourceSplitter(diskLink), id, deviceName)
+		}
+		time.Sleep(attachedDiskPollInterval)
+	}
+}
+
+// attachedDevicePath looks up the device index of the disk in the instance
+// disks, and reports whether it is attached with deviceName.
+func attachedDevicePath(instance *compute.Instance, diskLink string, deviceName string) (string, bool) {
 	for _, attachedDisk := range instance.Disks {
 		if attachedDisk.Source == diskLink {
 			deviceIndex := int(attachedDisk.Index)
-			devicePath = fmt.Sprintf("%s%s", googleDiskPathPrefix, string(googleDiskPathSuffix[deviceIndex]))
+			devicePath := fmt.Sprintf("%s%s", googleDiskPathPrefix, string(googleDiskPathSuffix[deviceIndex]))
+			return devicePath, attachedDisk.DeviceName == deviceName
 		}
 	}
 
-	return deviceName, devicePath, nil
+	return "", false
 }
@@ -0,0 +1,126 @@
+package instance_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService AttachDisk", func() {
+	const diskLink = "https://www.googleapis.com/compute/v1/projects/fake-project/zones/us-central1-a/disks/fake-disk"
+
+	var (
+		server      *clientfakes.FakeComputeServer
+		attached    *compute.AttachedDisk
+		hiddenReads int
+
+		vmService GoogleInstanceService
+	)
+
+	BeforeEach(func() {
+		attached = nil
+		hiddenReads = 0
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			disks := `{"deviceName": "fake-boot-disk", "source": "fake-boot-disk-self-link", "index": 0}`
+			if attached != nil && hiddenReads > 0 {
+				hiddenReads--
+			} else if attached != nil {
+				disks += fmt.Sprintf(`, {"deviceName": "%s", "source": "%s", "index": 1}`, attached.DeviceName, attached.Source)
+			}
+			fmt.Fprintf(w, `{"items": {"zones/us-central1-a": {"instances": [{"name": "fake-instance", "zone": "us-central1-a", "disks": [%s]}]}}}`, disks)
+		})
+		server.Handle("POST", "/fake-project/zones/us-central1-a/instances/fake-instance/attachDisk", func(w http.ResponseWriter, r *http.Request) {
+			attached = &compute.AttachedDisk{}
+			Expect(json.NewDecoder(r.Body).Decode(attached)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			nil,
+			nil,
+			&operationfakes.FakeOperationService{},
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			0,
+			false,
+			"",
+			"",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("names the device after the disk by default", func() {
+		deviceName, devicePath, err := vmService.AttachDisk("fake-instance", diskLink, "READ_WRITE", "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deviceName).To(Equal("fake-disk"))
+		Expect(devicePath).To(Equal("/dev/sdb"))
+		Expect(attached.DeviceName).To(Equal("fake-disk"))
+	})
+
+	It("attaches the disk with the custom device name", func() {
+		deviceName, _, err := vmService.AttachDisk("fake-instance", diskLink, "READ_WRITE", "fake-device-name")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deviceName).To(Equal("fake-device-name"))
+		Expect(attached.DeviceName).To(Equal("fake-device-name"))
+	})
+
+	It("returns an error if the device name is used by another disk of the instance", func() {
+		_, _, err := vmService.AttachDisk("fake-instance", diskLink, "READ_WRITE", "fake-boot-disk")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Device name 'fake-boot-disk' is already used by Google Disk 'fake-boot-disk-self-link'"))
+		Expect(attached).To(BeNil())
+	})
+
+	Describe("WaitForAttachedDisk", func() {
+		BeforeEach(func() {
+			attached = &compute.AttachedDisk{DeviceName: "fake-disk", Source: diskLink}
+		})
+
+		It("returns the device path once the disk shows with its device name", func() {
+			hiddenReads = 1
+
+			devicePath, err := vmService.WaitForAttachedDisk("fake-instance", diskLink, "fake-disk", 5*time.Second)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(devicePath).To(Equal("/dev/sdb"))
+			Expect(hiddenReads).To(BeZero())
+		})
+
+		It("returns an error if the disk shows with another device name", func() {
+			_, err := vmService.WaitForAttachedDisk("fake-instance", diskLink, "fake-device-name", 0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out after 0s waiting for Google Disk 'fake-disk' to be attached to Google Instance 'fake-instance' as device 'fake-device-name'"))
+		})
+
+		It("returns an error if the disk does not show within the timeout", func() {
+			hiddenReads = 10
+
+			_, err := vmService.WaitForAttachedDisk("fake-instance", diskLink, "fake-disk", 0)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out after 0s"))
+		})
+	})
+})
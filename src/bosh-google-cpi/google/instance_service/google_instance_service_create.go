@@ -3,11 +3,14 @@ package instance
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_template_service"
 	subnet "bosh-google-cpi/google/subnetwork_service"
 	"bosh-google-cpi/util"
 
@@ -15,6 +18,20 @@ import (
 )
 
 const defaultRootDiskSizeGb = 10
+
+// EphemeralDiskDeviceName is the device name of the ephemeral disk.
+const EphemeralDiskDeviceName = "ephemeral-disk"
+
+// EphemeralDiskDevicePath is the stable path of the ephemeral disk. The guest
+// links each disk by its device name, whatever the order of the devices.
+const EphemeralDiskDevicePath = googleDiskByIDPrefix + EphemeralDiskDeviceName
+
+// Interfaces disks can be attached with. Persistent disks, such as the boot
+// disk, must use SCSI, NVME is only supported by local SSDs.
+const (
+	DiskInterfaceSCSI = "SCSI"
+	DiskInterfaceNVME = "NVME"
+)
 const userDataKey = "user_data"
 
 // The zones in this map are known to default to Sandy Bridge CPUs, which do
@@ -38,12 +55,24 @@ func (i GoogleInstanceService) Create(vmProps *Properties, networks Networks, re
 		instanceName = fmt.Sprintf("%s-%s", googleInstanceNamePrefix, uuidStr)
 	}
 	canIPForward := networks.CanIPForward()
-	diskParams := i.createDiskParams(vmProps.Stemcell, vmProps.RootDiskSizeGb, vmProps.RootDiskType)
-	metadataParams, err := i.createMatadataParams(instanceName, registryEndpoint, networks)
+	diskParams := i.createDiskParams(vmProps.Stemcell, vmProps.RootDiskSizeGb, vmProps.RootDiskType, vmProps.RootDeviceName, vmProps.RootDiskInterface, vmProps.EphemeralDisk)
+	metadataParams, err := i.createMatadataParams(instanceName, registryEndpoint, networks, vmProps)
+	if err != nil {
+		return "", err
+	}
+	subnetwork, err := i.findSubnetwork(networks, vmProps.Zone)
 	if err != nil {
 		return "", err
 	}
-	networkInterfacesParams, err := i.createNetworkInterfacesParams(networks, vmProps.Zone)
+	reserveIP, err := i.validateReservedIP(networks, subnetwork)
+	if err != nil {
+		return "", err
+	}
+	internalAddressIP, err := i.findNamedInternalAddress(networks, subnetwork, vmProps.Zone)
+	if err != nil {
+		return "", err
+	}
+	networkInterfacesParams, err := i.createNetworkInterfacesParams(networks, subnetwork, internalAddressIP)
 	if err != nil {
 		return "", err
 	}
@@ -57,9 +86,14 @@ func (i GoogleInstanceService) Create(vmProps *Properties, networks Networks, re
 
 	acceleratorParams := i.createAcceleratorParams(vmProps.Accelerators)
 
+	description := vmProps.Description
+	if description == "" {
+		description = googleInstanceDescription
+	}
+
 	vm := &compute.Instance{
 		Name:              instanceName,
-		Description:       googleInstanceDescription,
+		Description:       description,
 		CanIpForward:      canIPForward,
 		Disks:             diskParams,
 		MachineType:       vmProps.MachineType,
@@ -74,17 +108,55 @@ func (i GoogleInstanceService) Create(vmProps *Properties, networks Networks, re
 		MinCpuPlatform: minCpuPlatform[vmProps.Zone],
 	}
 
+	if template := vmProps.SourceInstanceTemplate; template != nil {
+		if err := i.applyInstanceTemplate(vm, vmProps, template); err != nil {
+			return "", err
+		}
+	}
+
+	if networks.NetworkTier() != "" && hasAccessConfigs(vm) {
+		if err := i.requireBetaInsert("Network tier"); err != nil {
+			return "", err
+		}
+	}
+
+	if hasRootDiskKmsKeys(vmProps) {
+		if err := i.requireBetaInsert("Encrypting the root disk with KMS keys"); err != nil {
+			return "", err
+		}
+	}
+
+	if i.dryRun {
+		if err := i.validateLoadBalancers(vmProps); err != nil {
+			return "", api.NewVMCreationFailedError(err.Error(), false)
+		}
+		i.logger.Info(googleInstanceServiceLogTag, "Dry run, not creating Google Instance with params: %v", vm)
+		return vm.Name, nil
+	}
+
+	if reserveIP {
+		if err := i.reserveIP(vm.Name, vmProps.Zone, subnetwork.SelfLink, networks.ReservedIP()); err != nil {
+			return "", api.NewVMCreationFailedError(err.Error(), true)
+		}
+	}
+
 	i.logger.Debug(googleInstanceServiceLogTag, "Creating Google Instance with params: %v", vm)
-	operation, err := i.computeService.Instances.Insert(i.project, util.ResourceSplitter(vmProps.Zone), vm).Do()
+	operation, err := i.insert(vm, vmProps, networks.NetworkTier())
 	if err != nil {
 		i.logger.Debug(googleInstanceServiceLogTag, "Failed to create Google Instance: %v", err)
-		return "", api.NewVMCreationFailedError(err.Error(), true)
+		if _, err := i.releaseReservedIP(vm.Name, vmProps.Zone); err != nil {
+			i.logger.Debug(googleInstanceServiceLogTag, "Failed releasing the reserved IP of Google Instance '%s': %v", vm.Name, err)
+		}
+		return "", newCreationFailedError(err)
 	}
 
 	if operation, err = i.operationService.Waiter(operation, vmProps.Zone, ""); err != nil {
 		i.logger.Debug(googleInstanceServiceLogTag, "Failed to create Google Instance: %v", err)
 		i.CleanUp(vm.Name)
-		return "", api.NewVMCreationFailedError(err.Error(), true)
+		if _, err := i.releaseReservedIP(vm.Name, vmProps.Zone); err != nil {
+			i.logger.Debug(googleInstanceServiceLogTag, "Failed releasing the reserved IP of Google Instance '%s': %v", vm.Name, err)
+		}
+		return "", newCreationFailedError(err)
 	}
 
 	if vmProps.TargetPool != "" {
@@ -113,15 +185,63 @@ func (i GoogleInstanceService) CleanUp(id string) {
 
 }
 
-func (i GoogleInstanceService) createDiskParams(stemcell string, diskSize int, diskType string) []*compute.AttachedDisk {
+// applyInstanceTemplate merges the template metadata, tags and labels with
+// the ones set by the CPI, which take precedence, and clears the properties
+// the CPI only sets to defaults so the template values are used instead.
+// Disks and network interfaces always come from the CPI.
+func (i GoogleInstanceService) applyInstanceTemplate(vm *compute.Instance, vmProps *Properties, template *instancetemplate.InstanceTemplate) error {
+	metadata := Metadata{}
+	for key, value := range template.Metadata {
+		metadata[key] = value
+	}
+	for _, item := range vm.Metadata.Items {
+		if item.Value != nil {
+			metadata[item.Key] = *item.Value
+		}
+	}
+	if err := metadata.ValidateSize(); err != nil {
+		return err
+	}
+	var metadataItems []*compute.MetadataItems
+	for _, key := range metadata.sortedKeys() {
+		value := metadata[key]
+		metadataItems = append(metadataItems, &compute.MetadataItems{Key: key, Value: &value})
+	}
+	vm.Metadata = &compute.Metadata{Items: metadataItems}
+
+	tags := append(Tags(template.Tags), vm.Tags.Items...)
+	vm.Tags = &compute.Tags{Items: tags.Unique()}
+
+	if len(template.Labels) > 0 {
+		labels := map[string]string{}
+		for key, value := range template.Labels {
+			labels[key] = value
+		}
+		for key, value := range vm.Labels {
+			labels[key] = value
+		}
+		vm.Labels = labels
+	}
+
+	if !vmProps.Preemptible && !vmProps.AutomaticRestart && vmProps.OnHostMaintenance == "" {
+		vm.Scheduling = nil
+	}
+	vm.MinCpuPlatform = ""
+
+	return nil
+}
+
+func (i GoogleInstanceService) createDiskParams(stemcell string, diskSize int, diskType string, deviceName string, diskInterface string, ephemeralDisk *EphemeralDisk) []*compute.AttachedDisk {
 	var disks []*compute.AttachedDisk
 
 	if diskSize == 0 {
 		diskSize = defaultRootDiskSizeGb
 	}
-	disk := &compute.AttachedDisk{
+	bootDisk := &compute.AttachedDisk{
 		AutoDelete: true,
 		Boot:       true,
+		DeviceName: deviceName,
+		Interface:  diskInterface,
 		InitializeParams: &compute.AttachedDiskInitializeParams{
 			DiskSizeGb:  int64(diskSize),
 			DiskType:    diskType,
@@ -130,7 +250,22 @@ func (i GoogleInstanceService) createDiskParams(stemcell string, diskSize int, d
 		Mode: "READ_WRITE",
 		Type: "PERSISTENT",
 	}
-	disks = append(disks, disk)
+	disks = append(disks, bootDisk)
+
+	// The ephemeral disk is labelled so it is deleted along with the VM
+	if ephemeralDisk != nil {
+		disks = append(disks, &compute.AttachedDisk{
+			AutoDelete: true,
+			DeviceName: EphemeralDiskDeviceName,
+			InitializeParams: &compute.AttachedDiskInitializeParams{
+				DiskSizeGb: int64(ephemeralDisk.SizeGb),
+				DiskType:   ephemeralDisk.DiskType,
+				Labels:     map[string]string{disk.EphemeralLabelKey: "true"},
+			},
+			Mode: "READ_WRITE",
+			Type: "PERSISTENT",
+		})
+	}
 
 	return disks
 }
@@ -149,7 +284,7 @@ func (i GoogleInstanceService) createAcceleratorParams(accelerators []Accelerato
 	return accs
 }
 
-func (i GoogleInstanceService) createMatadataParams(name string, regEndpoint string, networks Networks) (*compute.Metadata, error) {
+func (i GoogleInstanceService) createMatadataParams(name string, regEndpoint string, networks Networks, vmProps *Properties) (*compute.Metadata, error) {
 	serverName := GoogleUserDataServerName{Name: name}
 	registryEndpoint := GoogleUserDataRegistryEndpoint{Endpoint: regEndpoint}
 	userData := GoogleUserData{Server: serverName, Registry: registryEndpoint}
@@ -163,16 +298,72 @@ func (i GoogleInstanceService) createMatadataParams(name string, regEndpoint str
 		return nil, bosherr.WrapErrorf(err, "Marshalling user data")
 	}
 
+	metadata := vmProps.StartupScript.Metadata()
+	metadata[userDataKey] = string(ud)
+	if vmProps.EnableGuestAttributes {
+		metadata[guestAttributesKey] = "TRUE"
+	}
+	if vmProps.EnableOSLogin != nil {
+		metadata[osLoginKey] = strings.ToUpper(strconv.FormatBool(*vmProps.EnableOSLogin))
+	}
+	if len(vmProps.SSHKeys) > 0 {
+		metadata[sshKeysKey] = strings.Join(vmProps.SSHKeys, "\n")
+	}
+	if vmProps.RebootMethod != "" {
+		metadata[rebootMethodKey] = vmProps.RebootMethod
+	}
+	if err := metadata.ValidateSize(); err != nil {
+		return nil, err
+	}
+
 	var metadataItems []*compute.MetadataItems
-	userDataValue := string(ud)
-	metadataItem := &compute.MetadataItems{Key: userDataKey, Value: &userDataValue}
-	metadataItems = append(metadataItems, metadataItem)
-	metadata := &compute.Metadata{Items: metadataItems}
+	for _, key := range metadata.sortedKeys() {
+		value := metadata[key]
+		metadataItems = append(metadataItems, &compute.MetadataItems{Key: key, Value: &value})
+	}
+
+	return &compute.Metadata{Items: metadataItems}, nil
+}
+
+func (i GoogleInstanceService) findSubnetwork(networks Networks, zone string) (subnet.Subnetwork, error) {
+	if networks.SubnetworkName() == "" {
+		return subnet.Subnetwork{}, nil
+	}
+
+	region, err := util.RegionFromZone(zone)
+	if err != nil {
+		return subnet.Subnetwork{}, err
+	}
+	subnetwork, err := i.subnetworkService.Find(networks.NetworkProjectID(), networks.SubnetworkName(), region)
+	if err != nil {
+		if err == subnet.ErrSubnetNotFound {
+			return subnet.Subnetwork{}, i.subnetworkNotFoundError(networks, zone, region)
+		}
+		return subnet.Subnetwork{}, err
+	}
 
-	return metadata, nil
+	return subnetwork, nil
 }
 
-func (i GoogleInstanceService) createNetworkInterfacesParams(networks Networks, zone string) ([]*compute.NetworkInterface, error) {
+// subnetworkNotFoundError tells apart a subnetwork that does not exist from
+// one in another region than the instance zone, which GCE reports alike.
+func (i GoogleInstanceService) subnetworkNotFoundError(networks Networks, zone string, region string) error {
+	subnetworks, err := i.subnetworkService.FindByName(networks.NetworkProjectID(), networks.SubnetworkName())
+	if err != nil {
+		i.logger.Debug(googleInstanceServiceLogTag, "Failed to find Google Subnetworks '%s' in other regions: %#v", networks.SubnetworkName(), err)
+	}
+	if len(subnetworks) == 0 {
+		return bosherr.WrapErrorf(subnet.ErrSubnetNotFound, "Subnetwork '%s' does not exist in project '%s'", networks.SubnetworkName(), networks.NetworkProjectID())
+	}
+
+	var regions []string
+	for _, subnetwork := range subnetworks {
+		regions = append(regions, subnetwork.Region)
+	}
+	return bosherr.Errorf("Subnetwork '%s' is in region '%s', not in region '%s' of zone '%s'", networks.SubnetworkName(), strings.Join(regions, "', '"), region, zone)
+}
+
+func (i GoogleInstanceService) createNetworkInterfacesParams(networks Networks, subnetwork subnet.Subnetwork, internalAddressIP string) ([]*compute.NetworkInterface, error) {
 	network, found, err := i.networkService.Find(networks.NetworkProjectID(), networks.NetworkName())
 	if err != nil {
 		return nil, err
@@ -181,29 +372,52 @@ func (i GoogleInstanceService) createNetworkInterfacesParams(networks Networks,
 		return nil, bosherr.WrapErrorf(err, "Network '%s' does not exist in project '%s'", networks.NetworkName(), networks.NetworkProjectID())
 	}
 
-	subnetworkLink := ""
-	if networks.SubnetworkName() != "" {
-		subnetwork, err := i.subnetworkService.Find(networks.NetworkProjectID(), networks.SubnetworkName(), util.RegionFromZone(zone))
-		if err != nil {
-			if err == subnet.ErrSubnetNotFound {
-				return nil, bosherr.WrapErrorf(err, "Subnetwork '%s' does not exist in project '%s'", networks.SubnetworkName(), networks.NetworkProjectID())
-			}
-			return nil, err
-		}
-		subnetworkLink = subnetwork.SelfLink
+	if subnetwork.Network != "" && subnetwork.Network != network.SelfLink {
+		return nil, bosherr.Errorf("Subnetwork '%s' belongs to network '%s', not to network '%s'", subnetwork.Name, util.ResourceSplitter(subnetwork.Network), networks.NetworkName())
 	}
 
+	subnetworkLink := subnetwork.SelfLink
+
 	var networkInterfaces []*compute.NetworkInterface
 	var accessConfigs []*compute.AccessConfig
 
-	vipNetwork := networks.VipNetwork()
-	if networks.EphemeralExternalIP() || vipNetwork.IP != "" {
+	networkIP := networks.StaticPrivateIP()
+	if reservedIP := networks.ReservedIP(); reservedIP != "" {
+		networkIP = reservedIP
+	}
+	if internalAddressIP != "" {
+		if networkIP != "" && networkIP != internalAddressIP {
+			return nil, bosherr.Errorf("Google Internal Address '%s' conflicts with the manual network IP '%s'", internalAddressIP, networkIP)
+		}
+		networkIP = internalAddressIP
+	}
+	natIP := networks.VipNetwork().IP
+	if natIP != "" {
+		// A vip network backed by a reserved internal address is used as the
+		// instance private IP instead of being attached as an external NAT
+		internalAddress, found, err := i.findInternalVipAddress(natIP)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			if err := validateInternalVipAddress(internalAddress, subnetworkLink); err != nil {
+				return nil, err
+			}
+			if networkIP != "" && networkIP != internalAddress.Address {
+				return nil, bosherr.Errorf("Google Internal Address '%s' conflicts with the manual network IP '%s'", internalAddress.Address, networkIP)
+			}
+			networkIP = internalAddress.Address
+			natIP = ""
+		}
+	}
+
+	if networks.EphemeralExternalIP() || natIP != "" {
 		accessConfig := &compute.AccessConfig{
 			Name: "External NAT",
 			Type: "ONE_TO_ONE_NAT",
 		}
-		if vipNetwork.IP != "" {
-			accessConfig.NatIP = vipNetwork.IP
+		if natIP != "" {
+			accessConfig.NatIP = natIP
 		}
 		accessConfigs = append(accessConfigs, accessConfig)
 	}
@@ -212,7 +426,7 @@ func (i GoogleInstanceService) createNetworkInterfacesParams(networks Networks,
 		Network:       network.SelfLink,
 		Subnetwork:    subnetworkLink,
 		AccessConfigs: accessConfigs,
-		NetworkIP:     networks.StaticPrivateIP(),
+		NetworkIP:     networkIP,
 	}
 	networkInterfaces = append(networkInterfaces, networkInterface)
 
@@ -252,8 +466,9 @@ func (i GoogleInstanceService) createServiceAccountsParams(vmProps *Properties)
 		vmProps.ServiceAccount = "default"
 	}
 
-	// A service account, but no scopes. Set the "full access" scope.
-	if vmProps.ServiceAccount != "" && len(vmProps.ServiceScopes) == 0 {
+	// A service account, but no scopes set. Set the "full access" scope. An
+	// explicitly empty list of scopes gives the account no scopes.
+	if vmProps.ServiceAccount != "" && vmProps.ServiceScopes == nil {
 		vmProps.ServiceScopes = ServiceScopes([]string{"https://www.googleapis.com/auth/cloud-platform"})
 	}
 
@@ -274,6 +489,24 @@ func (i GoogleInstanceService) createServiceAccountsParams(vmProps *Properties)
 	return []*compute.ServiceAccount{serviceAccount}
 }
 
+// validateLoadBalancers checks that the target pool the instance would be
+// added to exists. It is only used in dry run, real creates fail on add.
+func (i GoogleInstanceService) validateLoadBalancers(vmProps *Properties) error {
+	if vmProps.TargetPool == "" {
+		return nil
+	}
+
+	_, found, err := i.targetPoolService.Find(vmProps.TargetPool, "")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return bosherr.Errorf("Target Pool '%s' does not exist", vmProps.TargetPool)
+	}
+
+	return nil
+}
+
 func (i GoogleInstanceService) addToTargetPool(instanceSelfLink string, targetPoolName string) error {
 	if err := i.targetPoolService.AddInstance(targetPoolName, instanceSelfLink); err != nil {
 		return err
@@ -0,0 +1,718 @@
+package instance_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/address_service"
+	addressfakes "bosh-google-cpi/google/address_service/fakes"
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/config"
+	"bosh-google-cpi/google/disk_service"
+	"bosh-google-cpi/google/instance_template_service"
+	"bosh-google-cpi/google/network_service"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+	"bosh-google-cpi/google/project_service"
+	"bosh-google-cpi/google/subnetwork_service"
+	subnetworkfakes "bosh-google-cpi/google/subnetwork_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService Create", func() {
+	var (
+		server       *clientfakes.FakeComputeServer
+		insertQuery  string
+		insertAPI    string
+		insertedBody string
+		inserted     compute.Instance
+		insertError  string
+
+		operationService *operationfakes.FakeOperationService
+
+		addressService    *addressfakes.FakeAddressService
+		subnetworkService *subnetworkfakes.FakeSubnetworkService
+
+		vmService    GoogleInstanceService
+		newVMService func(useBetaAPI bool) GoogleInstanceService
+		apiVersion   string
+		vmProps      *Properties
+		networks     Networks
+	)
+
+	BeforeEach(func() {
+		insertQuery = ""
+		insertAPI = ""
+		apiVersion = ""
+		insertedBody = ""
+		inserted = compute.Instance{}
+		insertError = ""
+		operationService = &operationfakes.FakeOperationService{}
+		server = clientfakes.NewFakeComputeServer()
+		server.Respond("GET", "/fake-project/global/networks/fake-network-name", `{"name": "fake-network-name", "selfLink": "fake-network-self-link"}`)
+		insert := func(api string) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				insertAPI = api
+				insertQuery = r.URL.Query().Get("sourceInstanceTemplate")
+				body, _ := ioutil.ReadAll(r.Body)
+				insertedBody = string(body)
+				Expect(json.Unmarshal(body, &inserted)).To(Succeed())
+				if insertError != "" {
+					w.WriteHeader(http.StatusForbidden)
+					fmt.Fprint(w, insertError)
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			}
+		}
+		server.Handle("POST", "/fake-project/zones/fake-region1-a/instances", insert("v1"))
+		server.Handle("POST", "/beta/fake-project/zones/fake-region1-a/instances", insert("beta"))
+
+		computeService := server.ComputeService()
+		computeServiceB := server.ComputeBetaService()
+		computeServiceB.BasePath = server.URL() + "/beta/"
+
+		addressService = &addressfakes.FakeAddressService{}
+		subnetworkService = &subnetworkfakes.FakeSubnetworkService{
+			FindSubnetwork: subnetwork.Subnetwork{
+				Name:     "fake-subnetwork-name",
+				SelfLink: "fake-subnetwork-self-link",
+			},
+		}
+
+		newVMService = func(useBetaAPI bool) GoogleInstanceService {
+			logger := boshlog.NewLogger(boshlog.LevelNone)
+			return NewGoogleInstanceService(
+				"fake-project",
+				computeService,
+				computeServiceB,
+				addressService,
+				nil,
+				network.NewGoogleNetworkService(project.NewGoogleProjectService("fake-project"), computeService, logger),
+				operationService,
+				subnetworkService,
+				nil,
+				&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+				logger,
+				false,
+				0,
+				useBetaAPI,
+				"",
+				apiVersion,
+			)
+		}
+		vmService = newVMService(false)
+
+		vmProps = &Properties{
+			Zone:        "fake-region1-a",
+			Stemcell:    "fake-image-self-link",
+			MachineType: "fake-machine-type-self-link",
+			Tags:        Tags{"fake-cpi-tag"},
+			Labels:      Labels{"fake-label": "fake-cpi-value"},
+		}
+		networks = Networks{
+			"fake-network": &Network{
+				Type:        "dynamic",
+				NetworkName: "fake-network-name",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("creates the vm without an instance template", func() {
+		vm, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(vm).To(Equal("vm-fake-uuid"))
+		Expect(insertQuery).To(BeEmpty())
+		Expect(inserted.MachineType).To(Equal("fake-machine-type-self-link"))
+		Expect(inserted.Scheduling).NotTo(BeNil())
+		Expect(insertAPI).To(Equal("v1"))
+	})
+
+	It("enables the guest attributes of the vm", func() {
+		vmProps.EnableGuestAttributes = true
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata := map[string]string{}
+		for _, item := range inserted.Metadata.Items {
+			metadata[item.Key] = *item.Value
+		}
+		Expect(metadata).To(HaveKeyWithValue("enable-guest-attributes", "TRUE"))
+	})
+
+	It("leaves the guest attributes disabled by default", func() {
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		for _, item := range inserted.Metadata.Items {
+			Expect(item.Key).NotTo(Equal("enable-guest-attributes"))
+		}
+	})
+
+	It("enables OS Login on the vm", func() {
+		enableOSLogin := true
+		vmProps.EnableOSLogin = &enableOSLogin
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata := map[string]string{}
+		for _, item := range inserted.Metadata.Items {
+			metadata[item.Key] = *item.Value
+		}
+		Expect(metadata).To(HaveKeyWithValue("enable-oslogin", "TRUE"))
+		Expect(metadata).NotTo(HaveKey("ssh-keys"))
+	})
+
+	It("disables OS Login and sets the metadata SSH keys of the vm", func() {
+		enableOSLogin := false
+		vmProps.EnableOSLogin = &enableOSLogin
+		vmProps.SSHKeys = []string{"fake-user:ssh-rsa fake-key fake-user", "other-user:ssh-ed25519 other-key"}
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata := map[string]string{}
+		for _, item := range inserted.Metadata.Items {
+			metadata[item.Key] = *item.Value
+		}
+		Expect(metadata).To(HaveKeyWithValue("enable-oslogin", "FALSE"))
+		Expect(metadata).To(HaveKeyWithValue("ssh-keys", "fake-user:ssh-rsa fake-key fake-user\nother-user:ssh-ed25519 other-key"))
+	})
+
+	It("records the reboot method of the vm", func() {
+		vmProps.RebootMethod = "STOP_START"
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+
+		metadata := map[string]string{}
+		for _, item := range inserted.Metadata.Items {
+			metadata[item.Key] = *item.Value
+		}
+		Expect(metadata).To(HaveKeyWithValue("bosh-reboot-method", "STOP_START"))
+	})
+
+	It("describes the vm as managed by BOSH by default", func() {
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Description).To(Equal("Instance managed by BOSH"))
+	})
+
+	It("creates the vm with the description of its properties", func() {
+		vmProps.Description = "fake-description"
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Description).To(Equal("fake-description"))
+	})
+
+	Context("when the vm fails to be created", func() {
+		It("returns a retryable capacity error if the project is out of quota", func() {
+			insertError = `{"error": {"code": 403, "message": "Quota 'CPUS' exceeded. Limit: 24.0 in region fake-region1.", "errors": [{"reason": "quotaExceeded", "message": "Quota 'CPUS' exceeded. Limit: 24.0 in region fake-region1."}]}}`
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(api.VMCapacityError{}))
+			Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
+		})
+
+		It("returns a retryable capacity error if the zone is out of resources", func() {
+			operationService.WaiterErr = errors.New("The zone 'projects/fake-project/zones/fake-region1-a' does not have enough resources available to fulfill the request.")
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(api.VMCapacityError{}))
+			Expect(err.(api.RetryableError).CanRetry()).To(BeTrue())
+			Expect(IsZoneStockoutError(err)).To(BeTrue())
+		})
+
+		It("returns a non-retryable error for other failures", func() {
+			insertError = `{"error": {"code": 403, "message": "Required 'compute.instances.create' permission", "errors": [{"reason": "forbidden", "message": "Required 'compute.instances.create' permission"}]}}`
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(api.VMCreationFailedError{}))
+			Expect(err.(api.RetryableError).CanRetry()).To(BeFalse())
+		})
+	})
+
+	It("creates the vm without an external IP unless the network sets one", func() {
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.NetworkInterfaces).To(HaveLen(1))
+		Expect(inserted.NetworkInterfaces[0].AccessConfigs).To(BeEmpty())
+	})
+
+	It("creates an ephemeral disk deleted along with the vm", func() {
+		vmProps.EphemeralDisk = &EphemeralDisk{SizeGb: 20, DiskType: "fake-disk-type-self-link"}
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Disks).To(HaveLen(2))
+		Expect(inserted.Disks[0].Boot).To(BeTrue())
+		Expect(inserted.Disks[1].Boot).To(BeFalse())
+		Expect(inserted.Disks[1].AutoDelete).To(BeTrue())
+		Expect(inserted.Disks[1].InitializeParams.DiskSizeGb).To(Equal(int64(20)))
+		Expect(inserted.Disks[1].InitializeParams.DiskType).To(Equal("fake-disk-type-self-link"))
+		Expect(inserted.Disks[1].InitializeParams.Labels).To(HaveKey(disk.EphemeralLabelKey))
+	})
+
+	It("sets the device name and interface of the boot disk", func() {
+		vmProps.RootDeviceName = "fake-root-device"
+		vmProps.RootDiskInterface = "SCSI"
+
+		_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Disks[0].Boot).To(BeTrue())
+		Expect(inserted.Disks[0].DeviceName).To(Equal("fake-root-device"))
+		Expect(inserted.Disks[0].Interface).To(Equal("SCSI"))
+	})
+
+	Context("when a service account is set", func() {
+		BeforeEach(func() {
+			vmProps.ServiceAccount = "fake-service-account"
+		})
+
+		It("gives the account full access when no scopes are set", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted.ServiceAccounts).To(HaveLen(1))
+			Expect(inserted.ServiceAccounts[0].Email).To(Equal("fake-service-account"))
+			Expect(inserted.ServiceAccounts[0].Scopes).To(Equal([]string{"https://www.googleapis.com/auth/cloud-platform"}))
+		})
+
+		It("gives the account no scopes when an empty list of scopes is set", func() {
+			vmProps.ServiceScopes = ServiceScopes{}
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted.ServiceAccounts).To(HaveLen(1))
+			Expect(inserted.ServiceAccounts[0].Email).To(Equal("fake-service-account"))
+			Expect(inserted.ServiceAccounts[0].Scopes).To(BeEmpty())
+		})
+	})
+
+	Context("when SourceInstanceTemplate is set", func() {
+		BeforeEach(func() {
+			vmProps.MachineType = ""
+			vmProps.SourceInstanceTemplate = &instancetemplate.InstanceTemplate{
+				Name:     "fake-instance-template",
+				SelfLink: "fake-instance-template-self-link",
+				Metadata: map[string]string{
+					"fake-template-key": "fake-template-value",
+					"user_data":         "fake-template-user-data",
+				},
+				Tags:   []string{"fake-template-tag", "fake-cpi-tag"},
+				Labels: map[string]string{"fake-label": "fake-template-value", "fake-template-label": "fake-template-value"},
+			}
+		})
+
+		It("creates the vm from the template", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertQuery).To(Equal("fake-instance-template-self-link"))
+		})
+
+		It("merges the template metadata, tags and labels under the CPI ones", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+
+			metadata := map[string]string{}
+			for _, item := range inserted.Metadata.Items {
+				metadata[item.Key] = *item.Value
+			}
+			Expect(metadata).To(HaveKeyWithValue("fake-template-key", "fake-template-value"))
+			Expect(metadata["user_data"]).To(ContainSubstring("fake-registry-endpoint"))
+
+			Expect(inserted.Tags.Items).To(ConsistOf("fake-template-tag", "fake-cpi-tag"))
+			Expect(inserted.Labels).To(Equal(map[string]string{
+				"fake-label":          "fake-cpi-value",
+				"fake-template-label": "fake-template-value",
+			}))
+		})
+
+		It("overrides the disks and leaves the other properties to the template", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inserted.Disks).To(HaveLen(1))
+			Expect(inserted.Disks[0].InitializeParams.SourceImage).To(Equal("fake-image-self-link"))
+			Expect(inserted.MachineType).To(BeEmpty())
+			Expect(inserted.Scheduling).To(BeNil())
+			Expect(inserted.ServiceAccounts).To(BeEmpty())
+		})
+	})
+	Context("when the network tier is set", func() {
+		BeforeEach(func() {
+			networks["fake-network"].EphemeralExternalIP = true
+			networks["fake-network"].NetworkTier = NetworkTierStandard
+			vmService = newVMService(true)
+		})
+
+		It("sets the tier of the external IP", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted.NetworkInterfaces[0].AccessConfigs).To(HaveLen(1))
+			Expect(insertedBody).To(ContainSubstring(`"networkTier":"STANDARD"`))
+			Expect(insertAPI).To(Equal("beta"))
+		})
+
+		It("sets the PREMIUM tier of the external IP", func() {
+			networks["fake-network"].NetworkTier = NetworkTierPremium
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertedBody).To(ContainSubstring(`"networkTier":"PREMIUM"`))
+		})
+
+		It("leaves the tier to the default when unset", func() {
+			networks["fake-network"].NetworkTier = ""
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted.NetworkInterfaces[0].AccessConfigs).To(HaveLen(1))
+			Expect(insertedBody).NotTo(ContainSubstring("networkTier"))
+		})
+
+		It("returns an error if the beta API is not enabled", func() {
+			vmService = newVMService(false)
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Network tier requires the beta API, enable 'use_beta_api' to use it"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("does not need the beta API without an external IP", func() {
+			vmService = newVMService(false)
+			networks["fake-network"].EphemeralExternalIP = false
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertedBody).NotTo(ContainSubstring("networkTier"))
+			Expect(insertAPI).To(Equal("v1"))
+		})
+
+		It("returns an error if instances are pinned to the v1 API", func() {
+			apiVersion = config.APIVersionV1
+			vmService = newVMService(true)
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Network tier requires the beta API, but instances are pinned to the 'v1' API"))
+			Expect(insertAPI).To(BeEmpty())
+		})
+	})
+
+	Context("when the root disk and its source image use KMS keys", func() {
+		const (
+			diskKey  = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-disk-key"
+			imageKey = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-image-key"
+		)
+
+		var insertedB computebeta.Instance
+
+		BeforeEach(func() {
+			insertedB = computebeta.Instance{}
+			vmProps.RootDiskKmsKeyName = diskKey
+			vmProps.SourceImageKmsKeyName = imageKey
+			vmService = newVMService(true)
+		})
+
+		It("creates the vm through the beta API with both keys on the boot disk", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertAPI).To(Equal("beta"))
+
+			Expect(json.Unmarshal([]byte(insertedBody), &insertedB)).To(Succeed())
+			bootDisk := insertedB.Disks[0]
+			Expect(bootDisk.Boot).To(BeTrue())
+			Expect(bootDisk.DiskEncryptionKey).To(Equal(&computebeta.CustomerEncryptionKey{KmsKeyName: diskKey}))
+			Expect(bootDisk.InitializeParams.SourceImage).To(Equal("fake-image-self-link"))
+			Expect(bootDisk.InitializeParams.SourceImageEncryptionKey).To(Equal(&computebeta.CustomerEncryptionKey{KmsKeyName: imageKey}))
+		})
+
+		It("sets only the key of the source image when the disk does not use one", func() {
+			vmProps.RootDiskKmsKeyName = ""
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(json.Unmarshal([]byte(insertedBody), &insertedB)).To(Succeed())
+			Expect(insertedB.Disks[0].DiskEncryptionKey).To(BeNil())
+			Expect(insertedB.Disks[0].InitializeParams.SourceImageEncryptionKey).To(Equal(&computebeta.CustomerEncryptionKey{KmsKeyName: imageKey}))
+		})
+
+		It("returns an error if the beta API is not enabled", func() {
+			vmService = newVMService(false)
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Encrypting the root disk with KMS keys requires the beta API, enable 'use_beta_api' to use it"))
+			Expect(insertAPI).To(BeEmpty())
+		})
+
+		It("returns an error if instances are pinned to the v1 API", func() {
+			apiVersion = config.APIVersionV1
+			vmService = newVMService(true)
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Encrypting the root disk with KMS keys requires the beta API, but instances are pinned to the 'v1' API"))
+		})
+	})
+
+	Context("when instances are pinned to the beta API", func() {
+		BeforeEach(func() {
+			apiVersion = config.APIVersionBeta
+			vmService = newVMService(true)
+		})
+
+		It("creates the vm through the beta API", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertAPI).To(Equal("beta"))
+			Expect(inserted.MachineType).To(Equal("fake-machine-type-self-link"))
+			Expect(insertedBody).NotTo(ContainSubstring("networkTier"))
+		})
+	})
+
+	Context("when the network has a subnetwork", func() {
+		BeforeEach(func() {
+			networks["fake-network"].SubnetworkName = "fake-subnetwork-name"
+			subnetworkService.FindSubnetwork.Network = "fake-network-self-link"
+			subnetworkService.FindSubnetwork.Region = "fake-region1"
+		})
+
+		It("creates the vm in the subnetwork", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(inserted.NetworkInterfaces[0].Network).To(Equal("fake-network-self-link"))
+			Expect(inserted.NetworkInterfaces[0].Subnetwork).To(Equal("fake-subnetwork-self-link"))
+		})
+
+		It("returns an error if the subnetwork is in another region than the zone", func() {
+			subnetworkService.FindErr = subnetwork.ErrSubnetNotFound
+			subnetworkService.FindByNameSubnetworks = []subnetwork.Subnetwork{{Name: "fake-subnetwork-name", Region: "fake-region2"}}
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Subnetwork 'fake-subnetwork-name' is in region 'fake-region2', not in region 'fake-region1' of zone 'fake-region1-a'"))
+			Expect(subnetworkService.FindByNameCalled).To(BeTrue())
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the subnetwork does not exist in any region", func() {
+			subnetworkService.FindErr = subnetwork.ErrSubnetNotFound
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Subnetwork 'fake-subnetwork-name' does not exist"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the subnetwork belongs to another network", func() {
+			subnetworkService.FindSubnetwork.Network = "https://www.googleapis.com/compute/v1/projects/fake-project/global/networks/fake-other-network"
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Subnetwork 'fake-subnetwork-name' belongs to network 'fake-other-network', not to network 'fake-network-name'"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+	})
+
+	Context("when the vip network IP is a reserved internal address", func() {
+		BeforeEach(func() {
+			networks["fake-network"].SubnetworkName = "fake-subnetwork-name"
+			networks["fake-vip-network"] = &Network{
+				Type: "vip",
+				IP:   "10.0.0.10",
+			}
+			addressService.FindByIPFound = true
+			addressService.FindByIPAddress = address.Address{
+				Name:        "fake-internal-address",
+				Address:     "10.0.0.10",
+				AddressType: address.AddressTypeInternal,
+				Status:      address.StatusReserved,
+				Subnetwork:  "fake-subnetwork-self-link",
+			}
+		})
+
+		It("uses the address as the instance private IP", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addressService.FindByIPCalled).To(BeTrue())
+
+			Expect(inserted.NetworkInterfaces).To(HaveLen(1))
+			Expect(inserted.NetworkInterfaces[0].NetworkIP).To(Equal("10.0.0.10"))
+			Expect(inserted.NetworkInterfaces[0].Subnetwork).To(Equal("fake-subnetwork-self-link"))
+			Expect(inserted.NetworkInterfaces[0].AccessConfigs).To(BeEmpty())
+		})
+
+		It("returns an error if the address belongs to another subnetwork", func() {
+			addressService.FindByIPAddress.Subnetwork = "https://www.googleapis.com/compute/v1/projects/fake-project/regions/fake-region/subnetworks/fake-other-subnetwork"
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Internal Address '10.0.0.10' belongs to subnetwork 'fake-other-subnetwork', not to the instance subnetwork 'fake-subnetwork-self-link'"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the address is already in use", func() {
+			addressService.FindByIPAddress.Status = "IN_USE"
+			addressService.FindByIPAddress.Users = []string{"fake-instance-self-link"}
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Internal Address '10.0.0.10' is already in use by [fake-instance-self-link]"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the network has no subnetwork", func() {
+			networks["fake-network"].SubnetworkName = ""
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("requires the network to set a 'subnetwork_name'"))
+		})
+	})
+
+	Context("when the vip network IP is an external static address", func() {
+		BeforeEach(func() {
+			networks["fake-vip-network"] = &Network{
+				Type: "vip",
+				IP:   "fake-external-ip",
+			}
+		})
+
+		It("attaches the address as an external NAT", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(inserted.NetworkInterfaces[0].NetworkIP).To(BeEmpty())
+			Expect(inserted.NetworkInterfaces[0].AccessConfigs).To(HaveLen(1))
+			Expect(inserted.NetworkInterfaces[0].AccessConfigs[0].NatIP).To(Equal("fake-external-ip"))
+		})
+	})
+	Context("when the network sets an ip", func() {
+		BeforeEach(func() {
+			networks["fake-network"].SubnetworkName = "fake-subnetwork-name"
+			networks["fake-network"].ReservedIP = "10.0.0.20"
+			subnetworkService.FindSubnetwork.IPCidrRange = "10.0.0.0/24"
+		})
+
+		It("reserves the ip and uses it as the instance private IP", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(addressService.ReserveCalled).To(BeTrue())
+			Expect(addressService.ReserveID).To(Equal("vm-fake-uuid-ip"))
+			Expect(addressService.ReserveRegion).To(Equal("fake-region1"))
+			Expect(addressService.ReserveSubnetwork).To(Equal("fake-subnetwork-self-link"))
+			Expect(addressService.ReserveIPAddress).To(Equal("10.0.0.20"))
+			Expect(inserted.NetworkInterfaces[0].NetworkIP).To(Equal("10.0.0.20"))
+		})
+
+		It("uses an existing reserved internal address", func() {
+			addressService.FindByIPFound = true
+			addressService.FindByIPAddress = address.Address{
+				Name:        "fake-internal-address",
+				Address:     "10.0.0.20",
+				AddressType: address.AddressTypeInternal,
+				Status:      address.StatusReserved,
+				Subnetwork:  "fake-subnetwork-self-link",
+			}
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addressService.ReserveCalled).To(BeFalse())
+			Expect(inserted.NetworkInterfaces[0].NetworkIP).To(Equal("10.0.0.20"))
+		})
+
+		It("returns an error if the ip is not in the subnetwork range", func() {
+			networks["fake-network"].ReservedIP = "10.1.0.20"
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Network IP '10.1.0.20' is not in the range '10.0.0.0/24' of subnetwork 'fake-subnetwork-name'"))
+			Expect(addressService.ReserveCalled).To(BeFalse())
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the network has no subnetwork", func() {
+			networks["fake-network"].SubnetworkName = ""
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Network IP '10.0.0.20' requires the network to set a 'subnetwork_name'"))
+		})
+	})
+
+	Context("when the network sets an internal address name", func() {
+		BeforeEach(func() {
+			networks["fake-network"].SubnetworkName = "fake-subnetwork-name"
+			networks["fake-network"].InternalAddressName = "fake-internal-address"
+			addressService.FindFound = true
+			addressService.FindAddress = address.Address{
+				Name:        "fake-internal-address",
+				Address:     "10.0.0.30",
+				AddressType: address.AddressTypeInternal,
+				Status:      address.StatusReserved,
+				Subnetwork:  "fake-subnetwork-self-link",
+			}
+		})
+
+		It("uses the named address as the instance private IP without reserving one", func() {
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(addressService.FindID).To(Equal("fake-internal-address"))
+			Expect(addressService.FindRegion).To(Equal("fake-region1"))
+			Expect(addressService.ReserveCalled).To(BeFalse())
+			Expect(inserted.NetworkInterfaces[0].NetworkIP).To(Equal("10.0.0.30"))
+		})
+
+		It("returns an error if the address does not exist", func() {
+			addressService.FindFound = false
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Address 'fake-internal-address' does not exist in region 'fake-region1'"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the address is not an INTERNAL address", func() {
+			addressService.FindAddress.AddressType = "EXTERNAL"
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Address 'fake-internal-address' is not an INTERNAL address"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+
+		It("returns an error if the address belongs to another subnetwork", func() {
+			addressService.FindAddress.Subnetwork = "https://www.googleapis.com/compute/v1/projects/fake-project/regions/fake-region1/subnetworks/fake-other-subnetwork"
+
+			_, err := vmService.Create(vmProps, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Internal Address '10.0.0.30' belongs to subnetwork 'fake-other-subnetwork'"))
+			Expect(inserted.Name).To(BeEmpty())
+		})
+	})
+})
@@ -11,6 +11,11 @@ import (
 const asyncDeleteKey = "CPI_ASYNC_DELETE"
 
 func (i GoogleInstanceService) Delete(id string) error {
+	if i.dryRun {
+		i.logger.Warn(googleInstanceServiceLogTag, "Dry run, not deleting Google Instance '%s'", id)
+		return nil
+	}
+
 	instance, found, err := i.Find(id, "")
 	if err != nil {
 		return err
@@ -19,6 +24,17 @@ func (i GoogleInstanceService) Delete(id string) error {
 		return api.NewVMNotFoundError(id)
 	}
 
+	// Give the guest a chance to shut down cleanly before it is deleted
+	if i.gracefulShutdownTimeout > 0 && instance.Status == STATUS_RUNNING {
+		_, stopped, err := i.shutdown(instance)
+		if err != nil {
+			return err
+		}
+		if !stopped {
+			i.logger.Warn(googleInstanceServiceLogTag, "Google Instance '%s' did not shut down within %v, deleting it", id, i.gracefulShutdownTimeout)
+		}
+	}
+
 	i.logger.Debug(googleInstanceServiceLogTag, "Deleting Google Instance '%s'", id)
 	operation, err := i.computeService.Instances.Delete(i.project, util.ResourceSplitter(instance.Zone), id).Do()
 	if err != nil {
@@ -32,6 +48,13 @@ func (i GoogleInstanceService) Delete(id string) error {
 		}
 	}
 
+	// The address can only be released once the instance is gone, which an
+	// async delete does not wait for
+	released, err := i.releaseReservedIP(id, instance.Zone)
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to release the reserved IP of Google Instance '%s'", id)
+	}
+
 	if err = i.removeFromTargetPool(instance.SelfLink); err != nil {
 		return bosherr.WrapErrorf(err, "Failed to remove Google Instance %q from Target Pool", id)
 	}
@@ -39,5 +62,16 @@ func (i GoogleInstanceService) Delete(id string) error {
 	if err = i.removeFromBackendService(instance.SelfLink); err != nil {
 		return bosherr.WrapErrorf(err, "Failed to remove Google Instance %q from Backend Services", id)
 	}
+
+	// Retry once, the instance had more time to release the address
+	if !released {
+		if released, err = i.releaseReservedIP(id, instance.Zone); err != nil {
+			return bosherr.WrapErrorf(err, "Failed to release the reserved IP of Google Instance '%s'", id)
+		}
+		if !released {
+			i.logger.Warn(googleInstanceServiceLogTag, "Google Address '%s' is still in use by Google Instance '%s' and has to be released manually", reservedIPAddressName(id), id)
+		}
+	}
+
 	return nil
 }
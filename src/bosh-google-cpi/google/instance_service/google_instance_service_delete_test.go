@@ -0,0 +1,241 @@
+package instance_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+
+	"bosh-google-cpi/google/address_service"
+	addressfakes "bosh-google-cpi/google/address_service/fakes"
+	"bosh-google-cpi/google/backendservice_service"
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+	targetpoolfakes "bosh-google-cpi/google/target_pool_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService Delete", func() {
+	const instancePath = "/fake-project/zones/us-central1-a/instances/fake-instance"
+
+	var (
+		server           *clientfakes.FakeComputeServer
+		addressService   *addressfakes.FakeAddressService
+		operationService *operationfakes.FakeOperationService
+		requests         []string
+		statuses         []string
+		instanceStatus   string
+
+		computeService  *compute.Service
+		computeServiceB *computebeta.Service
+	)
+
+	newVMService := func(gracefulShutdownTimeout time.Duration) GoogleInstanceService {
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		return NewGoogleInstanceService(
+			"fake-project",
+			computeService,
+			computeServiceB,
+			addressService,
+			backendservice.NewGoogleBackendServiceService("fake-project", computeService, operationService, logger),
+			nil,
+			operationService,
+			nil,
+			&targetpoolfakes.FakeTargetPoolService{},
+			&fakeuuid.FakeGenerator{},
+			logger,
+			false,
+			gracefulShutdownTimeout,
+			false,
+			"",
+			"",
+		)
+	}
+
+	// nextStatus returns the status the instance is in when polled, the last
+	// one is repeated
+	nextStatus := func() string {
+		status := statuses[0]
+		if len(statuses) > 1 {
+			statuses = statuses[1:]
+		}
+		return status
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		statuses = []string{"RUNNING"}
+		instanceStatus = "RUNNING"
+		addressService = &addressfakes.FakeAddressService{}
+		operationService = &operationfakes.FakeOperationService{}
+		server = clientfakes.NewFakeComputeServer()
+		instance := `{"name": "fake-instance", "zone": "https://www.googleapis.com/compute/v1/projects/fake-project/zones/us-central1-a", "selfLink": "https://www.googleapis.com/compute/v1/projects/fake-project/zones/us-central1-a/instances/fake-instance", "status": "%s"}`
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, `{"items": {"zones/us-central1-a": {"instances": [`+instance+`]}}}`, instanceStatus)
+		})
+		server.Handle("GET", instancePath, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "GET")
+			fmt.Fprintf(w, instance, nextStatus())
+		})
+		server.Handle("POST", instancePath+"/stop", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "STOP")
+			fmt.Fprint(w, `{"name": "fake-stop-operation", "status": "PENDING"}`)
+		})
+		server.Handle("DELETE", instancePath, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "DELETE")
+			fmt.Fprint(w, `{"name": "fake-delete-operation", "status": "DONE"}`)
+		})
+		server.Respond("GET", "/fake-project/global/backendServices", `{}`)
+
+		computeService = server.ComputeService()
+		computeServiceB = server.ComputeBetaService()
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("deletes the instance right away without a graceful shutdown timeout", func() {
+		Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+		Expect(requests).To(Equal([]string{"DELETE"}))
+		Expect(addressService.DeleteCalled).To(BeFalse())
+	})
+
+	It("releases the internal address reserved for the instance", func() {
+		addressService.FindFound = true
+		addressService.FindAddress = address.Address{Name: "fake-instance-ip", Description: "Address managed by BOSH", Status: address.StatusReserved}
+
+		Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+		Expect(addressService.DeleteCalled).To(BeTrue())
+		Expect(addressService.DeleteID).To(Equal("fake-instance-ip"))
+		Expect(addressService.DeleteRegion).To(Equal("us-central1"))
+	})
+
+	Context("with an async delete", func() {
+		BeforeEach(func() {
+			os.Setenv("CPI_ASYNC_DELETE", "true")
+			addressService.FindFound = true
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("CPI_ASYNC_DELETE")
+		})
+
+		It("releases the internal address once the instance no longer uses it", func() {
+			addressService.FindAddresses = []address.Address{
+				{Name: "fake-instance-ip", Description: "Address managed by BOSH", Status: "IN_USE"},
+				{Name: "fake-instance-ip", Description: "Address managed by BOSH", Status: address.StatusReserved},
+			}
+
+			Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+			Expect(addressService.DeleteCalled).To(BeTrue())
+			Expect(addressService.DeleteID).To(Equal("fake-instance-ip"))
+		})
+
+		It("does not release the internal address while the instance still uses it", func() {
+			addressService.FindAddress = address.Address{Name: "fake-instance-ip", Description: "Address managed by BOSH", Status: "IN_USE"}
+
+			Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+			Expect(addressService.DeleteCalled).To(BeFalse())
+		})
+	})
+
+	It("does not release internal addresses reserved outside of the CPI", func() {
+		addressService.FindFound = true
+		addressService.FindAddress = address.Address{Name: "fake-instance-ip"}
+
+		Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+		Expect(addressService.DeleteCalled).To(BeFalse())
+	})
+
+	It("keeps the named internal address the instance was created with", func() {
+		addressService.FindFound = false
+
+		Expect(newVMService(0).Delete("fake-instance")).To(Succeed())
+		Expect(addressService.FindID).To(Equal("fake-instance-ip"))
+		Expect(addressService.DeleteCalled).To(BeFalse())
+	})
+
+	Context("with a graceful shutdown timeout", func() {
+		It("stops the instance and waits for it to be terminated before deleting it", func() {
+			statuses = []string{"STOPPING", "STOPPING", "TERMINATED"}
+
+			Expect(newVMService(time.Second).Delete("fake-instance")).To(Succeed())
+			Expect(requests).To(Equal([]string{"STOP", "GET", "GET", "GET", "DELETE"}))
+		})
+
+		It("deletes the instance when it does not stop within the timeout", func() {
+			statuses = []string{"STOPPING"}
+
+			start := time.Now()
+			Expect(newVMService(200 * time.Millisecond).Delete("fake-instance")).To(Succeed())
+			Expect(time.Since(start)).To(BeNumerically(">=", 200*time.Millisecond))
+
+			Expect(len(requests)).To(BeNumerically(">", 2))
+			Expect(requests[0]).To(Equal("STOP"))
+			for _, request := range requests[1 : len(requests)-1] {
+				Expect(request).To(Equal("GET"))
+			}
+			Expect(requests[len(requests)-1]).To(Equal("DELETE"))
+		})
+	})
+
+	Describe("Stop", func() {
+		It("waits for the instance to be terminated", func() {
+			statuses = []string{"STOPPING", "TERMINATED"}
+
+			Expect(newVMService(time.Second).Stop("fake-instance")).To(Succeed())
+			Expect(requests).To(Equal([]string{"STOP", "GET", "GET"}))
+		})
+
+		It("does not wait on the stop operation when the instance stopped in time", func() {
+			statuses = []string{"TERMINATED"}
+
+			Expect(newVMService(time.Second).Stop("fake-instance")).To(Succeed())
+			Expect(operationService.WaiterCalled).To(BeFalse())
+		})
+
+		It("waits on the stop operation when the instance does not stop within the timeout", func() {
+			statuses = []string{"STOPPING"}
+
+			Expect(newVMService(100 * time.Millisecond).Stop("fake-instance")).To(Succeed())
+			Expect(operationService.WaiterCalled).To(BeTrue())
+			Expect(requests[0]).To(Equal("STOP"))
+			Expect(requests).NotTo(ContainElement("DELETE"))
+		})
+
+		It("returns an error if the stop operation fails", func() {
+			statuses = []string{"STOPPING"}
+			operationService.WaiterErr = errors.New("fake-operation-error")
+
+			err := newVMService(100 * time.Millisecond).Stop("fake-instance")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("fake-operation-error"))
+		})
+
+		It("waits for an instance that is already stopping", func() {
+			instanceStatus = "STOPPING"
+
+			Expect(newVMService(time.Second).Stop("fake-instance")).To(Succeed())
+			Expect(requests).To(Equal([]string{"STOP"}))
+			Expect(operationService.WaiterCalled).To(BeTrue())
+		})
+
+		It("does not stop an instance that is already terminated", func() {
+			instanceStatus = "TERMINATED"
+
+			Expect(newVMService(time.Second).Stop("fake-instance")).To(Succeed())
+			Expect(requests).To(BeEmpty())
+		})
+	})
+})
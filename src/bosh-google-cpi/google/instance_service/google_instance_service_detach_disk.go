@@ -5,9 +5,15 @@ import (
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/util"
+	"google.golang.org/api/googleapi"
 )
 
 func (i GoogleInstanceService) DetachDisk(id string, diskID string) error {
+	if i.dryRun {
+		i.logger.Warn(googleInstanceServiceLogTag, "Dry run, not detaching Google Disk '%s' from Google Instance '%s'", diskID, id)
+		return nil
+	}
+
 	// Find the instance
 	instance, found, err := i.Find(id, "")
 	if err != nil {
@@ -32,6 +38,10 @@ func (i GoogleInstanceService) DetachDisk(id string, diskID string) error {
 	i.logger.Debug(googleInstanceServiceLogTag, "Detaching Google Disk '%s' from Google Instance '%s'", diskID, id)
 	operation, err := i.computeService.Instances.DetachDisk(i.project, util.ResourceSplitter(instance.Zone), id, deviceName).Do()
 	if err != nil {
+		// The disk may have been detached or deleted since the instance was read
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			return api.NewDiskNotAttachedError(id, diskID, false)
+		}
 		return bosherr.WrapErrorf(err, "Failed to detach Google Disk '%s' from Google Instance '%s'", diskID, id)
 	}
 
@@ -0,0 +1,119 @@
+package instance_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/network_service"
+	"bosh-google-cpi/google/project_service"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService in dry run", func() {
+	var (
+		server *clientfakes.FakeComputeServer
+
+		vmService GoogleInstanceService
+		networks  Networks
+	)
+
+	BeforeEach(func() {
+		server = clientfakes.NewFakeComputeServer()
+		server.Respond("GET", "/fake-project/global/networks/fake-network-name", `{"name": "fake-network-name", "selfLink": "fake-network-self-link"}`)
+		computeService := server.ComputeService()
+
+		logger := boshlog.NewLogger(boshlog.LevelNone)
+		networkService := network.NewGoogleNetworkService(
+			project.NewGoogleProjectService("fake-project"),
+			computeService,
+			logger,
+		)
+
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			computeService,
+			server.ComputeBetaService(),
+			nil,
+			nil,
+			networkService,
+			nil,
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			logger,
+			true,
+			0,
+			false,
+			"",
+			"",
+		)
+
+		networks = Networks{
+			"fake-network": &Network{
+				Type:        "dynamic",
+				NetworkName: "fake-network-name",
+			},
+		}
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	mutatingRequests := func() []string {
+		var mutating []string
+		for _, request := range server.Requests() {
+			if request[:4] != "GET " {
+				mutating = append(mutating, request)
+			}
+		}
+		return mutating
+	}
+
+	Describe("Create", func() {
+		It("looks up the network but does not insert the instance", func() {
+			vm, err := vmService.Create(&Properties{Zone: "fake-zone"}, networks, "fake-registry-endpoint")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vm).To(Equal("vm-fake-uuid"))
+			Expect(server.Requests()).To(ContainElement("GET /fake-project/global/networks/fake-network-name"))
+			Expect(mutatingRequests()).To(BeEmpty())
+		})
+
+		It("returns an error if the network does not exist", func() {
+			networks["fake-network"].NetworkName = "fake-missing-network-name"
+
+			_, err := vmService.Create(&Properties{Zone: "fake-zone"}, networks, "fake-registry-endpoint")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Network 'fake-missing-network-name' does not exist"))
+			Expect(mutatingRequests()).To(BeEmpty())
+		})
+	})
+
+	Describe("Delete", func() {
+		It("does not call the API", func() {
+			Expect(vmService.Delete("fake-vm-id")).To(Succeed())
+			Expect(server.Requests()).To(BeEmpty())
+		})
+	})
+
+	Describe("AttachDisk", func() {
+		It("does not call the API", func() {
+			deviceName, _, err := vmService.AttachDisk("fake-vm-id", "https://fake-disk-self-link/fake-disk-id", "READ_WRITE", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deviceName).To(Equal("fake-disk-id"))
+			Expect(server.Requests()).To(BeEmpty())
+		})
+	})
+
+	Describe("DetachDisk", func() {
+		It("does not call the API", func() {
+			Expect(vmService.DetachDisk("fake-vm-id", "fake-disk-id")).To(Succeed())
+			Expect(server.Requests()).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,37 @@
+package instance
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+	"google.golang.org/api/compute/v1"
+)
+
+// FindByLabels finds the instances carrying all the labels, in every zone.
+func (i GoogleInstanceService) FindByLabels(labels Labels) ([]*compute.Instance, error) {
+	var found []*compute.Instance
+
+	i.logger.Debug(googleInstanceServiceLogTag, "Finding Google Instances labelled '%v'", labels)
+	call := i.computeService.Instances.AggregatedList(i.project).Filter(util.LabelsFilter(labels))
+	for {
+		instances, err := call.Do()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Failed to find Google Instances labelled '%v'", labels)
+		}
+
+		for _, instanceItems := range instances.Items {
+			for _, instance := range instanceItems.Instances {
+				// The filter is applied by the API, this only guards against
+				// filters it would ignore
+				if util.HasLabels(instance.Labels, labels) {
+					found = append(found, instance)
+				}
+			}
+		}
+
+		if instances.NextPageToken == "" {
+			return found, nil
+		}
+		call = call.PageToken(instances.NextPageToken)
+	}
+}
@@ -0,0 +1,70 @@
+package instance_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService FindByLabels", func() {
+	var (
+		server *clientfakes.FakeComputeServer
+		filter string
+
+		vmService GoogleInstanceService
+	)
+
+	BeforeEach(func() {
+		filter = ""
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			filter = r.URL.Query().Get("filter")
+			fmt.Fprint(w, `{"items": {"zones/fake-zone": {"instances": [
+				{"name": "fake-managed-instance", "labels": {"director": "fake-director", "deployment": "fake-deployment"}},
+				{"name": "fake-other-deployment-instance", "labels": {"director": "fake-director", "deployment": "fake-other-deployment"}},
+				{"name": "fake-unlabelled-instance"}
+			]}}}`)
+		})
+
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			nil,
+			nil,
+			&operationfakes.FakeOperationService{},
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			0,
+			false,
+			"",
+			"",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the instances carrying all the labels", func() {
+		instances, err := vmService.FindByLabels(Labels{"director": "fake-director", "deployment": "fake-deployment"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filter).To(Equal("(labels.deployment eq fake-deployment) (labels.director eq fake-director)"))
+		Expect(instances).To(HaveLen(1))
+		Expect(instances[0].Name).To(Equal("fake-managed-instance"))
+	})
+})
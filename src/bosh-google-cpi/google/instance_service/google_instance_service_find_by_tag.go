@@ -0,0 +1,39 @@
+package instance
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// FindByTag returns the names of the instances tagged with tag, across all
+// zones.
+func (i GoogleInstanceService) FindByTag(tag string) ([]string, error) {
+	var names []string
+
+	i.logger.Debug(googleInstanceServiceLogTag, "Finding Google Instances tagged '%s'", tag)
+	call := i.computeService.Instances.AggregatedList(i.project)
+	for {
+		instances, err := call.Do()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Failed to find Google Instances tagged '%s'", tag)
+		}
+
+		for _, instanceItems := range instances.Items {
+			for _, instance := range instanceItems.Instances {
+				if instance.Tags == nil {
+					continue
+				}
+				for _, instanceTag := range instance.Tags.Items {
+					if instanceTag == tag {
+						names = append(names, instance.Name)
+						break
+					}
+				}
+			}
+		}
+
+		if instances.NextPageToken == "" {
+			return names, nil
+		}
+		call = call.PageToken(instances.NextPageToken)
+	}
+}
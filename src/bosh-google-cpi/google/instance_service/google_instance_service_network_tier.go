@@ -0,0 +1,128 @@
+package instance
+
+import (
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/config"
+	"bosh-google-cpi/util"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+)
+
+// insert creates the instance. The network tier of external IPs and the KMS
+// keys of the root disk are only known to the beta API, so instances with
+// any set are inserted through it, as are all instances when they are pinned
+// to the beta API.
+func (i GoogleInstanceService) insert(vm *compute.Instance, vmProps *Properties, networkTier string) (*compute.Operation, error) {
+	if !i.insertWithBetaAPI(vm, vmProps, networkTier) {
+		insertCall := i.computeService.Instances.Insert(i.project, util.ResourceSplitter(vmProps.Zone), vm)
+		if vmProps.SourceInstanceTemplate != nil {
+			insertCall = insertCall.SourceInstanceTemplate(vmProps.SourceInstanceTemplate.SelfLink)
+		}
+		return insertCall.Do()
+	}
+
+	vmB := &computebeta.Instance{}
+	if err := util.ConvertResource(vm, vmB); err != nil {
+		return nil, err
+	}
+	if networkTier != "" {
+		for _, networkInterface := range vmB.NetworkInterfaces {
+			for _, accessConfig := range networkInterface.AccessConfigs {
+				accessConfig.NetworkTier = networkTier
+			}
+		}
+	}
+	for _, disk := range vmB.Disks {
+		if !disk.Boot {
+			continue
+		}
+		if vmProps.RootDiskKmsKeyName != "" {
+			disk.DiskEncryptionKey = &computebeta.CustomerEncryptionKey{KmsKeyName: vmProps.RootDiskKmsKeyName}
+		}
+		if vmProps.SourceImageKmsKeyName != "" && disk.InitializeParams != nil {
+			disk.InitializeParams.SourceImageEncryptionKey = &computebeta.CustomerEncryptionKey{KmsKeyName: vmProps.SourceImageKmsKeyName}
+		}
+	}
+
+	insertCall := i.computeServiceB.Instances.Insert(i.project, util.ResourceSplitter(vmProps.Zone), vmB)
+	if vmProps.SourceInstanceTemplate != nil {
+		insertCall = insertCall.SourceInstanceTemplate(vmProps.SourceInstanceTemplate.SelfLink)
+	}
+	operationB, err := insertCall.Do()
+	if err != nil {
+		return nil, err
+	}
+
+	// The operation is the same resource in both APIs, so the insert is
+	// waited for like any other
+	operation := &compute.Operation{}
+	if err := util.ConvertResource(operationB, operation); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+// insertWithBetaAPI tells if the instance is inserted through the beta API,
+// which the API version instances are pinned to decides when set.
+func (i GoogleInstanceService) insertWithBetaAPI(vm *compute.Instance, vmProps *Properties, networkTier string) bool {
+	switch i.apiVersion {
+	case config.APIVersionBeta:
+		return true
+	case config.APIVersionV1:
+		return false
+	}
+	return (networkTier != "" && hasAccessConfigs(vm)) || hasRootDiskKmsKeys(vmProps)
+}
+
+func hasRootDiskKmsKeys(vmProps *Properties) bool {
+	return vmProps.RootDiskKmsKeyName != "" || vmProps.SourceImageKmsKeyName != ""
+}
+
+func (i GoogleInstanceService) updateNetworkTier(instance *compute.Instance, networks Networks) error {
+	networkTier := networks.NetworkTier()
+	if networkTier == "" {
+		return nil
+	}
+
+	// External IPs are attached with the default tier, so any other tier
+	// needs the VM to be recreated
+	if len(instance.NetworkInterfaces[0].AccessConfigs) == 0 {
+		if networkTier != NetworkTierPremium && (networks.EphemeralExternalIP() || networks.VipNetwork().IP != "") {
+			i.logger.Debug(googleInstanceServiceLogTag, "Attaching a '%s' tier external IP to Google Instance '%s' not supported", networkTier, instance.Name)
+			return api.NotSupportedError{}
+		}
+		return nil
+	}
+
+	if err := i.requireBetaAPI("Network tier"); err != nil {
+		return err
+	}
+
+	instanceB, found, err := i.FindBeta(instance.Name, instance.Zone)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return api.NewVMNotFoundError(instance.Name)
+	}
+
+	instanceNetworkTier := NetworkTierPremium
+	if accessConfigs := instanceB.NetworkInterfaces[0].AccessConfigs; len(accessConfigs) > 0 && accessConfigs[0].NetworkTier != "" {
+		instanceNetworkTier = accessConfigs[0].NetworkTier
+	}
+	if instanceNetworkTier != networkTier {
+		i.logger.Debug(googleInstanceServiceLogTag, "Changing network tier for Google Instance '%s' not supported", instance.Name)
+		return api.NotSupportedError{}
+	}
+
+	return nil
+}
+
+func hasAccessConfigs(vm *compute.Instance) bool {
+	for _, networkInterface := range vm.NetworkInterfaces {
+		if len(networkInterface.AccessConfigs) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -5,7 +5,10 @@ import (
 	"sort"
 
 	"bosh-google-cpi/api"
+	"bosh-google-cpi/google/address_service"
 	"bosh-google-cpi/util"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -30,6 +33,10 @@ func (i GoogleInstanceService) UpdateNetworkConfiguration(id string, networks Ne
 		return err
 	}
 
+	if err = i.updateNetworkTier(instance, networks); err != nil {
+		return err
+	}
+
 	if err = i.updateExternalIP(instance, networks); err != nil {
 		return err
 	}
@@ -80,7 +87,15 @@ func (i GoogleInstanceService) updateExternalIP(instance *compute.Instance, netw
 
 	vipNetwork := networks.VipNetwork()
 	if vipNetwork.IP != "" {
-		err = i.updateVipAddress(instance, vipNetwork.IP)
+		var found bool
+		if _, found, err = i.findInternalVipAddress(vipNetwork.IP); err != nil {
+			return err
+		}
+		if found {
+			err = i.updateInternalVipAddress(instance, networks, vipNetwork.IP)
+		} else {
+			err = i.updateVipAddress(instance, vipNetwork.IP)
+		}
 	} else {
 		err = i.updateEphemeralExternalIP(instance, networks)
 	}
@@ -91,6 +106,16 @@ func (i GoogleInstanceService) updateExternalIP(instance *compute.Instance, netw
 	return nil
 }
 
+func (i GoogleInstanceService) updateInternalVipAddress(instance *compute.Instance, networks Networks, ipAddress string) error {
+	// The private IP of an instance can not be changed, so we need to recreate the VM
+	if instance.NetworkInterfaces[0].NetworkIP != ipAddress {
+		i.logger.Debug(googleInstanceServiceLogTag, "Changing private IP for Google Instance '%s' not supported", instance.Name)
+		return api.NotSupportedError{}
+	}
+
+	return i.updateEphemeralExternalIP(instance, networks)
+}
+
 func (i GoogleInstanceService) updateVipAddress(instance *compute.Instance, ipAddress string) error {
 	var instanceExternalIP, accessConfigName string
 	if len(instance.NetworkInterfaces[0].AccessConfigs) > 0 {
@@ -215,3 +240,33 @@ func (i GoogleInstanceService) updateTags(instance *compute.Instance, networks N
 
 	return nil
 }
+
+// findInternalVipAddress returns the reserved INTERNAL address backing a vip
+// network IP, if any. External static IPs are reported as not found.
+func (i GoogleInstanceService) findInternalVipAddress(ipAddress string) (address.Address, bool, error) {
+	vipAddress, found, err := i.addressService.FindByIP(ipAddress)
+	if err != nil {
+		return address.Address{}, false, err
+	}
+	if !found || !vipAddress.IsInternal() {
+		return address.Address{}, false, nil
+	}
+
+	return vipAddress, true, nil
+}
+
+func validateInternalVipAddress(internalAddress address.Address, subnetworkLink string) error {
+	if subnetworkLink == "" {
+		return bosherr.Errorf("Google Internal Address '%s' requires the network to set a 'subnetwork_name'", internalAddress.Address)
+	}
+
+	if internalAddress.Subnetwork != subnetworkLink {
+		return bosherr.Errorf("Google Internal Address '%s' belongs to subnetwork '%s', not to the instance subnetwork '%s'", internalAddress.Address, util.ResourceSplitter(internalAddress.Subnetwork), util.ResourceSplitter(subnetworkLink))
+	}
+
+	if internalAddress.IsInUse() {
+		return bosherr.Errorf("Google Internal Address '%s' is already in use by %v", internalAddress.Address, internalAddress.Users)
+	}
+
+	return nil
+}
@@ -1,6 +1,8 @@
 package instance
 
 import (
+	"time"
+
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/api"
@@ -9,6 +11,7 @@ import (
 
 const (
 	STATUS_RUNNING    = "RUNNING"
+	STATUS_STOPPING   = "STOPPING"
 	STATUS_TERMINATED = "TERMINATED"
 )
 
@@ -46,3 +49,52 @@ func (i GoogleInstanceService) Reboot(id string) error {
 		return nil
 	}
 }
+
+// RebootMethod returns the reboot method recorded on the instance when it was
+// created, or an empty method when none was.
+func (i GoogleInstanceService) RebootMethod(id string) (string, error) {
+	instance, found, err := i.Find(id, "")
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", api.NewVMNotFoundError(id)
+	}
+
+	if instance.Metadata != nil {
+		for _, item := range instance.Metadata.Items {
+			if item.Key == rebootMethodKey && item.Value != nil {
+				return *item.Value, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// WaitForRunning polls the instance until it is RUNNING, for up to timeout.
+func (i GoogleInstanceService) WaitForRunning(id string, timeout time.Duration) error {
+	interval := timeout / 10
+	if interval > gracefulShutdownMaxPollInterval {
+		interval = gracefulShutdownMaxPollInterval
+	}
+
+	i.logger.Debug(googleInstanceServiceLogTag, "Waiting up to %v for Google Instance %q to be running", timeout, id)
+	deadline := time.Now().Add(timeout)
+	for {
+		instance, found, err := i.Find(id, "")
+		if err != nil {
+			return err
+		}
+		if !found {
+			return api.NewVMNotFoundError(id)
+		}
+		if instance.Status == STATUS_RUNNING {
+			return nil
+		}
+
+		if time.Now().Add(interval).After(deadline) {
+			return bosherr.Errorf("Timed out after %v waiting for Google Instance '%s' to be running, status is %q", timeout, id, instance.Status)
+		}
+		time.Sleep(interval)
+	}
+}
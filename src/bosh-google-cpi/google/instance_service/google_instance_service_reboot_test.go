@@ -0,0 +1,152 @@
+package instance_test
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	"bosh-google-cpi/api"
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService WaitForRunning", func() {
+	var (
+		server   *clientfakes.FakeComputeServer
+		polls    int
+		statuses []string
+
+		vmService GoogleInstanceService
+	)
+
+	BeforeEach(func() {
+		polls = 0
+		statuses = []string{"RUNNING"}
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			// The last status is repeated
+			polls++
+			status := statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			fmt.Fprintf(w, `{"items": {"zones/us-central1-a": {"instances": [{"name": "fake-instance", "zone": "us-central1-a", "status": "%s"}]}}}`, status)
+		})
+
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			nil,
+			nil,
+			&operationfakes.FakeOperationService{},
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			0,
+			false,
+			"",
+			"",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns right away if the instance is running", func() {
+		Expect(vmService.WaitForRunning("fake-instance", time.Second)).To(Succeed())
+		Expect(polls).To(Equal(1))
+	})
+
+	It("blocks until the instance is running", func() {
+		statuses = []string{"STOPPING", "TERMINATED", "STAGING", "RUNNING"}
+
+		Expect(vmService.WaitForRunning("fake-instance", time.Second)).To(Succeed())
+		Expect(polls).To(Equal(4))
+	})
+
+	It("returns an error if the instance is not running within the timeout", func() {
+		statuses = []string{"STAGING"}
+
+		err := vmService.WaitForRunning("fake-instance", 200*time.Millisecond)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Timed out after 200ms waiting for Google Instance 'fake-instance' to be running, status is \"STAGING\""))
+	})
+})
+
+var _ = Describe("GoogleInstanceService RebootMethod", func() {
+	var (
+		server   *clientfakes.FakeComputeServer
+		metadata string
+
+		vmService GoogleInstanceService
+	)
+
+	BeforeEach(func() {
+		metadata = `{"items": [{"key": "bosh-reboot-method", "value": "STOP_START"}]}`
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			if metadata == "" {
+				fmt.Fprint(w, `{"items": {}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"items": {"zones/us-central1-a": {"instances": [{"name": "fake-instance", "zone": "us-central1-a", "metadata": %s}]}}}`, metadata)
+		})
+
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			nil,
+			nil,
+			&operationfakes.FakeOperationService{},
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			0,
+			false,
+			"",
+			"",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the reboot method recorded on the instance", func() {
+		method, err := vmService.RebootMethod("fake-instance")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(method).To(Equal("STOP_START"))
+	})
+
+	It("returns an empty method if the instance has none recorded", func() {
+		metadata = `{"items": [{"key": "fake-key", "value": "fake-value"}]}`
+
+		method, err := vmService.RebootMethod("fake-instance")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(method).To(BeEmpty())
+	})
+
+	It("returns an error if the instance is not found", func() {
+		metadata = ""
+
+		_, err := vmService.RebootMethod("fake-instance")
+		Expect(err).To(Equal(api.NewVMNotFoundError("fake-instance")))
+	})
+})
@@ -0,0 +1,126 @@
+package instance
+
+import (
+	"fmt"
+	"net"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	subnet "bosh-google-cpi/google/subnetwork_service"
+	"bosh-google-cpi/util"
+)
+
+// reservedIPAddressName is the name of the INTERNAL address the CPI reserves
+// for the 'ip' network cloud property of an instance.
+func reservedIPAddressName(instanceName string) string {
+	return fmt.Sprintf("%s-ip", instanceName)
+}
+
+// validateReservedIP checks that the IP requested through the 'ip' network
+// cloud property is in the subnetwork range and, if it is already reserved,
+// that the reservation is an unassigned INTERNAL address of the subnetwork.
+// It reports whether the IP still needs to be reserved.
+func (i GoogleInstanceService) validateReservedIP(networks Networks, subnetwork subnet.Subnetwork) (bool, error) {
+	ipAddress := networks.ReservedIP()
+	if ipAddress == "" {
+		return false, nil
+	}
+
+	if staticIP := networks.StaticPrivateIP(); staticIP != "" && staticIP != ipAddress {
+		return false, bosherr.Errorf("Network IP '%s' conflicts with the manual network IP '%s'", ipAddress, staticIP)
+	}
+
+	if subnetwork.SelfLink == "" {
+		return false, bosherr.Errorf("Network IP '%s' requires the network to set a 'subnetwork_name'", ipAddress)
+	}
+
+	ip := net.ParseIP(ipAddress)
+	if ip == nil {
+		return false, bosherr.Errorf("Network IP '%s' is not a valid IP address", ipAddress)
+	}
+
+	_, ipRange, err := net.ParseCIDR(subnetwork.IPCidrRange)
+	if err != nil {
+		return false, bosherr.WrapErrorf(err, "Parsing the range of subnetwork '%s'", subnetwork.Name)
+	}
+	if !ipRange.Contains(ip) {
+		return false, bosherr.Errorf("Network IP '%s' is not in the range '%s' of subnetwork '%s'", ipAddress, subnetwork.IPCidrRange, subnetwork.Name)
+	}
+
+	reserved, found, err := i.findInternalVipAddress(ipAddress)
+	if err != nil {
+		return false, err
+	}
+	if found && reserved.Address == ipAddress {
+		return false, validateInternalVipAddress(reserved, subnetwork.SelfLink)
+	}
+
+	return true, nil
+}
+
+// findNamedInternalAddress returns the IP of the INTERNAL address named by
+// the 'internal_address_name' network cloud property, after checking that it
+// is an unassigned address of the subnetwork. The address is reserved outside
+// of the CPI, so releaseReservedIP never deletes it.
+func (i GoogleInstanceService) findNamedInternalAddress(networks Networks, subnetwork subnet.Subnetwork, zone string) (string, error) {
+	name := networks.InternalAddressName()
+	if name == "" {
+		return "", nil
+	}
+
+	region, err := util.RegionFromZone(util.ResourceSplitter(zone))
+	if err != nil {
+		return "", err
+	}
+
+	internalAddress, found, err := i.addressService.Find(name, region)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return "", bosherr.Errorf("Google Address '%s' does not exist in region '%s'", name, region)
+	}
+	if !internalAddress.IsInternal() {
+		return "", bosherr.Errorf("Google Address '%s' is not an INTERNAL address", name)
+	}
+	if err := validateInternalVipAddress(internalAddress, subnetwork.SelfLink); err != nil {
+		return "", err
+	}
+
+	return internalAddress.Address, nil
+}
+
+func (i GoogleInstanceService) reserveIP(instanceName string, zone string, subnetworkLink string, ipAddress string) error {
+	region, err := util.RegionFromZone(util.ResourceSplitter(zone))
+	if err != nil {
+		return err
+	}
+
+	_, err = i.addressService.Reserve(reservedIPAddressName(instanceName), region, subnetworkLink, ipAddress)
+	return err
+}
+
+// releaseReservedIP deletes the INTERNAL address the CPI reserved for the
+// instance, if any. Addresses reserved outside of the CPI are left alone. It
+// reports false, without deleting it, while the address is still in use.
+func (i GoogleInstanceService) releaseReservedIP(instanceName string, zone string) (bool, error) {
+	region, err := util.RegionFromZone(util.ResourceSplitter(zone))
+	if err != nil {
+		return false, err
+	}
+
+	name := reservedIPAddressName(instanceName)
+	reserved, found, err := i.addressService.Find(name, region)
+	if err != nil {
+		return false, err
+	}
+	if !found || !reserved.IsManaged() {
+		return true, nil
+	}
+	if reserved.IsInUse() {
+		return false, nil
+	}
+
+	i.logger.Debug(googleInstanceServiceLogTag, "Releasing Google Address '%s' of Google Instance '%s'", name, instanceName)
+	return true, i.addressService.Delete(name, region)
+}
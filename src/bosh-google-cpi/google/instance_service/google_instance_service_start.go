@@ -0,0 +1,36 @@
+package instance
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/util"
+)
+
+func (i GoogleInstanceService) Start(id string) error {
+	instance, found, err := i.Find(id, "")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return api.NewVMNotFoundError(id)
+	}
+
+	switch instance.Status {
+	default:
+		return bosherr.Errorf("Can not start instance in state %q", instance.Status)
+	case STATUS_RUNNING:
+		i.logger.Debug(googleInstanceServiceLogTag, "Google Instance %q is already running", id)
+		return nil
+	case STATUS_TERMINATED:
+		i.logger.Debug(googleInstanceServiceLogTag, "Starting Google Instance %q", id)
+		operation, err := i.computeService.Instances.Start(i.project, util.ResourceSplitter(instance.Zone), id).Do()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Failed to start Google Instance '%s'", id)
+		}
+		if _, err = i.operationService.Waiter(operation, instance.Zone, ""); err != nil {
+			return bosherr.WrapErrorf(err, "Failed to start Google Instance '%s'", id)
+		}
+		return nil
+	}
+}
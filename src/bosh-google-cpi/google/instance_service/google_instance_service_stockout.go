@@ -0,0 +1,47 @@
+package instance
+
+import (
+	"regexp"
+	"strings"
+
+	"bosh-google-cpi/api"
+)
+
+// Google Compute Engine reports a zone running out of capacity for the
+// requested resources (ZONE_RESOURCE_POOL_EXHAUSTED) with this message.
+const zoneStockoutMessage = "does not have enough resources available"
+
+// Google Compute Engine reports a project running out of quota either with
+// the QUOTA_EXCEEDED operation error, whose message names the quota, or with
+// the quotaExceeded API error reason.
+var quotaExceededRe = regexp.MustCompile(`QUOTA_EXCEEDED|quotaExceeded|Quota '[^']+' exceeded`)
+
+// IsZoneStockoutError reports whether a Create error was caused by the zone
+// not having enough resources, in which case the VM may fit in another zone.
+func IsZoneStockoutError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return strings.Contains(err.Error(), zoneStockoutMessage) || strings.Contains(err.Error(), "ZONE_RESOURCE_POOL_EXHAUSTED")
+}
+
+// IsCapacityError reports whether a Create error was caused by the zone
+// running out of resources or the project running out of quota, which may
+// go away when retried.
+func IsCapacityError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	return IsZoneStockoutError(err) || quotaExceededRe.MatchString(err.Error())
+}
+
+// newCreationFailedError returns the error of a failed Create, which can only
+// be retried when it was caused by a lack of capacity.
+func newCreationFailedError(err error) error {
+	if IsCapacityError(err) {
+		return api.NewVMCapacityError(err.Error())
+	}
+	return api.NewVMCreationFailedError(err.Error(), false)
+}
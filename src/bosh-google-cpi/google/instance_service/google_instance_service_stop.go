@@ -0,0 +1,90 @@
+package instance
+
+import (
+	"time"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/api"
+	"bosh-google-cpi/util"
+	"google.golang.org/api/compute/v1"
+)
+
+const gracefulShutdownMaxPollInterval = 5 * time.Second
+
+func (i GoogleInstanceService) Stop(id string) error {
+	instance, found, err := i.Find(id, "")
+	if err != nil {
+		return err
+	}
+	if !found {
+		return api.NewVMNotFoundError(id)
+	}
+
+	switch instance.Status {
+	default:
+		return bosherr.Errorf("Can not stop instance in state %q", instance.Status)
+	case STATUS_TERMINATED:
+		i.logger.Debug(googleInstanceServiceLogTag, "Google Instance %q is already stopped", id)
+		return nil
+	case STATUS_RUNNING, STATUS_STOPPING:
+		var operation *compute.Operation
+		if i.gracefulShutdownTimeout > 0 && instance.Status == STATUS_RUNNING {
+			var stopped bool
+			operation, stopped, err = i.shutdown(instance)
+			if err != nil {
+				return err
+			}
+			if stopped {
+				return nil
+			}
+			i.logger.Warn(googleInstanceServiceLogTag, "Google Instance '%s' did not shut down within %v, waiting for the stop operation", id, i.gracefulShutdownTimeout)
+		} else {
+			// Stopping keeps the instance disks and its internal and static
+			// external IPs, only the compute resources are released. A stop
+			// already in progress is waited on through a new stop operation.
+			i.logger.Debug(googleInstanceServiceLogTag, "Stopping Google Instance %q", id)
+			operation, err = i.computeService.Instances.Stop(i.project, util.ResourceSplitter(instance.Zone), id).Do()
+			if err != nil {
+				return bosherr.WrapErrorf(err, "Failed to stop Google Instance '%s'", id)
+			}
+		}
+
+		if _, err = i.operationService.Waiter(operation, instance.Zone, ""); err != nil {
+			return bosherr.WrapErrorf(err, "Failed to stop Google Instance '%s'", id)
+		}
+		return nil
+	}
+}
+
+// shutdown stops a running instance, which sends an ACPI shutdown to the
+// guest, and waits up to the graceful shutdown timeout for the instance to
+// be TERMINATED. It returns the stop operation and reports whether the
+// instance stopped in time.
+func (i GoogleInstanceService) shutdown(instance *compute.Instance) (*compute.Operation, bool, error) {
+	i.logger.Debug(googleInstanceServiceLogTag, "Stopping Google Instance %q, waiting up to %v for it to shut down", instance.Name, i.gracefulShutdownTimeout)
+	operation, err := i.computeService.Instances.Stop(i.project, util.ResourceSplitter(instance.Zone), instance.Name).Do()
+	if err != nil {
+		return nil, false, bosherr.WrapErrorf(err, "Failed to stop Google Instance '%s'", instance.Name)
+	}
+
+	interval := i.gracefulShutdownTimeout / 10
+	if interval > gracefulShutdownMaxPollInterval {
+		interval = gracefulShutdownMaxPollInterval
+	}
+
+	deadline := time.Now().Add(i.gracefulShutdownTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		current, found, err := i.Find(instance.Name, instance.Zone)
+		if err != nil {
+			return nil, false, err
+		}
+		if !found || current.Status == STATUS_TERMINATED {
+			return operation, true, nil
+		}
+	}
+
+	return operation, false, nil
+}
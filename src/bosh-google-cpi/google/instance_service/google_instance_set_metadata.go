@@ -1,87 +1,144 @@
 package instance
 
 import (
-	"fmt"
+	"net/http"
+	"reflect"
 
 	"bosh-google-cpi/api"
 	"bosh-google-cpi/util"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
-	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
+// Metadata and labels updates are guarded by a fingerprint. When another
+// update lands between reading the instance and writing it back the request
+// is rejected, so the instance is read again and the update retried.
+const setMetadataMaxAttempts = 3
+
+// SetMetadata updates the metadata items and labels of the instance from a
+// single read of the instance. Both updates are sent before waiting for
+// either, and updates that would not change anything are skipped. The
+// metadata item keys are prefixed with the configured metadata key prefix.
 func (i GoogleInstanceService) SetMetadata(id string, vmMetadata Metadata) error {
-	// Find the instance
-	instance, found, err := i.FindBeta(id, "")
-	if err != nil {
-		return err
+	labels, metadata := vmMetadata.Split()
+	metadata = metadata.WithKeyPrefix(i.metadataKeyPrefix)
+
+	for attempt := 1; ; attempt++ {
+		// Find the instance
+		instance, found, err := i.Find(id, "")
+		if err != nil {
+			return err
+		}
+		if !found {
+			return api.NewVMNotFoundError(id)
+		}
+
+		var operations []*compute.Operation
+		operation, err := i.setMetadataItems(instance, metadata)
+		if operation != nil {
+			operations = append(operations, operation)
+		}
+		if err == nil {
+			operation, err = i.setLabels(instance, labels)
+			if operation != nil {
+				operations = append(operations, operation)
+			}
+		}
+
+		// Wait for the updates sent so far, even if the other one failed
+		for _, operation := range operations {
+			if _, werr := i.operationService.Waiter(operation, instance.Zone, ""); werr != nil {
+				return bosherr.WrapErrorf(werr, "Failed to set metadata for Google Instance '%s'", id)
+			}
+		}
+
+		if err != nil {
+			if isFingerprintConflict(err) && attempt < setMetadataMaxAttempts {
+				i.logger.Debug(googleInstanceServiceLogTag, "Metadata fingerprint for Google Instance '%s' changed, retrying (%d/%d)", id, attempt, setMetadataMaxAttempts)
+				continue
+			}
+			return bosherr.WrapErrorf(err, "Failed to set metadata for Google Instance '%s'", id)
+		}
+
+		return nil
 	}
-	if !found {
-		return api.NewVMNotFoundError(id)
+}
+
+// setMetadataItems starts updating the instance metadata items, and returns
+// a nil operation when they are already up to date.
+func (i GoogleInstanceService) setMetadataItems(instance *compute.Instance, vmMetadata Metadata) (*compute.Operation, error) {
+	if len(vmMetadata) == 0 {
+		return nil, nil
 	}
 
-	// We need to reuse the original instance metadata fingerprint and items
+	// We need to reuse the original instance metadata fingerprint and
+	// preserve the items set by other tooling, such as startup scripts
 	metadata := instance.Metadata
-	metadataMap := make(map[string]string)
-
-	// Grab the original metadata items
+	if metadata == nil {
+		metadata = &compute.Metadata{}
+	}
+	currentMetadata := Metadata{}
 	for _, item := range metadata.Items {
-		metadataMap[item.Key] = *item.Value
+		if item.Value != nil {
+			currentMetadata[item.Key] = *item.Value
+		}
 	}
 
-	// TODO(evanbrown): Is it possible to update metadata, labels, and tags
-	// in a single PATCH request? The current method requires 4 requests to
-	// accomplish this.
-	// Add or override the new metadata items.
-	for key, value := range vmMetadata {
-		metadataMap[key] = value
+	// Add, override or remove the new metadata items
+	newMetadata := currentMetadata.Merge(vmMetadata)
+	if reflect.DeepEqual(newMetadata, currentMetadata) {
+		return nil, nil
 	}
-
-	// Set the new metadata items
-	var metadataItems []*computebeta.MetadataItems
-	for key, value := range metadataMap {
-		mValue := value
-		metadataItems = append(metadataItems, &computebeta.MetadataItems{Key: key, Value: &mValue})
+	if err := newMetadata.ValidateSize(); err != nil {
+		return nil, err
+	}
+	var metadataItems []*compute.MetadataItems
+	for _, key := range newMetadata.sortedKeys() {
+		mValue := newMetadata[key]
+		metadataItems = append(metadataItems, &compute.MetadataItems{Key: key, Value: &mValue})
 	}
 	metadata.Items = metadataItems
 
-	i.logger.Debug(googleInstanceServiceLogTag, "Setting metadata for Google Instance '%s'", id)
-	operation, err := i.computeServiceB.Instances.SetMetadata(i.project, util.ResourceSplitter(instance.Zone), id, metadata).Do()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to set metadata for Google Instance '%s'", id)
-	}
+	i.logger.Debug(googleInstanceServiceLogTag, "Setting metadata for Google Instance '%s'", instance.Name)
+	return i.computeService.Instances.SetMetadata(i.project, util.ResourceSplitter(instance.Zone), instance.Name, metadata).Do()
+}
 
-	if _, err = i.operationService.WaiterB(operation, instance.Zone, ""); err != nil {
-		return bosherr.WrapErrorf(err, "Failed to set metadata for Google Instance '%s'", id)
+// setLabels starts updating the instance labels, and returns a nil operation
+// when they are already up to date.
+func (i GoogleInstanceService) setLabels(instance *compute.Instance, labels Labels) (*compute.Operation, error) {
+	if len(labels) == 0 {
+		return nil, nil
 	}
 
-	// Apply labels to VM
 	// First create a new map and copy existing labels into it
 	labelsMap := make(map[string]string)
 	for k, v := range instance.Labels {
 		labelsMap[k] = v
 	}
 
-	for k, v := range vmMetadata {
-		if l, err := SafeLabel(v); err == nil {
-			labelsMap[k] = l
-		} else {
-			i.logger.Debug(googleInstanceServiceLogTag, fmt.Sprintf("Skipped label for %q: %v", k, err))
+	// Labels explicitly cleared with an empty value are removed
+	for k, v := range labels {
+		if v == "" {
+			delete(labelsMap, k)
+			continue
 		}
+		labelsMap[k] = v
+	}
+	if reflect.DeepEqual(labelsMap, instance.Labels) || len(labelsMap)+len(instance.Labels) == 0 {
+		return nil, nil
 	}
 
-	labelsRequest := &computebeta.InstancesSetLabelsRequest{
+	labelsRequest := &compute.InstancesSetLabelsRequest{
 		LabelFingerprint: instance.LabelFingerprint,
 		Labels:           labelsMap,
 	}
-	i.logger.Debug(googleInstanceServiceLogTag, "Setting labels for Google Instance '%s'", id)
-	operation, err = i.computeServiceB.Instances.SetLabels(i.project, util.ResourceSplitter(instance.Zone), id, labelsRequest).Do()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Failed to set labels for Google Instance '%s'", id)
-	}
-	if _, err = i.operationService.WaiterB(operation, instance.Zone, ""); err != nil {
-		return bosherr.WrapErrorf(err, "Failed to set labels for Google Instance '%s'", id)
-	}
+	i.logger.Debug(googleInstanceServiceLogTag, "Setting labels for Google Instance '%s'", instance.Name)
+	return i.computeService.Instances.SetLabels(i.project, util.ResourceSplitter(instance.Zone), instance.Name, labelsRequest).Do()
+}
 
-	return nil
+func isFingerprintConflict(err error) bool {
+	gerr, ok := err.(*googleapi.Error)
+	return ok && gerr.Code == http.StatusPreconditionFailed
 }
@@ -0,0 +1,156 @@
+package instance_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("GoogleInstanceService SetMetadata", func() {
+	const instancePath = "/fake-project/zones/us-central1-a/instances/fake-instance"
+
+	var (
+		server            *clientfakes.FakeComputeServer
+		requests          []string
+		metadataConflict  bool
+		labelsRequest     compute.InstancesSetLabelsRequest
+		metadataRequest   compute.Metadata
+		metadataKeyPrefix string
+		operationService  *operationfakes.FakeOperationService
+
+		vmService GoogleInstanceService
+	)
+
+	BeforeEach(func() {
+		requests = nil
+		metadataConflict = false
+		labelsRequest = compute.InstancesSetLabelsRequest{}
+		metadataRequest = compute.Metadata{}
+		metadataKeyPrefix = ""
+	})
+
+	JustBeforeEach(func() {
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/aggregated/instances", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "FIND")
+			fmt.Fprint(w, `{"items": {"zones/us-central1-a": {"instances": [{
+				"name": "fake-instance",
+				"zone": "us-central1-a",
+				"metadata": {"fingerprint": "fake-fingerprint", "items": [{"key": "director", "value": "fake-director"}]},
+				"labels": {"director": "fake-director", "cost-center": "fake-cost-center"},
+				"labelFingerprint": "fake-label-fingerprint"
+			}]}}}`)
+		})
+		server.Handle("POST", instancePath+"/setMetadata", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "SET_METADATA")
+			Expect(json.NewDecoder(r.Body).Decode(&metadataRequest)).To(Succeed())
+			if metadataConflict {
+				metadataConflict = false
+				clientfakes.WriteError(w, http.StatusPreconditionFailed, "fingerprint mismatch")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-metadata-operation", "status": "PENDING"}`)
+		})
+		server.Handle("POST", instancePath+"/setLabels", func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "SET_LABELS")
+			Expect(json.NewDecoder(r.Body).Decode(&labelsRequest)).To(Succeed())
+			fmt.Fprint(w, `{"name": "fake-labels-operation", "status": "PENDING"}`)
+		})
+
+		operationService = &operationfakes.FakeOperationService{}
+		vmService = NewGoogleInstanceService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			nil,
+			nil,
+			nil,
+			operationService,
+			nil,
+			nil,
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			0,
+			false,
+			metadataKeyPrefix,
+			"",
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("reads the instance once and sends the metadata and labels updates together", func() {
+		err := vmService.SetMetadata("fake-instance", Metadata{"deployment": "fake-deployment", "job": "fake job"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"FIND", "SET_METADATA", "SET_LABELS"}))
+		Expect(operationService.WaiterCalled).To(BeTrue())
+	})
+
+	It("skips the updates that would not change anything", func() {
+		err := vmService.SetMetadata("fake-instance", Metadata{"director": "fake-director"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"FIND"}))
+		Expect(operationService.WaiterCalled).To(BeFalse())
+	})
+
+	It("removes only the labels cleared with an empty value", func() {
+		err := vmService.SetMetadata("fake-instance", Metadata{"cost-center": ""})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"FIND", "SET_LABELS"}))
+		Expect(labelsRequest.LabelFingerprint).To(Equal("fake-label-fingerprint"))
+		Expect(labelsRequest.Labels).To(Equal(map[string]string{"director": "fake-director"}))
+	})
+
+	It("skips removing labels the instance does not have", func() {
+		err := vmService.SetMetadata("fake-instance", Metadata{"team": ""})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"FIND"}))
+	})
+
+	It("reads the instance again and retries when it changed in the meantime", func() {
+		metadataConflict = true
+
+		err := vmService.SetMetadata("fake-instance", Metadata{"deployment": "fake_deployment"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(requests).To(Equal([]string{"FIND", "SET_METADATA", "FIND", "SET_METADATA", "SET_LABELS"}))
+	})
+
+	Context("with a metadata key prefix", func() {
+		BeforeEach(func() {
+			metadataKeyPrefix = "bosh-"
+		})
+
+		It("prefixes the metadata item keys and preserves the other items", func() {
+			err := vmService.SetMetadata("fake-instance", Metadata{"job": "fake job"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"FIND", "SET_METADATA"}))
+
+			items := map[string]string{}
+			for _, item := range metadataRequest.Items {
+				items[item.Key] = *item.Value
+			}
+			Expect(items).To(Equal(map[string]string{"director": "fake-director", "bosh-job": "fake job"}))
+		})
+
+		It("does not remove the unprefixed items cleared with an empty value", func() {
+			err := vmService.SetMetadata("fake-instance", Metadata{"director": ""})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"FIND", "SET_LABELS"}))
+		})
+	})
+})
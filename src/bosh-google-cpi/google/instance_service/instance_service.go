@@ -1,12 +1,15 @@
 package instance
 
 import (
+	"time"
+
+	"bosh-google-cpi/google/instance_template_service"
 	"google.golang.org/api/compute/v1"
 )
 
 type Service interface {
 	AddAccessConfig(id string, zone string, networkInterface string, accessConfig *compute.AccessConfig) error
-	AttachDisk(id string, diskLink string) (string, string, error)
+	AttachDisk(id string, diskLink string, mode string, deviceName string) (string, string, error)
 	AttachedDisks(id string) (AttachedDisks, error)
 	CleanUp(id string)
 	Create(vmProps *Properties, networks Networks, registryEndpoint string) (string, error)
@@ -14,10 +17,17 @@ type Service interface {
 	DeleteAccessConfig(id string, zone string, networkInterface string, accessConfig string) error
 	DetachDisk(id string, diskID string) error
 	Find(id string, zone string) (*compute.Instance, bool, error)
+	FindByTag(tag string) ([]string, error)
+	FindByLabels(labels Labels) ([]*compute.Instance, error)
 	Reboot(id string) error
+	RebootMethod(id string) (string, error)
 	SetMetadata(id string, vmMetadata Metadata) error
 	SetTags(id string, zone string, instanceTags *compute.Tags) error
+	Start(id string) error
+	Stop(id string) error
 	UpdateNetworkConfiguration(id string, networks Networks) error
+	WaitForAttachedDisk(id string, diskLink string, deviceName string, timeout time.Duration) (string, error)
+	WaitForRunning(id string, timeout time.Duration) error
 }
 
 type AttachedDisks []string
@@ -31,6 +41,9 @@ type Properties struct {
 	MachineType       string
 	RootDiskSizeGb    int
 	RootDiskType      string
+	RootDeviceName    string
+	RootDiskInterface string
+	EphemeralDisk     *EphemeralDisk
 	AutomaticRestart  bool
 	OnHostMaintenance string
 	Preemptible       bool
@@ -41,6 +54,36 @@ type Properties struct {
 	Tags              Tags
 	Labels            Labels
 	Accelerators      []Accelerator
+	StartupScript     StartupScript
+	Description       string
+
+	// EnableGuestAttributes lets the guest publish guest attributes
+	EnableGuestAttributes bool
+
+	// EnableOSLogin, when set, enables or disables OS Login, and SSHKeys
+	// are the "<username>:<public key>" metadata SSH keys
+	EnableOSLogin *bool
+	SSHKeys       []string
+
+	// RebootMethod, when set, is how reboot_vm reboots the instance instead
+	// of the configured reboot method
+	RebootMethod string
+
+	// Cloud KMS keys the root disk is encrypted with, and the source image
+	// of the root disk is encrypted with. They require the beta API
+	RootDiskKmsKeyName    string
+	SourceImageKmsKeyName string
+
+	// SourceInstanceTemplate, when set, provides the properties the CPI
+	// does not set itself
+	SourceInstanceTemplate *instancetemplate.InstanceTemplate
+}
+
+// EphemeralDisk is a data disk created and deleted along with the VM, for
+// stemcells that expect the ephemeral disk apart from the root disk.
+type EphemeralDisk struct {
+	SizeGb   int
+	DiskType string
 }
 
 type ServiceScopes []string
@@ -49,6 +92,12 @@ type ServiceAccount string
 type BackendService struct {
 	Name string
 }
+
+type StartupScript struct {
+	Inline string
+	URL    string
+}
+
 type Accelerator struct {
 	AcceleratorType string
 	Count           int64
@@ -0,0 +1,166 @@
+package instance
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+)
+
+// MaxMetadataSize is the maximum total size, in bytes, of the keys and values
+// of an instance metadata.
+const MaxMetadataSize = 256 * 1024
+
+// Number of largest keys reported when metadata is too big.
+const metadataSizeReportedKeys = 3
+
+const startupScriptKey = "startup-script"
+const startupScriptURLKey = "startup-script-url"
+
+// guestAttributesKey enables the guest attributes of an instance, which the
+// guest can write through the metadata server.
+const guestAttributesKey = "enable-guest-attributes"
+
+// OS Login manages the SSH access to an instance through IAM, in which case
+// the SSH keys of its metadata are ignored.
+const osLoginKey = "enable-oslogin"
+const sshKeysKey = "ssh-keys"
+
+// rebootMethodKey records the reboot method of the instance, since reboot_vm
+// only gets the VM CID.
+const rebootMethodKey = "bosh-reboot-method"
+
+// Split partitions BOSH VM metadata into the entries applied as GCE labels
+// and the entries stored as instance metadata. Entries whose key and value
+// are already valid labels only become labels. Any other entry is kept as
+// instance metadata, and is also applied as a label when its key is a valid
+// label key and its value can be sanitized, so director tags such as
+// deployment names remain queryable. An empty value removes the key, from
+// both the labels and the instance metadata when the key is a valid label key.
+func (m Metadata) Split() (Labels, Metadata) {
+	labels := Labels{}
+	metadata := Metadata{}
+
+	for k, v := range m {
+		if !mustMatchRe.MatchString(k) {
+			metadata[k] = v
+			continue
+		}
+
+		if v == "" {
+			labels[k] = v
+			metadata[k] = v
+			continue
+		}
+
+		if mustMatchRe.MatchString(v) {
+			labels[k] = v
+			continue
+		}
+
+		metadata[k] = v
+		if l, err := SafeLabel(v); err == nil {
+			labels[k] = l
+		}
+	}
+
+	return labels, metadata
+}
+
+// Merge returns the metadata resulting from applying updates on top of m.
+// Keys absent from updates are preserved, and keys explicitly cleared with
+// an empty value are removed.
+func (m Metadata) Merge(updates Metadata) Metadata {
+	merged := Metadata{}
+	for k, v := range m {
+		merged[k] = v
+	}
+
+	for k, v := range updates {
+		if v == "" {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// WithKeyPrefix returns the metadata with prefix prepended to every key, so
+// the items written by the CPI cannot clash with the ones set by other
+// tooling on the same instance.
+func (m Metadata) WithKeyPrefix(prefix string) Metadata {
+	if prefix == "" {
+		return m
+	}
+
+	prefixed := Metadata{}
+	for k, v := range m {
+		prefixed[prefix+k] = v
+	}
+	return prefixed
+}
+
+// Size returns the total size, in bytes, of the metadata keys and values.
+func (m Metadata) Size() int {
+	size := 0
+	for k, v := range m {
+		size += len(k) + len(v)
+	}
+	return size
+}
+
+// ValidateSize returns an error identifying the largest keys when the
+// metadata exceeds MaxMetadataSize, so it fails before calling the API.
+func (m Metadata) ValidateSize() error {
+	size := m.Size()
+	if size <= MaxMetadataSize {
+		return nil
+	}
+
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		sizeA, sizeB := len(keys[a])+len(m[keys[a]]), len(keys[b])+len(m[keys[b]])
+		if sizeA == sizeB {
+			return keys[a] < keys[b]
+		}
+		return sizeA > sizeB
+	})
+	if len(keys) > metadataSizeReportedKeys {
+		keys = keys[:metadataSizeReportedKeys]
+	}
+
+	largest := make([]string, len(keys))
+	for i, k := range keys {
+		largest[i] = fmt.Sprintf("%s (%d bytes)", k, len(k)+len(m[k]))
+	}
+
+	return bosherr.Errorf("Instance metadata exceeds 256KB (actual %d bytes), largest keys: %s", size, strings.Join(largest, ", "))
+}
+
+// Metadata returns the instance metadata running the startup script, either
+// inline or downloaded from a Google Cloud Storage URL.
+func (s StartupScript) Metadata() Metadata {
+	switch {
+	case s.Inline != "":
+		return Metadata{startupScriptKey: s.Inline}
+	case s.URL != "":
+		return Metadata{startupScriptURLKey: s.URL}
+	}
+	return Metadata{}
+}
+
+// sortedKeys returns the metadata keys in a stable order.
+func (m Metadata) sortedKeys() []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
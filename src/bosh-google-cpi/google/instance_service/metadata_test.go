@@ -0,0 +1,148 @@
+package instance_test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_service"
+)
+
+var _ = Describe("Metadata", func() {
+	Describe("Split", func() {
+		It("applies label-safe keys and values as labels only", func() {
+			labels, metadata := Metadata{
+				"director":   "fake-director",
+				"deployment": "fake-deployment",
+			}.Split()
+
+			Expect(labels).To(Equal(Labels{
+				"director":   "fake-director",
+				"deployment": "fake-deployment",
+			}))
+			Expect(metadata).To(BeEmpty())
+		})
+
+		It("keeps non label-safe keys as instance metadata", func() {
+			labels, metadata := Metadata{
+				"created_at": "fake-created-at",
+				"Job":        "fake-job",
+			}.Split()
+
+			Expect(labels).To(BeEmpty())
+			Expect(metadata).To(Equal(Metadata{
+				"created_at": "fake-created-at",
+				"Job":        "fake-job",
+			}))
+		})
+
+		It("keeps free-form values as instance metadata and applies a sanitized label", func() {
+			labels, metadata := Metadata{
+				"job":   "fake_job/0",
+				"index": "0",
+			}.Split()
+
+			Expect(labels).To(Equal(Labels{
+				"job":   "fake-job-0",
+				"index": "n0",
+			}))
+			Expect(metadata).To(Equal(Metadata{
+				"job":   "fake_job/0",
+				"index": "0",
+			}))
+		})
+
+		It("does not apply a label for values that can not be sanitized", func() {
+			labels, metadata := Metadata{
+				"name": "Fake Name",
+			}.Split()
+
+			Expect(labels).To(BeEmpty())
+			Expect(metadata).To(Equal(Metadata{"name": "Fake Name"}))
+		})
+
+		It("clears both the label and the instance metadata of keys with an empty value", func() {
+			labels, metadata := Metadata{
+				"cost-center": "",
+				"Job":         "",
+			}.Split()
+
+			Expect(labels).To(Equal(Labels{"cost-center": ""}))
+			Expect(metadata).To(Equal(Metadata{"cost-center": "", "Job": ""}))
+		})
+	})
+	Describe("Merge", func() {
+		It("preserves existing keys when adding a new key", func() {
+			merged := Metadata{
+				"startup-script": "fake-startup-script",
+				"bosh_settings":  "fake-settings",
+			}.Merge(Metadata{"director": "fake-director"})
+
+			Expect(merged).To(Equal(Metadata{
+				"startup-script": "fake-startup-script",
+				"bosh_settings":  "fake-settings",
+				"director":       "fake-director",
+			}))
+		})
+
+		It("overrides existing keys", func() {
+			merged := Metadata{"director": "fake-director"}.Merge(Metadata{"director": "fake-new-director"})
+
+			Expect(merged).To(Equal(Metadata{"director": "fake-new-director"}))
+		})
+
+		It("removes keys explicitly cleared", func() {
+			merged := Metadata{
+				"startup-script": "fake-startup-script",
+				"director":       "fake-director",
+			}.Merge(Metadata{"director": ""})
+
+			Expect(merged).To(Equal(Metadata{"startup-script": "fake-startup-script"}))
+		})
+
+		It("does not modify the existing metadata", func() {
+			existing := Metadata{"director": "fake-director"}
+			existing.Merge(Metadata{"director": "", "job": "fake-job"})
+
+			Expect(existing).To(Equal(Metadata{"director": "fake-director"}))
+		})
+	})
+	Describe("ValidateSize", func() {
+		It("does not return error if the metadata is under the limit", func() {
+			metadata := Metadata{
+				"bosh_settings":  strings.Repeat("s", 128*1024),
+				"startup-script": strings.Repeat("s", 64*1024),
+			}
+
+			Expect(metadata.ValidateSize()).ToNot(HaveOccurred())
+		})
+
+		It("returns error reporting the size and largest keys if the metadata is over the limit", func() {
+			metadata := Metadata{
+				"bosh_settings":  strings.Repeat("s", 200*1024),
+				"startup-script": strings.Repeat("s", 100*1024),
+				"user_data":      "fake-user-data",
+				"director":       "fake-director",
+			}
+
+			err := metadata.ValidateSize()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Instance metadata exceeds 256KB (actual %d bytes)", metadata.Size()))
+			Expect(err.Error()).To(ContainSubstring("largest keys: bosh_settings (204813 bytes), startup-script (102414 bytes), user_data (23 bytes)"))
+		})
+	})
+	Describe("StartupScript", func() {
+		It("sets the startup-script key for an inline script", func() {
+			Expect(StartupScript{Inline: "fake-startup-script"}.Metadata()).To(Equal(Metadata{"startup-script": "fake-startup-script"}))
+		})
+
+		It("sets the startup-script-url key for a url", func() {
+			Expect(StartupScript{URL: "gs://fake-bucket/fake-startup-script"}.Metadata()).To(Equal(Metadata{"startup-script-url": "gs://fake-bucket/fake-startup-script"}))
+		})
+
+		It("sets no key when not set", func() {
+			Expect(StartupScript{}.Metadata()).To(BeEmpty())
+		})
+	})
+})
@@ -7,6 +7,13 @@ import (
 
 const maxTagLength = 63
 
+// Network tiers of external IPs. GCE uses the project default tier, PREMIUM
+// unless changed, when none is set.
+const (
+	NetworkTierPremium  = "PREMIUM"
+	NetworkTierStandard = "STANDARD"
+)
+
 type Network struct {
 	Type                string
 	IP                  string
@@ -20,6 +27,9 @@ type Network struct {
 	EphemeralExternalIP bool
 	IPForwarding        bool
 	Tags                Tags
+	ReservedIP          string
+	NetworkTier         string
+	InternalAddressName string
 }
 
 type Tags []string
@@ -62,10 +72,22 @@ func (n Network) Validate() error {
 		if err := n.Tags.Validate(); err != nil {
 			return err
 		}
+		if err := n.validateNetworkTier(); err != nil {
+			return err
+		}
+		if n.InternalAddressName != "" && n.ReservedIP != "" {
+			return bosherr.Errorf("Network 'internal_address_name' '%s' can not be used with 'ip'", n.InternalAddressName)
+		}
 	case n.IsManual():
 		if err := n.Tags.Validate(); err != nil {
 			return err
 		}
+		if err := n.validateNetworkTier(); err != nil {
+			return err
+		}
+		if n.InternalAddressName != "" && n.ReservedIP != "" {
+			return bosherr.Errorf("Network 'internal_address_name' '%s' can not be used with 'ip'", n.InternalAddressName)
+		}
 	case n.IsVip():
 		if n.IP == "" {
 			return bosherr.Error("VIP Networks must provide an IP Address")
@@ -77,3 +99,12 @@ func (n Network) Validate() error {
 
 	return nil
 }
+
+func (n Network) validateNetworkTier() error {
+	switch n.NetworkTier {
+	case "", NetworkTierPremium, NetworkTierStandard:
+		return nil
+	default:
+		return bosherr.Errorf("Network tier '%s' not supported, must be '%s' or '%s'", n.NetworkTier, NetworkTierPremium, NetworkTierStandard)
+	}
+}
@@ -87,6 +87,35 @@ var _ = Describe("Network", func() {
 			})
 		})
 
+		Context("when the network tier is set", func() {
+			It("accepts PREMIUM and STANDARD", func() {
+				dynamicNetwork.NetworkTier = NetworkTierPremium
+				Expect(dynamicNetwork.Validate()).To(Succeed())
+
+				dynamicNetwork.NetworkTier = NetworkTierStandard
+				Expect(dynamicNetwork.Validate()).To(Succeed())
+			})
+
+			It("returns an error for other tiers", func() {
+				dynamicNetwork.NetworkTier = "FIXED_STANDARD"
+
+				err = dynamicNetwork.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Network tier 'FIXED_STANDARD' not supported"))
+			})
+		})
+
+		Context("when the internal address name is set", func() {
+			It("returns an error if the network also sets an ip", func() {
+				dynamicNetwork.InternalAddressName = "fake-internal-address"
+				dynamicNetwork.ReservedIP = "10.0.0.20"
+
+				err = dynamicNetwork.Validate()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Network 'internal_address_name' 'fake-internal-address' can not be used with 'ip'"))
+			})
+		})
+
 		Context("VIP Network", func() {
 			It("does not return error if network properties are valid", func() {
 				err = vipNetwork.Validate()
@@ -99,6 +99,24 @@ func (n Networks) StaticPrivateIP() string {
 	return network.IP
 }
 
+func (n Networks) ReservedIP() string {
+	network := n.Network()
+
+	return network.ReservedIP
+}
+
+func (n Networks) InternalAddressName() string {
+	network := n.Network()
+
+	return network.InternalAddressName
+}
+
+func (n Networks) NetworkTier() string {
+	network := n.Network()
+
+	return network.NetworkTier
+}
+
 func (n Networks) CanIPForward() bool {
 	network := n.Network()
 
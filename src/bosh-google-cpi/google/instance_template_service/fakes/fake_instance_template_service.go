@@ -0,0 +1,17 @@
+package fakes
+
+import (
+	"bosh-google-cpi/google/instance_template_service"
+)
+
+type FakeInstanceTemplateService struct {
+	FindCalled           bool
+	FindFound            bool
+	FindInstanceTemplate instancetemplate.InstanceTemplate
+	FindErr              error
+}
+
+func (t *FakeInstanceTemplateService) Find(id string) (instancetemplate.InstanceTemplate, bool, error) {
+	t.FindCalled = true
+	return t.FindInstanceTemplate, t.FindFound, t.FindErr
+}
@@ -0,0 +1,27 @@
+package instancetemplate
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"google.golang.org/api/compute/v1"
+)
+
+const googleInstanceTemplateServiceLogTag = "GoogleInstanceTemplateService"
+
+type GoogleInstanceTemplateService struct {
+	project        string
+	computeService *compute.Service
+	logger         boshlog.Logger
+}
+
+func NewGoogleInstanceTemplateService(
+	project string,
+	computeService *compute.Service,
+	logger boshlog.Logger,
+) GoogleInstanceTemplateService {
+	return GoogleInstanceTemplateService{
+		project:        project,
+		computeService: computeService,
+		logger:         logger,
+	}
+}
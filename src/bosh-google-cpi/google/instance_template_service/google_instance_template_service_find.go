@@ -0,0 +1,50 @@
+package instancetemplate
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"google.golang.org/api/googleapi"
+)
+
+func (t GoogleInstanceTemplateService) Find(id string) (InstanceTemplate, bool, error) {
+	t.logger.Debug(googleInstanceTemplateServiceLogTag, "Finding Google Instance Template '%s'", id)
+	templateItem, err := t.computeService.InstanceTemplates.Get(t.project, id).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			return InstanceTemplate{}, false, nil
+		}
+
+		return InstanceTemplate{}, false, bosherr.WrapErrorf(err, "Failed to find Google Instance Template '%s'", id)
+	}
+
+	template := InstanceTemplate{
+		Name:     templateItem.Name,
+		SelfLink: templateItem.SelfLink,
+	}
+
+	if properties := templateItem.Properties; properties != nil {
+		template.MachineType = properties.MachineType
+		template.Labels = properties.Labels
+
+		if properties.Metadata != nil {
+			template.Metadata = map[string]string{}
+			for _, item := range properties.Metadata.Items {
+				if item.Value != nil {
+					template.Metadata[item.Key] = *item.Value
+				}
+			}
+		}
+
+		if properties.Tags != nil {
+			template.Tags = properties.Tags.Items
+		}
+
+		for _, networkInterface := range properties.NetworkInterfaces {
+			if networkInterface.Subnetwork != "" {
+				template.Subnetworks = append(template.Subnetworks, networkInterface.Subnetwork)
+			}
+		}
+	}
+
+	return template, true, nil
+}
@@ -0,0 +1,37 @@
+package instancetemplate
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+type InstanceTemplate struct {
+	Name        string
+	SelfLink    string
+	MachineType string
+	Metadata    map[string]string
+	Tags        []string
+	Labels      map[string]string
+	Subnetworks []string
+}
+
+// ValidateZone checks that instances can be created from the template in
+// zone, that is that its subnetworks are in the region of the zone.
+func (t InstanceTemplate) ValidateZone(zone string) error {
+	if len(t.Subnetworks) == 0 {
+		return nil
+	}
+
+	region, err := util.RegionFromZone(zone)
+	if err != nil {
+		return err
+	}
+	for _, subnetwork := range t.Subnetworks {
+		if subnetworkRegion := util.RegionFromURL(subnetwork); subnetworkRegion != region {
+			return bosherr.Errorf("Instance Template '%s' uses subnetwork '%s' in region '%s', not usable in zone '%s'", t.Name, util.ResourceSplitter(subnetwork), subnetworkRegion, zone)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,5 @@
+package instancetemplate
+
+type Service interface {
+	Find(id string) (InstanceTemplate, bool, error)
+}
@@ -0,0 +1,13 @@
+package instancetemplate_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestInstanceTemplateService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Instance Template Service Suite")
+}
@@ -0,0 +1,44 @@
+package instancetemplate_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/instance_template_service"
+)
+
+var _ = Describe("InstanceTemplate", func() {
+	Describe("ValidateZone", func() {
+		It("accepts templates without subnetworks", func() {
+			Expect(InstanceTemplate{Name: "fake-template"}.ValidateZone("us-central1-a")).To(Succeed())
+		})
+
+		It("accepts templates with subnetworks in the region of the zone", func() {
+			template := InstanceTemplate{
+				Name:        "fake-template",
+				Subnetworks: []string{"https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central1/subnetworks/fake-subnetwork"},
+			}
+			Expect(template.ValidateZone("us-central1-a")).To(Succeed())
+		})
+
+		It("rejects templates with subnetworks in another region", func() {
+			template := InstanceTemplate{
+				Name:        "fake-template",
+				Subnetworks: []string{"https://www.googleapis.com/compute/v1/projects/fake-project/regions/europe-west1/subnetworks/fake-subnetwork"},
+			}
+			err := template.ValidateZone("us-central1-a")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("Instance Template 'fake-template' uses subnetwork 'fake-subnetwork' in region 'europe-west1', not usable in zone 'us-central1-a'"))
+		})
+
+		It("returns an error for a malformed zone", func() {
+			template := InstanceTemplate{
+				Name:        "fake-template",
+				Subnetworks: []string{"https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central1/subnetworks/fake-subnetwork"},
+			}
+			err := template.ValidateZone("fake-zone")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Malformed zone 'fake-zone'"))
+		})
+	})
+})
@@ -7,6 +7,7 @@ import (
 type FakeMachineTypeService struct {
 	FindCalled      bool
 	FindFound       bool
+	FindFoundZones  []string
 	FindMachineType machinetype.MachineType
 	FindErr         error
 
@@ -16,6 +17,14 @@ type FakeMachineTypeService struct {
 
 func (d *FakeMachineTypeService) Find(id string, zone string) (machinetype.MachineType, bool, error) {
 	d.FindCalled = true
+	if d.FindFoundZones != nil {
+		for _, foundZone := range d.FindFoundZones {
+			if foundZone == zone {
+				return d.FindMachineType, true, d.FindErr
+			}
+		}
+		return d.FindMachineType, false, d.FindErr
+	}
 	return d.FindMachineType, d.FindFound, d.FindErr
 }
 
@@ -14,6 +14,9 @@ func (n GoogleNetworkService) Find(projectId, id string) (Network, bool, error)
 		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
 			return Network{}, false, nil
 		}
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 {
+			return Network{}, false, bosherr.WrapErrorf(err, "Service account has no access to Google Network '%s' in project '%s'", id, n.projectService.Find(projectId))
+		}
 
 		return Network{}, false, bosherr.WrapErrorf(err, "Failed to find Google Network '%s' in project '%s'", id, projectId)
 	}
@@ -0,0 +1,64 @@
+package network_test
+
+import (
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/project_service"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/network_service"
+)
+
+var _ = Describe("GoogleNetworkService", func() {
+	var (
+		server         *clientfakes.FakeComputeServer
+		networkService GoogleNetworkService
+	)
+
+	BeforeEach(func() {
+		server = clientfakes.NewFakeComputeServer()
+		for _, project := range []string{"fake-network-project", "fake-other-project"} {
+			server.Respond("GET", "/"+project+"/global/networks/fake-network", `{"name": "fake-network", "selfLink": "fake-network-self-link"}`)
+		}
+		server.Handle("GET", "/fake-forbidden-project/global/networks/fake-network", func(w http.ResponseWriter, r *http.Request) {
+			clientfakes.WriteError(w, http.StatusForbidden, "forbidden")
+		})
+
+		networkService = NewGoogleNetworkService(
+			project.NewGoogleProjectService("fake-network-project"),
+			server.ComputeService(),
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Find", func() {
+		It("looks up the network in the network project", func() {
+			network, found, err := networkService.Find("", "fake-network")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(found).To(BeTrue())
+			Expect(network.SelfLink).To(Equal("fake-network-self-link"))
+			Expect(server.Requests()).To(Equal([]string{"GET /fake-network-project/global/networks/fake-network"}))
+		})
+
+		It("prefers the project of the network", func() {
+			_, _, err := networkService.Find("fake-other-project", "fake-network")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(server.Requests()).To(Equal([]string{"GET /fake-other-project/global/networks/fake-network"}))
+		})
+
+		It("returns an error naming the project the service account cannot access", func() {
+			_, _, err := networkService.Find("fake-forbidden-project", "fake-network")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Service account has no access to Google Network 'fake-network' in project 'fake-forbidden-project'"))
+		})
+	})
+})
@@ -0,0 +1,30 @@
+package fakes
+
+import (
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+)
+
+type FakeOperationService struct {
+	WaiterCalled bool
+	WaiterErr    error
+
+	WaiterBCalled bool
+	WaiterBErr    error
+}
+
+func (o *FakeOperationService) Waiter(operation *compute.Operation, zone string, region string) (*compute.Operation, error) {
+	o.WaiterCalled = true
+	if o.WaiterErr != nil {
+		return nil, o.WaiterErr
+	}
+	return operation, nil
+}
+
+func (o *FakeOperationService) WaiterB(operation *computebeta.Operation, zone string, region string) (*computebeta.Operation, error) {
+	o.WaiterBCalled = true
+	if o.WaiterBErr != nil {
+		return nil, o.WaiterBErr
+	}
+	return operation, nil
+}
@@ -1,6 +1,8 @@
 package operation
 
 import (
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 
 	computebeta "google.golang.org/api/compute/v0.beta"
@@ -8,7 +10,12 @@ import (
 )
 
 const googleOperationServiceLogTag = "GoogleOperationService"
-const googleOperationServiceMaxTries = 100
+
+// Timeout bounds how long an operation, or other changes of a resource, are
+// waited for.
+const Timeout = 13 * time.Minute
+
+const googleOperationServiceDefaultPollInterval = 8 * time.Second
 const googleOperationServiceMaxSleepExponent = 3
 const googleOperationReadyStatus = "DONE"
 
@@ -17,6 +24,14 @@ type GoogleOperationService struct {
 	computeService  *compute.Service
 	computeServiceB *computebeta.Service
 	logger          boshlog.Logger
+	pollInterval    time.Duration
+
+	// stuckThreshold is how long an operation may stay at the same progress
+	// before it is reported as stuck. Detection is disabled when it is zero.
+	stuckThreshold time.Duration
+	abortStuck     bool
+
+	timeouts Timeouts
 }
 
 func NewGoogleOperationService(
@@ -24,11 +39,24 @@ func NewGoogleOperationService(
 	computeService *compute.Service,
 	computeServiceB *computebeta.Service,
 	logger boshlog.Logger,
+	pollInterval time.Duration,
+	stuckThreshold time.Duration,
+	abortStuck bool,
+	timeouts Timeouts,
 ) GoogleOperationService {
+	if pollInterval == 0 {
+		pollInterval = googleOperationServiceDefaultPollInterval
+	}
+
 	return GoogleOperationService{
 		project:         project,
 		computeService:  computeService,
 		computeServiceB: computeServiceB,
 		logger:          logger,
+		pollInterval:    pollInterval,
+		stuckThreshold:  stuckThreshold,
+		abortStuck:      abortStuck,
+
+		timeouts: timeouts,
 	}
 }
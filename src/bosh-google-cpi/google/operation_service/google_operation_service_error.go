@@ -2,6 +2,8 @@ package operation
 
 import (
 	"bytes"
+	"fmt"
+	"time"
 
 	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
@@ -29,3 +31,15 @@ func (e GoogleOperationErrorB) Error() string {
 
 	return buf.String()
 }
+
+// StuckOperationError is returned when an operation has not progressed for
+// longer than the stuck operation threshold and stuck operations are aborted.
+type StuckOperationError struct {
+	ID       string
+	Progress int64
+	Duration time.Duration
+}
+
+func (e StuckOperationError) Error() string {
+	return fmt.Sprintf("Google Operation '%s' is stuck at %d%% progress for %v", e.ID, e.Progress, e.Duration)
+}
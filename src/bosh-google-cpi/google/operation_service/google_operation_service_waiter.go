@@ -1,6 +1,7 @@
 package operation
 
 import (
+	"fmt"
 	"math"
 	"time"
 
@@ -12,29 +13,31 @@ import (
 )
 
 func (o GoogleOperationService) Waiter(operation *compute.Operation, zone string, region string) (*compute.Operation, error) {
-	var tries int
 	var err error
-	var opName string
+	opName := operation.Name
 
+	project := o.operationProject(operation.SelfLink)
 	start := time.Now()
-	for tries = 1; tries < googleOperationServiceMaxTries; tries++ {
-		factor := math.Pow(2, math.Min(float64(tries), float64(googleOperationServiceMaxSleepExponent)))
-		wait := time.Duration(factor) * time.Second
+	timeout := o.timeouts.For(operation.OperationType)
+	watch := newProgressWatch(operation.Progress, start)
+	for tries := 0; time.Since(start) < timeout; tries++ {
+		wait := o.pollWait(tries)
 		opName = operation.Name
-		o.logger.Debug(googleOperationServiceLogTag, "Waiting for Google Operation '%s' to be ready, retrying in %v (%d/%d)", opName, wait, tries, googleOperationServiceMaxTries)
+		o.logger.Debug(googleOperationServiceLogTag, "Waiting for Google Operation '%s' to be ready, retrying in %v", opName, wait)
 		time.Sleep(wait)
 
 		if zone == "" {
 			if region == "" {
-				operation, err = o.computeService.GlobalOperations.Get(o.project, opName).Do()
+				operation, err = o.computeService.GlobalOperations.Get(project, opName).Do()
 			} else {
-				operation, err = o.computeService.RegionOperations.Get(o.project, util.ResourceSplitter(region), opName).Do()
+				operation, err = o.computeService.RegionOperations.Get(project, util.ResourceSplitter(region), opName).Do()
 			}
 		} else {
-			operation, err = o.computeService.ZoneOperations.Get(o.project, util.ResourceSplitter(zone), opName).Do()
+			operation, err = o.computeService.ZoneOperations.Get(project, util.ResourceSplitter(zone), opName).Do()
 		}
 
 		if err != nil {
+			opName = o.operationID(project, opName, zone, region)
 			o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' finished with an error: %#v", opName, err)
 			if operation != nil && operation.Error != nil {
 				return nil, bosherr.WrapErrorf(GoogleOperationError(*operation.Error), "Google Operation '%s' finished with an error", opName)
@@ -45,6 +48,7 @@ func (o GoogleOperationService) Waiter(operation *compute.Operation, zone string
 
 		if operation.Status == googleOperationReadyStatus {
 			if operation.Error != nil {
+				opName = o.operationID(project, opName, zone, region)
 				o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' finished with an error: %s", opName, GoogleOperationError(*operation.Error))
 				return nil, bosherr.WrapErrorf(GoogleOperationError(*operation.Error), "Google Operation '%s' finished with an error", opName)
 			}
@@ -52,35 +56,41 @@ func (o GoogleOperationService) Waiter(operation *compute.Operation, zone string
 			o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' is now ready after %v", opName, time.Since(start))
 			return operation, nil
 		}
+
+		if err := o.checkProgress(watch, o.operationID(project, opName, zone, region), operation.Progress); err != nil {
+			return nil, err
+		}
 	}
 
-	return nil, bosherr.Errorf("Timed out waiting for Google Operation '%s' to be ready", opName)
+	return nil, bosherr.Errorf("Timed out after %v waiting for Google Operation '%s' to be ready", timeout, o.operationID(project, opName, zone, region))
 }
 
 func (o GoogleOperationService) WaiterB(operation *computebeta.Operation, zone string, region string) (*computebeta.Operation, error) {
-	var tries int
 	var err error
-	var opName string
+	opName := operation.Name
 
+	project := o.operationProject(operation.SelfLink)
 	start := time.Now()
-	for tries = 1; tries < googleOperationServiceMaxTries; tries++ {
-		factor := math.Pow(2, math.Min(float64(tries), float64(googleOperationServiceMaxSleepExponent)))
-		wait := time.Duration(factor) * time.Second
+	timeout := o.timeouts.For(operation.OperationType)
+	watch := newProgressWatch(operation.Progress, start)
+	for tries := 0; time.Since(start) < timeout; tries++ {
+		wait := o.pollWait(tries)
 		opName = operation.Name
-		o.logger.Debug(googleOperationServiceLogTag, "Waiting for Google Operation '%s' to be ready, retrying in %v (%d/%d)", opName, wait, tries, googleOperationServiceMaxTries)
+		o.logger.Debug(googleOperationServiceLogTag, "Waiting for Google Operation '%s' to be ready, retrying in %v", opName, wait)
 		time.Sleep(wait)
 
 		if zone == "" {
 			if region == "" {
-				operation, err = o.computeServiceB.GlobalOperations.Get(o.project, opName).Do()
+				operation, err = o.computeServiceB.GlobalOperations.Get(project, opName).Do()
 			} else {
-				operation, err = o.computeServiceB.RegionOperations.Get(o.project, util.ResourceSplitter(region), opName).Do()
+				operation, err = o.computeServiceB.RegionOperations.Get(project, util.ResourceSplitter(region), opName).Do()
 			}
 		} else {
-			operation, err = o.computeServiceB.ZoneOperations.Get(o.project, util.ResourceSplitter(zone), opName).Do()
+			operation, err = o.computeServiceB.ZoneOperations.Get(project, util.ResourceSplitter(zone), opName).Do()
 		}
 
 		if err != nil {
+			opName = o.operationID(project, opName, zone, region)
 			o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' finished with an error: %#v", opName, err)
 			if operation != nil && operation.Error != nil {
 				return nil, bosherr.WrapErrorf(GoogleOperationErrorB(*operation.Error), "Google Operation '%s' finished with an error", opName)
@@ -91,6 +101,7 @@ func (o GoogleOperationService) WaiterB(operation *computebeta.Operation, zone s
 
 		if operation.Status == googleOperationReadyStatus {
 			if operation.Error != nil {
+				opName = o.operationID(project, opName, zone, region)
 				o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' finished with an error: %s", opName, GoogleOperationErrorB(*operation.Error))
 				return nil, bosherr.WrapErrorf(GoogleOperationErrorB(*operation.Error), "Google Operation '%s' finished with an error", opName)
 			}
@@ -98,7 +109,83 @@ func (o GoogleOperationService) WaiterB(operation *computebeta.Operation, zone s
 			o.logger.Debug(googleOperationServiceLogTag, "Google Operation '%s' is now ready after %v", opName, time.Since(start))
 			return operation, nil
 		}
+
+		if err := o.checkProgress(watch, o.operationID(project, opName, zone, region), operation.Progress); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, bosherr.Errorf("Timed out after %v waiting for Google Operation '%s' to be ready", timeout, o.operationID(project, opName, zone, region))
+}
+
+// pollWait returns how long to wait before polling an operation again.
+// Operations are polled quickly at first, backing off to the poll interval.
+func (o GoogleOperationService) pollWait(tries int) time.Duration {
+	exponent := googleOperationServiceMaxSleepExponent - math.Min(float64(tries), float64(googleOperationServiceMaxSleepExponent))
+	return time.Duration(float64(o.pollInterval) / math.Pow(2, exponent))
+}
+
+// progressWatch tracks since when an operation has been at the same
+// progress percentage.
+type progressWatch struct {
+	progress int64
+	since    time.Time
+	warned   bool
+}
+
+func newProgressWatch(progress int64, start time.Time) *progressWatch {
+	return &progressWatch{progress: progress, since: start}
+}
+
+// checkProgress warns when an operation has not progressed for longer than
+// the stuck threshold, and fails when stuck operations must be aborted.
+func (o GoogleOperationService) checkProgress(watch *progressWatch, opID string, progress int64) error {
+	if o.stuckThreshold == 0 {
+		return nil
 	}
 
-	return nil, bosherr.Errorf("Timed out waiting for Google Operation '%s' to be ready", opName)
+	if progress != watch.progress {
+		watch.progress = progress
+		watch.since = time.Now()
+		watch.warned = false
+		return nil
+	}
+
+	stuckFor := time.Since(watch.since)
+	if stuckFor <= o.stuckThreshold {
+		return nil
+	}
+
+	if o.abortStuck {
+		return StuckOperationError{ID: opID, Progress: progress, Duration: stuckFor.Truncate(time.Second)}
+	}
+	if !watch.warned {
+		o.logger.Warn(googleOperationServiceLogTag, "Google Operation '%s' has been at %d%% progress for %v, it may be stuck", opID, progress, stuckFor.Truncate(time.Second))
+		watch.warned = true
+	}
+
+	return nil
+}
+
+// operationProject returns the project an operation runs in, from its self
+// link. Resources such as images and firewall rules may live in another
+// project than the instances, and so do their operations.
+func (o GoogleOperationService) operationProject(selfLink string) string {
+	if project := util.ProjectFromURL(selfLink); project != "" {
+		return project
+	}
+	return o.project
+}
+
+// operationID returns the fully-qualified name of an operation, which
+// identifies it in Cloud Logging and includes the zone or region it ran in.
+func (o GoogleOperationService) operationID(project string, opName string, zone string, region string) string {
+	switch {
+	case zone != "":
+		return fmt.Sprintf("projects/%s/zones/%s/operations/%s", project, util.ResourceSplitter(zone), opName)
+	case region != "":
+		return fmt.Sprintf("projects/%s/regions/%s/operations/%s", project, util.ResourceSplitter(region), opName)
+	default:
+		return fmt.Sprintf("projects/%s/global/operations/%s", project, opName)
+	}
 }
@@ -0,0 +1,166 @@
+package operation_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/operation_service"
+)
+
+var _ = Describe("GoogleOperationService", func() {
+	var (
+		server           *clientfakes.FakeComputeServer
+		computeService   *compute.Service
+		computeServiceB  *computebeta.Service
+		operationService GoogleOperationService
+		slowPolls        int
+		polls            []time.Time
+	)
+
+	BeforeEach(func() {
+		slowPolls = 0
+		polls = nil
+		server = clientfakes.NewFakeComputeServer()
+		server.Respond("GET", "/fake-project/zones/fake-zone/operations/fake-stuck-operation", `{"name": "fake-stuck-operation", "status": "RUNNING", "progress": 10}`)
+		server.Handle("GET", "/fake-project/global/operations/fake-polled-operation", func(w http.ResponseWriter, r *http.Request) {
+			polls = append(polls, time.Now())
+			if len(polls) < 6 {
+				fmt.Fprint(w, `{"name": "fake-polled-operation", "status": "RUNNING"}`)
+			} else {
+				fmt.Fprint(w, `{"name": "fake-polled-operation", "status": "DONE"}`)
+			}
+		})
+		server.Handle("GET", "/fake-project/zones/fake-zone/operations/fake-slow-operation", func(w http.ResponseWriter, r *http.Request) {
+			slowPolls++
+			switch slowPolls {
+			case 1:
+				fmt.Fprint(w, `{"name": "fake-slow-operation", "status": "RUNNING", "progress": 10}`)
+			default:
+				fmt.Fprint(w, `{"name": "fake-slow-operation", "status": "DONE", "progress": 100}`)
+			}
+		})
+		server.Respond("GET", "/fake-image-project/global/operations/fake-image-operation", `{"name": "fake-image-operation", "status": "DONE"}`)
+		for _, path := range []string{
+			"/fake-project/zones/fake-zone/operations/fake-operation",
+			"/fake-project/regions/fake-region/operations/fake-operation",
+		} {
+			server.Respond("GET", path, `{"name": "fake-operation", "status": "DONE", "error": {"errors": [{"code": "FAKE_CODE", "message": "fake-operation-error"}]}}`)
+		}
+
+		computeService = server.ComputeService()
+		computeServiceB = server.ComputeBetaService()
+
+		operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewLogger(boshlog.LevelNone), 0, 0, false, Timeouts{})
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Waiter", func() {
+		It("includes the fully-qualified operation name of a failed zonal operation", func() {
+			_, err := operationService.Waiter(&compute.Operation{Name: "fake-operation"}, "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Operation 'projects/fake-project/zones/fake-zone/operations/fake-operation' finished with an error"))
+			Expect(err.Error()).To(ContainSubstring("fake-operation-error"))
+		})
+
+		It("includes the fully-qualified operation name when the operation can not be read", func() {
+			_, err := operationService.Waiter(&compute.Operation{Name: "fake-operation"}, "", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Operation 'projects/fake-project/global/operations/fake-operation' finished with an error"))
+		})
+
+		It("polls the operation in the project of its self link", func() {
+			operation := &compute.Operation{
+				Name:     "fake-image-operation",
+				SelfLink: "https://www.googleapis.com/compute/v1/projects/fake-image-project/global/operations/fake-image-operation",
+			}
+
+			done, err := operationService.Waiter(operation, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(done.Status).To(Equal("DONE"))
+		})
+
+		It("polls quickly at first and then at the configured interval", func() {
+			interval := 400 * time.Millisecond
+			operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewLogger(boshlog.LevelNone), interval, 0, false, Timeouts{})
+
+			start := time.Now()
+			_, err := operationService.Waiter(&compute.Operation{Name: "fake-polled-operation"}, "", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(polls).To(HaveLen(6))
+
+			waits := []time.Duration{interval / 8, interval / 4, interval / 2, interval, interval, interval}
+			last := start
+			for i, poll := range polls {
+				Expect(poll.Sub(last)).To(BeNumerically("~", waits[i], 100*time.Millisecond))
+				Expect(poll.Sub(last)).To(BeNumerically(">=", waits[i]))
+				last = poll
+			}
+		})
+
+		It("aborts operations stuck at the same progress when configured to", func() {
+			operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewLogger(boshlog.LevelNone), 0, 500*time.Millisecond, true, Timeouts{})
+
+			_, err := operationService.Waiter(&compute.Operation{Name: "fake-stuck-operation", Progress: 10}, "fake-zone", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err).To(BeAssignableToTypeOf(StuckOperationError{}))
+			Expect(err.Error()).To(ContainSubstring("Google Operation 'projects/fake-project/zones/fake-zone/operations/fake-stuck-operation' is stuck at 10% progress"))
+		})
+
+		It("does not abort operations that make progress", func() {
+			operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewLogger(boshlog.LevelNone), 0, 500*time.Millisecond, true, Timeouts{})
+
+			operation, err := operationService.Waiter(&compute.Operation{Name: "fake-slow-operation"}, "fake-zone", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operation.Status).To(Equal("DONE"))
+		})
+
+		It("waits for operations up to the timeout of their type", func() {
+			operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewLogger(boshlog.LevelNone), 0, 0, false, Timeouts{
+				Operation: time.Minute,
+				Create:    time.Nanosecond,
+			})
+
+			_, err := operationService.Waiter(&compute.Operation{Name: "fake-slow-operation", OperationType: OperationTypeInsert}, "fake-zone", "")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Timed out after 1ns waiting for Google Operation 'projects/fake-project/zones/fake-zone/operations/fake-slow-operation' to be ready"))
+			Expect(slowPolls).To(Equal(0))
+
+			operation, err := operationService.Waiter(&compute.Operation{Name: "fake-slow-operation", OperationType: OperationTypeDelete}, "fake-zone", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operation.Status).To(Equal("DONE"))
+		})
+	})
+
+	Describe("WaiterB", func() {
+		It("includes the fully-qualified operation name of a failed regional operation", func() {
+			_, err := operationService.WaiterB(&computebeta.Operation{Name: "fake-operation"}, "", "fake-region")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Google Operation 'projects/fake-project/regions/fake-region/operations/fake-operation' finished with an error"))
+			Expect(err.Error()).To(ContainSubstring("fake-operation-error"))
+		})
+
+		It("warns about operations stuck at the same progress and keeps waiting", func() {
+			logs := &bytes.Buffer{}
+			operationService = NewGoogleOperationService("fake-project", computeService, computeServiceB, boshlog.NewWriterLogger(boshlog.LevelWarn, logs), 0, 500*time.Millisecond, false, Timeouts{})
+
+			operation, err := operationService.WaiterB(&computebeta.Operation{Name: "fake-slow-operation", Progress: 10}, "fake-zone", "")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(operation.Status).To(Equal("DONE"))
+			Expect(logs.String()).To(ContainSubstring("Google Operation 'projects/fake-project/zones/fake-zone/operations/fake-slow-operation' has been at 10% progress"))
+		})
+	})
+})
@@ -0,0 +1,55 @@
+package operation
+
+import (
+	"time"
+)
+
+// Operation types, as Google Compute Engine reports them on operations, that
+// timeouts are looked up by.
+const (
+	OperationTypeInsert         = "insert"
+	OperationTypeDelete         = "delete"
+	OperationTypeAttachDisk     = "attachDisk"
+	OperationTypeDetachDisk     = "detachDisk"
+	OperationTypeCreateSnapshot = "createSnapshot"
+	OperationTypeReset          = "reset"
+	OperationTypeStart          = "start"
+)
+
+// Timeouts bound how long operations are waited for, by operation type.
+// Types without a timeout of their own are bound by Operation, and Operation
+// defaults to Timeout.
+type Timeouts struct {
+	Operation time.Duration
+	Create    time.Duration
+	Delete    time.Duration
+	Attach    time.Duration
+	Snapshot  time.Duration
+}
+
+// For returns the timeout of operations of operationType, one of the
+// OperationType constants or any other type Google Compute Engine reports.
+func (t Timeouts) For(operationType string) time.Duration {
+	var timeout time.Duration
+	switch operationType {
+	case OperationTypeInsert:
+		timeout = t.Create
+	case OperationTypeDelete:
+		timeout = t.Delete
+	case OperationTypeAttachDisk, OperationTypeDetachDisk:
+		timeout = t.Attach
+	case OperationTypeCreateSnapshot:
+		timeout = t.Snapshot
+	case OperationTypeReset, OperationTypeStart:
+		// Reboots, through a reset or a start, have no timeout of their own
+		timeout = t.Operation
+	}
+
+	if timeout == 0 {
+		timeout = t.Operation
+	}
+	if timeout == 0 {
+		timeout = Timeout
+	}
+	return timeout
+}
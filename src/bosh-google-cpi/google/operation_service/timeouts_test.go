@@ -0,0 +1,47 @@
+package operation_test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/operation_service"
+)
+
+var _ = Describe("Timeouts", func() {
+	timeouts := Timeouts{
+		Operation: 5 * time.Minute,
+		Create:    20 * time.Minute,
+		Delete:    2 * time.Minute,
+		Attach:    3 * time.Minute,
+		Snapshot:  30 * time.Minute,
+	}
+
+	It("applies the timeout of each operation type", func() {
+		Expect(timeouts.For(OperationTypeInsert)).To(Equal(20 * time.Minute))
+		Expect(timeouts.For(OperationTypeDelete)).To(Equal(2 * time.Minute))
+		Expect(timeouts.For(OperationTypeAttachDisk)).To(Equal(3 * time.Minute))
+		Expect(timeouts.For(OperationTypeDetachDisk)).To(Equal(3 * time.Minute))
+		Expect(timeouts.For(OperationTypeCreateSnapshot)).To(Equal(30 * time.Minute))
+	})
+
+	It("applies the operation timeout to reboots", func() {
+		Expect(timeouts.For(OperationTypeReset)).To(Equal(5 * time.Minute))
+		Expect(timeouts.For(OperationTypeStart)).To(Equal(5 * time.Minute))
+	})
+
+	It("applies the operation timeout to the other operation types", func() {
+		Expect(timeouts.For("setMetadata")).To(Equal(5 * time.Minute))
+		Expect(timeouts.For("")).To(Equal(5 * time.Minute))
+	})
+
+	It("falls back to the operation timeout when an operation type has none", func() {
+		Expect(Timeouts{Operation: 5 * time.Minute}.For(OperationTypeInsert)).To(Equal(5 * time.Minute))
+	})
+
+	It("falls back to the default timeout when none is set", func() {
+		Expect(Timeouts{}.For(OperationTypeInsert)).To(Equal(Timeout))
+		Expect(Timeouts{}.For("setLabels")).To(Equal(Timeout))
+	})
+})
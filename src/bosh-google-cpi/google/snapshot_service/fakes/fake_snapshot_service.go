@@ -11,26 +11,49 @@ type FakeSnapshotService struct {
 	CreateDiskID      string
 	CreateDescription string
 	CreateZone        string
+	CreateLabels      map[string]string
+
+	CreateInRegionCalled bool
+	CreateInRegionDiskID string
+	CreateInRegionRegion string
+	CreateInRegionLabels map[string]string
 
 	DeleteCalled bool
 	DeleteErr    error
+	DeleteIDs    []string
 
 	FindCalled   bool
 	FindFound    bool
 	FindSnapshot snapshot.Snapshot
 	FindErr      error
+
+	FindByLabelsCalled    bool
+	FindByLabelsLabels    map[string]string
+	FindByLabelsSnapshots []snapshot.Snapshot
+	FindByLabelsErr       error
 }
 
-func (s *FakeSnapshotService) Create(diskID string, description string, zone string) (string, error) {
+func (s *FakeSnapshotService) Create(diskID string, description string, zone string, labels map[string]string) (string, error) {
 	s.CreateCalled = true
+	s.CreateLabels = labels
 	s.CreateDiskID = diskID
 	s.CreateDescription = description
 	s.CreateZone = zone
 	return s.CreateID, s.CreateErr
 }
 
+func (s *FakeSnapshotService) CreateInRegion(diskID string, description string, region string, labels map[string]string) (string, error) {
+	s.CreateInRegionCalled = true
+	s.CreateInRegionDiskID = diskID
+	s.CreateInRegionRegion = region
+	s.CreateInRegionLabels = labels
+	s.CreateDescription = description
+	return s.CreateID, s.CreateErr
+}
+
 func (s *FakeSnapshotService) Delete(id string) error {
 	s.DeleteCalled = true
+	s.DeleteIDs = append(s.DeleteIDs, id)
 	return s.DeleteErr
 }
 
@@ -38,3 +61,9 @@ func (s *FakeSnapshotService) Find(id string) (snapshot.Snapshot, bool, error) {
 	s.FindCalled = true
 	return s.FindSnapshot, s.FindFound, s.FindErr
 }
+
+func (s *FakeSnapshotService) FindByLabels(labels map[string]string) ([]snapshot.Snapshot, error) {
+	s.FindByLabelsCalled = true
+	s.FindByLabelsLabels = labels
+	return s.FindByLabelsSnapshots, s.FindByLabelsErr
+}
@@ -1,10 +1,13 @@
 package snapshot
 
 import (
+	"time"
+
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	boshuuid "github.com/cloudfoundry/bosh-utils/uuid"
 
 	"bosh-google-cpi/google/operation_service"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
 )
 
@@ -14,26 +17,48 @@ const googleSnapshotDescription = "Snapshot managed by BOSH"
 const googleSnapshotReadyStatus = "READY"
 const googleSnapshotFailedStatus = "FAILED"
 
+const googleSnapshotReadyPollInterval = time.Second
+
 type GoogleSnapshotService struct {
 	project          string
 	computeService   *compute.Service
+	computeServiceB  *computebeta.Service
 	operationService operation.Service
 	uuidGen          boshuuid.Generator
 	logger           boshlog.Logger
+	guestFlush       bool
+
+	// kmsKeyName is the Cloud KMS key snapshots are encrypted with, through
+	// the beta API. Snapshots are encrypted like their disk when it is empty.
+	kmsKeyName string
+
+	// readyTimeout bounds how long Create waits for new snapshots to be
+	// ready. Snapshots are uploaded after the snapshot operation is done,
+	// which can take a while for large disks.
+	readyTimeout time.Duration
 }
 
 func NewGoogleSnapshotService(
 	project string,
 	computeService *compute.Service,
+	computeServiceB *computebeta.Service,
 	operationService operation.Service,
 	uuidGen boshuuid.Generator,
 	logger boshlog.Logger,
+	guestFlush bool,
+	kmsKeyName string,
+	readyTimeout time.Duration,
 ) GoogleSnapshotService {
 	return GoogleSnapshotService{
 		project:          project,
 		computeService:   computeService,
+		computeServiceB:  computeServiceB,
 		operationService: operationService,
 		uuidGen:          uuidGen,
 		logger:           logger,
+		guestFlush:       guestFlush,
+
+		kmsKeyName:   kmsKeyName,
+		readyTimeout: readyTimeout,
 	}
 }
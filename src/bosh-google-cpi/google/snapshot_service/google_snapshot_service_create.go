@@ -2,14 +2,34 @@ package snapshot
 
 import (
 	"fmt"
+	"time"
 
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/util"
+	computebeta "google.golang.org/api/compute/v0.beta"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
-func (s GoogleSnapshotService) Create(diskID string, description string, zone string) (string, error) {
+func (s GoogleSnapshotService) Create(diskID string, description string, zone string, labels map[string]string) (string, error) {
+	return s.create(description, labels, func(snapshot *compute.Snapshot) error {
+		if s.kmsKeyName != "" {
+			return s.insertEncrypted(diskID, zone, snapshot)
+		}
+		return s.insert(diskID, zone, snapshot)
+	})
+}
+
+// CreateInRegion snapshots a regional disk like Create. Regional disks are
+// only exposed by the beta API, which does not support guest flush for them.
+func (s GoogleSnapshotService) CreateInRegion(diskID string, description string, region string, labels map[string]string) (string, error) {
+	return s.create(description, labels, func(snapshot *compute.Snapshot) error {
+		return s.insertInRegion(diskID, region, snapshot)
+	})
+}
+
+func (s GoogleSnapshotService) create(description string, labels map[string]string, insert func(*compute.Snapshot) error) (string, error) {
 	uuidStr, err := s.uuidGen.Generate()
 	if err != nil {
 		return "", bosherr.WrapErrorf(err, "Generating random Google Snapshot name")
@@ -22,15 +42,16 @@ func (s GoogleSnapshotService) Create(diskID string, description string, zone st
 	snapshot := &compute.Snapshot{
 		Name:        fmt.Sprintf("%s-%s", googleSnapshotNamePrefix, uuidStr),
 		Description: description,
+		Labels:      labels,
 	}
 
-	s.logger.Debug(googleSnapshotServiceLogTag, "Creating Google Snapshot with params: %#v", snapshot)
-	operation, err := s.computeService.Disks.CreateSnapshot(s.project, util.ResourceSplitter(zone), diskID, snapshot).Do()
-	if err != nil {
+	if err = insert(snapshot); err != nil {
 		return "", bosherr.WrapErrorf(err, "Failed to create Google Snapshot")
 	}
 
-	if _, err = s.operationService.Waiter(operation, zone, ""); err != nil {
+	// The snapshot operation is done before the snapshot is uploaded, and
+	// disks can only be created from it once it is ready
+	if err = s.waitForReady(snapshot.Name); err != nil {
 		s.cleanUp(snapshot.Name)
 		return "", bosherr.WrapErrorf(err, "Failed to create Google Snapshot")
 	}
@@ -38,8 +59,132 @@ func (s GoogleSnapshotService) Create(diskID string, description string, zone st
 	return snapshot.Name, nil
 }
 
+// insert snapshots the disk and waits for the snapshot operation. Disks are
+// snapshotted while attached, the guest is asked to flush its buffers first
+// when guest flush is enabled.
+func (s GoogleSnapshotService) insert(diskID string, zone string, snapshot *compute.Snapshot) error {
+	s.logger.Debug(googleSnapshotServiceLogTag, "Creating Google Snapshot with params: %#v", snapshot)
+	createSnapshotCall := s.computeService.Disks.CreateSnapshot(s.project, util.ResourceSplitter(zone), diskID, snapshot)
+	if s.guestFlush {
+		createSnapshotCall = createSnapshotCall.GuestFlush(true)
+	}
+	operation, err := createSnapshotCall.Do()
+	if err != nil {
+		return err
+	}
+
+	if _, err = s.operationService.Waiter(operation, zone, ""); err != nil {
+		s.cleanUp(snapshot.Name)
+		return err
+	}
+
+	return nil
+}
+
+// insertEncrypted is insert with the snapshot encrypted with the snapshot
+// KMS key. The KMS key of the disk is passed along when it has one.
+func (s GoogleSnapshotService) insertEncrypted(diskID string, zone string, snapshot *compute.Snapshot) error {
+	disk, err := s.computeServiceB.Disks.Get(s.project, util.ResourceSplitter(zone), diskID).Do()
+	if err != nil {
+		return bosherr.WrapErrorf(err, "Failed to find Google Disk '%s'", diskID)
+	}
+
+	snapshotB := &computebeta.Snapshot{
+		Name:                  snapshot.Name,
+		Description:           snapshot.Description,
+		Labels:                snapshot.Labels,
+		SnapshotEncryptionKey: &computebeta.CustomerEncryptionKey{KmsKeyName: s.kmsKeyName},
+	}
+	if key := disk.DiskEncryptionKey; key != nil && key.KmsKeyName != "" {
+		snapshotB.SourceDiskEncryptionKey = &computebeta.CustomerEncryptionKey{KmsKeyName: key.KmsKeyName}
+	}
+
+	s.logger.Debug(googleSnapshotServiceLogTag, "Creating Google Snapshot with params: %#v", snapshotB)
+	createSnapshotCall := s.computeServiceB.Disks.CreateSnapshot(s.project, util.ResourceSplitter(zone), diskID, snapshotB)
+	if s.guestFlush {
+		createSnapshotCall = createSnapshotCall.GuestFlush(true)
+	}
+	operation, err := createSnapshotCall.Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 {
+			return bosherr.WrapErrorf(err, "Compute Engine can not use KMS key '%s', grant its service agent the Cloud KMS CryptoKey Encrypter/Decrypter role on the key", s.kmsKeyName)
+		}
+		return err
+	}
+
+	if _, err = s.operationService.WaiterB(operation, zone, ""); err != nil {
+		s.cleanUp(snapshot.Name)
+		return err
+	}
+
+	return nil
+}
+
+// insertInRegion snapshots a regional disk through the beta API, encrypted
+// with the snapshot KMS key when there is one.
+func (s GoogleSnapshotService) insertInRegion(diskID string, region string, snapshot *compute.Snapshot) error {
+	snapshotB := &computebeta.Snapshot{
+		Name:        snapshot.Name,
+		Description: snapshot.Description,
+		Labels:      snapshot.Labels,
+	}
+	if s.kmsKeyName != "" {
+		disk, err := s.computeServiceB.RegionDisks.Get(s.project, util.ResourceSplitter(region), diskID).Do()
+		if err != nil {
+			return bosherr.WrapErrorf(err, "Failed to find Google Disk '%s'", diskID)
+		}
+		snapshotB.SnapshotEncryptionKey = &computebeta.CustomerEncryptionKey{KmsKeyName: s.kmsKeyName}
+		if key := disk.DiskEncryptionKey; key != nil && key.KmsKeyName != "" {
+			snapshotB.SourceDiskEncryptionKey = &computebeta.CustomerEncryptionKey{KmsKeyName: key.KmsKeyName}
+		}
+	}
+
+	s.logger.Debug(googleSnapshotServiceLogTag, "Creating Google Snapshot with params: %#v", snapshotB)
+	operation, err := s.computeServiceB.RegionDisks.CreateSnapshot(s.project, util.ResourceSplitter(region), diskID, snapshotB).Do()
+	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 && s.kmsKeyName != "" {
+			return bosherr.WrapErrorf(err, "Compute Engine can not use KMS key '%s', grant its service agent the Cloud KMS CryptoKey Encrypter/Decrypter role on the key", s.kmsKeyName)
+		}
+		return err
+	}
+
+	if _, err = s.operationService.WaiterB(operation, "", region); err != nil {
+		s.cleanUp(snapshot.Name)
+		return err
+	}
+
+	return nil
+}
+
+func (s GoogleSnapshotService) waitForReady(id string) error {
+	deadline := time.Now().Add(s.readyTimeout)
+	for {
+		snapshot, found, err := s.Find(id)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return bosherr.Errorf("Google Snapshot '%s' does not exist", id)
+		}
+
+		switch snapshot.Status {
+		case googleSnapshotReadyStatus:
+			return nil
+		case googleSnapshotFailedStatus:
+			return bosherr.Errorf("Google Snapshot '%s' failed to be created", id)
+		}
+
+		if time.Now().After(deadline) {
+			return bosherr.Errorf("Timed out after %v waiting for Google Snapshot '%s' to be ready, status is '%s'", s.readyTimeout, id, snapshot.Status)
+		}
+
+		s.logger.Debug(googleSnapshotServiceLogTag, "Google Snapshot '%s' is '%s', waiting for it to be ready", id, snapshot.Status)
+		time.Sleep(googleSnapshotReadyPollInterval)
+	}
+}
+
 func (s GoogleSnapshotService) cleanUp(id string) {
-	if err := s.Delete(id); err != nil {
-		s.logger.Debug(googleSnapshotServiceLogTag, "Failed cleaning up Google Snapshot '%s': %#v", id, err)
+	if err := s.delete(id); err != nil {
+		s.logger.Warn(googleSnapshotServiceLogTag, "Failed cleaning up Google Snapshot '%s', it has to be deleted manually: %#v", id, err)
 	}
 }
@@ -0,0 +1,219 @@
+package snapshot_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+	computebeta "google.golang.org/api/compute/v0.beta"
+	"google.golang.org/api/compute/v1"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("GoogleSnapshotService Create", func() {
+	const snapshotPath = "/fake-project/global/snapshots/snapshot-fake-uuid"
+
+	var (
+		server     *clientfakes.FakeComputeServer
+		requests   []string
+		statuses   []string
+		guestFlush string
+		inserted   compute.Snapshot
+		insertedB  computebeta.Snapshot
+		diskKey    string
+		insertCode int
+
+		readyTimeout time.Duration
+
+		computeService  *compute.Service
+		computeServiceB *computebeta.Service
+		snapshotService GoogleSnapshotService
+	)
+
+	newSnapshotService := func(guestFlush bool, kmsKeyName string) GoogleSnapshotService {
+		return NewGoogleSnapshotService(
+			"fake-project",
+			computeService,
+			computeServiceB,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{GeneratedUUID: "fake-uuid"},
+			boshlog.NewLogger(boshlog.LevelNone),
+			guestFlush,
+			kmsKeyName,
+			readyTimeout,
+		)
+	}
+
+	BeforeEach(func() {
+		requests = nil
+		guestFlush = ""
+		diskKey = ""
+		insertCode = http.StatusOK
+		statuses = []string{"UPLOADING", "READY"}
+		readyTimeout = time.Minute
+		server = clientfakes.NewFakeComputeServer()
+		for _, diskPath := range []string{"/fake-project/zones/fake-zone/disks/fake-disk", "/fake-project/regions/fake-region/disks/fake-disk"} {
+			server.Handle("POST", diskPath+"/createSnapshot", func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, "SNAPSHOT")
+				guestFlush = r.URL.Query().Get("guestFlush")
+				body, _ := ioutil.ReadAll(r.Body)
+				inserted = compute.Snapshot{}
+				insertedB = computebeta.Snapshot{}
+				Expect(json.Unmarshal(body, &inserted)).To(Succeed())
+				Expect(json.Unmarshal(body, &insertedB)).To(Succeed())
+				if insertCode != http.StatusOK {
+					clientfakes.WriteError(w, insertCode, "fake-error")
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+			})
+			server.Handle("GET", diskPath, func(w http.ResponseWriter, r *http.Request) {
+				requests = append(requests, "DISK")
+				if diskKey != "" {
+					fmt.Fprintf(w, `{"name": "fake-disk", "diskEncryptionKey": {"kmsKeyName": "%s"}}`, diskKey)
+					return
+				}
+				fmt.Fprint(w, `{"name": "fake-disk"}`)
+			})
+		}
+		server.Handle("GET", snapshotPath, func(w http.ResponseWriter, r *http.Request) {
+			status := statuses[0]
+			if len(statuses) > 1 {
+				statuses = statuses[1:]
+			}
+			requests = append(requests, status)
+			fmt.Fprintf(w, `{"name": "snapshot-fake-uuid", "status": "%s"}`, status)
+		})
+		server.Handle("DELETE", snapshotPath, func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, "DELETE")
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		computeService = server.ComputeService()
+		computeServiceB = server.ComputeBetaService()
+
+		snapshotService = newSnapshotService(false, "")
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("waits for the global snapshot to be ready", func() {
+		snapshotID, err := snapshotService.Create("fake-disk", "", "https://www.googleapis.com/compute/v1/projects/fake-project/zones/fake-zone", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(snapshotID).To(Equal("snapshot-fake-uuid"))
+		Expect(requests).To(Equal([]string{"SNAPSHOT", "UPLOADING", "READY"}))
+		Expect(guestFlush).To(BeEmpty())
+	})
+
+	It("snapshots the attached disk in place, asking the guest to flush when enabled", func() {
+		snapshotService = newSnapshotService(true, "")
+
+		_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(guestFlush).To(Equal("true"))
+		// Only the disk snapshot and the snapshot itself are requested, the
+		// disk is never detached from its instance
+		Expect(requests).To(Equal([]string{"SNAPSHOT", "UPLOADING", "READY"}))
+	})
+
+	It("labels the snapshot", func() {
+		labels := map[string]string{DiskLabelKey: "fake-disk", VMLabelKey: "fake-vm"}
+
+		_, err := snapshotService.Create("fake-disk", "fake-description", "fake-zone", labels)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(inserted.Description).To(Equal("fake-description"))
+		Expect(inserted.Labels).To(Equal(labels))
+	})
+
+	Context("with a snapshot KMS key", func() {
+		const kmsKeyName = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-snapshot-key"
+
+		BeforeEach(func() {
+			snapshotService = newSnapshotService(false, kmsKeyName)
+		})
+
+		It("encrypts the snapshot with the KMS key", func() {
+			_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"DISK", "SNAPSHOT", "UPLOADING", "READY"}))
+			Expect(insertedB.SnapshotEncryptionKey.KmsKeyName).To(Equal(kmsKeyName))
+			Expect(insertedB.SourceDiskEncryptionKey).To(BeNil())
+		})
+
+		It("passes the KMS key of the disk along", func() {
+			diskKey = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-disk-key"
+
+			_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(insertedB.SnapshotEncryptionKey.KmsKeyName).To(Equal(kmsKeyName))
+			Expect(insertedB.SourceDiskEncryptionKey.KmsKeyName).To(Equal(diskKey))
+		})
+
+		It("explains how to grant access to the KMS key when it is refused", func() {
+			insertCode = http.StatusForbidden
+
+			_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Compute Engine can not use KMS key '" + kmsKeyName + "'"))
+		})
+	})
+
+	Describe("CreateInRegion", func() {
+		It("snapshots the regional disk and waits for the snapshot to be ready", func() {
+			labels := map[string]string{DiskLabelKey: "fake-disk"}
+
+			snapshotID, err := snapshotService.CreateInRegion("fake-disk", "fake-description", "fake-region", labels)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(snapshotID).To(Equal("snapshot-fake-uuid"))
+			Expect(requests).To(Equal([]string{"SNAPSHOT", "UPLOADING", "READY"}))
+			Expect(insertedB.Description).To(Equal("fake-description"))
+			Expect(insertedB.Labels).To(Equal(labels))
+			Expect(insertedB.SnapshotEncryptionKey).To(BeNil())
+		})
+
+		It("encrypts the snapshot with the KMS key, passing the KMS key of the disk along", func() {
+			const kmsKeyName = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-snapshot-key"
+			diskKey = "projects/fake-project/locations/global/keyRings/fake-ring/cryptoKeys/fake-disk-key"
+			snapshotService = newSnapshotService(false, kmsKeyName)
+
+			_, err := snapshotService.CreateInRegion("fake-disk", "", "fake-region", nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(requests).To(Equal([]string{"DISK", "SNAPSHOT", "UPLOADING", "READY"}))
+			Expect(insertedB.SnapshotEncryptionKey.KmsKeyName).To(Equal(kmsKeyName))
+			Expect(insertedB.SourceDiskEncryptionKey.KmsKeyName).To(Equal(diskKey))
+		})
+	})
+
+	It("deletes the snapshot if it fails to be created", func() {
+		statuses = []string{"FAILED"}
+
+		_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Google Snapshot 'snapshot-fake-uuid' failed to be created"))
+		Expect(requests).To(ContainElement("DELETE"))
+	})
+
+	It("deletes the snapshot if it is not ready within the ready timeout", func() {
+		statuses = []string{"UPLOADING"}
+		readyTimeout = 0
+		snapshotService = newSnapshotService(false, "")
+
+		_, err := snapshotService.Create("fake-disk", "", "fake-zone", nil)
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Timed out after 0s waiting for Google Snapshot 'snapshot-fake-uuid' to be ready, status is 'UPLOADING'"))
+		Expect(requests).To(ContainElement("DELETE"))
+	})
+})
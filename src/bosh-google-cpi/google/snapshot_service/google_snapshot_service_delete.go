@@ -2,6 +2,7 @@ package snapshot
 
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+	"google.golang.org/api/googleapi"
 )
 
 func (s GoogleSnapshotService) Delete(id string) error {
@@ -9,17 +10,30 @@ func (s GoogleSnapshotService) Delete(id string) error {
 	if err != nil {
 		return err
 	}
+	// The snapshot is already gone, retried deletes succeed
 	if !found {
-		return bosherr.WrapErrorf(err, "Google Snapshot '%s' does not exists", id)
+		s.logger.Info(googleSnapshotServiceLogTag, "Google Snapshot '%s' does not exist, it may have already been deleted", id)
+		return nil
 	}
 
 	if snapshot.Status != googleSnapshotReadyStatus && snapshot.Status != googleSnapshotFailedStatus {
-		return bosherr.WrapErrorf(err, "Cannot delete Google Snapshot '%s', status is '%s'", id, snapshot.Status)
+		return bosherr.Errorf("Cannot delete Google Snapshot '%s', status is '%s'", id, snapshot.Status)
 	}
 
+	return s.delete(id)
+}
+
+// delete deletes the snapshot whatever its status, which lets snapshots that
+// never became ready be cleaned up.
+func (s GoogleSnapshotService) delete(id string) error {
 	s.logger.Debug(googleSnapshotServiceLogTag, "Deleting Google Snapshot '%s'", id)
 	operation, err := s.computeService.Snapshots.Delete(s.project, id).Do()
 	if err != nil {
+		// The snapshot was deleted since it was found
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			s.logger.Info(googleSnapshotServiceLogTag, "Google Snapshot '%s' does not exist, it may have already been deleted", id)
+			return nil
+		}
 		return bosherr.WrapErrorf(err, "Failed to delete Google Snapshot '%s'", id)
 	}
 
@@ -0,0 +1,106 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("GoogleSnapshotService Delete", func() {
+	const snapshotPath = "/fake-project/global/snapshots/fake-snapshot"
+
+	var (
+		server       *clientfakes.FakeComputeServer
+		getStatus    int
+		status       string
+		deleteStatus int
+		deleted      bool
+
+		snapshotService GoogleSnapshotService
+	)
+
+	BeforeEach(func() {
+		getStatus = http.StatusOK
+		status = "READY"
+		deleteStatus = http.StatusOK
+		deleted = false
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", snapshotPath, func(w http.ResponseWriter, r *http.Request) {
+			if getStatus != http.StatusOK {
+				clientfakes.WriteError(w, getStatus, "not found")
+				return
+			}
+			fmt.Fprintf(w, `{"name": "fake-snapshot", "status": "%s"}`, status)
+		})
+		server.Handle("DELETE", snapshotPath, func(w http.ResponseWriter, r *http.Request) {
+			deleted = true
+			if deleteStatus != http.StatusOK {
+				clientfakes.WriteError(w, deleteStatus, "fake-error")
+				return
+			}
+			fmt.Fprint(w, `{"name": "fake-operation", "status": "DONE"}`)
+		})
+
+		snapshotService = NewGoogleSnapshotService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("deletes the snapshot", func() {
+		Expect(snapshotService.Delete("fake-snapshot")).To(Succeed())
+		Expect(deleted).To(BeTrue())
+	})
+
+	It("succeeds if the snapshot does not exist", func() {
+		getStatus = http.StatusNotFound
+
+		Expect(snapshotService.Delete("fake-snapshot")).To(Succeed())
+		Expect(deleted).To(BeFalse())
+	})
+
+	It("succeeds if the snapshot is deleted since it was found", func() {
+		deleteStatus = http.StatusNotFound
+
+		Expect(snapshotService.Delete("fake-snapshot")).To(Succeed())
+		Expect(deleted).To(BeTrue())
+	})
+
+	It("does not delete a snapshot that is still being created", func() {
+		status = "UPLOADING"
+
+		err := snapshotService.Delete("fake-snapshot")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(Equal("Cannot delete Google Snapshot 'fake-snapshot', status is 'UPLOADING'"))
+		Expect(deleted).To(BeFalse())
+	})
+
+	It("returns other errors", func() {
+		deleteStatus = http.StatusForbidden
+
+		err := snapshotService.Delete("fake-snapshot")
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("Failed to delete Google Snapshot 'fake-snapshot'"))
+	})
+})
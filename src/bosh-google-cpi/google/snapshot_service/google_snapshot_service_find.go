@@ -3,6 +3,7 @@ package snapshot
 import (
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
+	"google.golang.org/api/compute/v1"
 	"google.golang.org/api/googleapi"
 )
 
@@ -17,10 +18,17 @@ func (s GoogleSnapshotService) Find(id string) (Snapshot, bool, error) {
 		return Snapshot{}, false, bosherr.WrapErrorf(err, "Failed to find Google Snapshot '%s'", id)
 	}
 
-	snapshot := Snapshot{
+	return newSnapshot(snapshotItem), true, nil
+}
+
+func newSnapshot(snapshotItem *compute.Snapshot) Snapshot {
+	return Snapshot{
 		Name:     snapshotItem.Name,
 		SelfLink: snapshotItem.SelfLink,
 		Status:   snapshotItem.Status,
+		Labels:   snapshotItem.Labels,
+
+		CreationTimestamp: snapshotItem.CreationTimestamp,
+		DiskSizeGb:        snapshotItem.DiskSizeGb,
 	}
-	return snapshot, true, nil
 }
@@ -0,0 +1,35 @@
+package snapshot
+
+import (
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+// FindByLabels finds the snapshots carrying all the labels.
+func (s GoogleSnapshotService) FindByLabels(labels map[string]string) ([]Snapshot, error) {
+	var found []Snapshot
+
+	s.logger.Debug(googleSnapshotServiceLogTag, "Finding Google Snapshots labelled '%v'", labels)
+	call := s.computeService.Snapshots.List(s.project).Filter(util.LabelsFilter(labels))
+	for {
+		snapshots, err := call.Do()
+		if err != nil {
+			return nil, bosherr.WrapErrorf(err, "Failed to find Google Snapshots labelled '%v'", labels)
+		}
+
+		for _, snapshotItem := range snapshots.Items {
+			// The filter is applied by the API, this only guards against
+			// filters it would ignore
+			if !util.HasLabels(snapshotItem.Labels, labels) {
+				continue
+			}
+			found = append(found, newSnapshot(snapshotItem))
+		}
+
+		if snapshots.NextPageToken == "" {
+			return found, nil
+		}
+		call = call.PageToken(snapshots.NextPageToken)
+	}
+}
@@ -0,0 +1,71 @@
+package snapshot_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+	fakeuuid "github.com/cloudfoundry/bosh-utils/uuid/fakes"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	operationfakes "bosh-google-cpi/google/operation_service/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/snapshot_service"
+)
+
+var _ = Describe("GoogleSnapshotService FindByLabels", func() {
+	var (
+		server  *clientfakes.FakeComputeServer
+		filters []string
+
+		snapshotService GoogleSnapshotService
+	)
+
+	BeforeEach(func() {
+		filters = nil
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/global/snapshots", func(w http.ResponseWriter, r *http.Request) {
+			filters = append(filters, r.URL.Query().Get("filter"))
+			if r.URL.Query().Get("pageToken") == "" {
+				fmt.Fprint(w, `{"items": [
+					{"name": "fake-managed-snapshot", "labels": {"director": "fake-director"}, "creationTimestamp": "fake-timestamp"},
+					{"name": "fake-unlabelled-snapshot"}
+				], "nextPageToken": "fake-page-token"}`)
+				return
+			}
+			fmt.Fprint(w, `{"items": [
+				{"name": "fake-other-managed-snapshot", "labels": {"director": "fake-director"}},
+				{"name": "fake-other-director-snapshot", "labels": {"director": "fake-other-director"}}
+			]}`)
+		})
+
+		snapshotService = NewGoogleSnapshotService(
+			"fake-project",
+			server.ComputeService(),
+			nil,
+			&operationfakes.FakeOperationService{},
+			&fakeuuid.FakeGenerator{},
+			boshlog.NewLogger(boshlog.LevelNone),
+			false,
+			"",
+			0,
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("returns the snapshots of every page carrying the labels", func() {
+		snapshots, err := snapshotService.FindByLabels(map[string]string{"director": "fake-director"})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(filters).To(Equal([]string{"(labels.director eq fake-director)", "(labels.director eq fake-director)"}))
+		Expect(snapshots).To(HaveLen(2))
+		Expect(snapshots[0].Name).To(Equal("fake-managed-snapshot"))
+		Expect(snapshots[0].CreationTimestamp).To(Equal("fake-timestamp"))
+		Expect(snapshots[1].Name).To(Equal("fake-other-managed-snapshot"))
+	})
+})
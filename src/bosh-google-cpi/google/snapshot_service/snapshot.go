@@ -1,7 +1,23 @@
 package snapshot
 
+// Snapshots are labelled with the disk and VM they were taken from.
+const (
+	DiskLabelKey = "bosh-disk-id"
+	VMLabelKey   = "bosh-vm-id"
+)
+
+// MoveZoneLabelKey marks the intermediate snapshots of disks being moved to
+// another zone, with the zone they are moved to.
+const MoveZoneLabelKey = "bosh-move-zone"
+
 type Snapshot struct {
 	Name     string
 	SelfLink string
 	Status   string
+	Labels   map[string]string
+
+	CreationTimestamp string
+
+	// DiskSizeGb is the size of the disk the snapshot was taken from
+	DiskSizeGb int64
 }
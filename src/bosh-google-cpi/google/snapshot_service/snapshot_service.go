@@ -1,7 +1,9 @@
 package snapshot
 
 type Service interface {
-	Create(diskID string, description string, zone string) (string, error)
+	Create(diskID string, description string, zone string, labels map[string]string) (string, error)
+	CreateInRegion(diskID string, description string, region string, labels map[string]string) (string, error)
 	Delete(id string) error
 	Find(id string) (Snapshot, bool, error)
+	FindByLabels(labels map[string]string) ([]Snapshot, error)
 }
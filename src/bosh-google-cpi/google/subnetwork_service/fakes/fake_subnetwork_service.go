@@ -6,12 +6,20 @@ import (
 
 type FakeSubnetworkService struct {
 	FindCalled     bool
-	FindFound      bool
 	FindSubnetwork subnetwork.Subnetwork
 	FindErr        error
+
+	FindByNameCalled      bool
+	FindByNameSubnetworks []subnetwork.Subnetwork
+	FindByNameErr         error
 }
 
-func (s *FakeSubnetworkService) Find(id string, region string) (subnetwork.Subnetwork, bool, error) {
+func (s *FakeSubnetworkService) Find(projectId string, id string, region string) (subnetwork.Subnetwork, error) {
 	s.FindCalled = true
-	return s.FindSubnetwork, s.FindFound, s.FindErr
+	return s.FindSubnetwork, s.FindErr
+}
+
+func (s *FakeSubnetworkService) FindByName(projectId string, id string) ([]subnetwork.Subnetwork, error) {
+	s.FindByNameCalled = true
+	return s.FindByNameSubnetworks, s.FindByNameErr
 }
@@ -2,8 +2,14 @@ package subnetwork
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 
 	"bosh-google-cpi/util"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 var ErrRegionRequired error = errors.New("A region is required to find a subnet")
@@ -17,12 +23,47 @@ func (s GoogleSubnetworkService) Find(projectId, id, region string) (Subnetwork,
 	s.logger.Debug(googleSubnetworkServiceLogTag, "Finding Google Subnetwork '%s' in region '%s' in project '%s'", id, region, projectId)
 	subnetworkItem, err := s.computeService.Subnetworks.Get(s.projectService.Find(projectId), util.ResourceSplitter(region), id).Do()
 	if err != nil {
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 403 {
+			return Subnetwork{}, bosherr.WrapErrorf(err, "Service account has no access to Google Subnetwork '%s' in project '%s'", id, s.projectService.Find(projectId))
+		}
+		if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == 404 {
+			return Subnetwork{}, ErrSubnetNotFound
+		}
 		return Subnetwork{}, err
 	}
 
-	subnetwork := Subnetwork{
-		Name:     subnetworkItem.Name,
-		SelfLink: subnetworkItem.SelfLink,
+	return newSubnetwork(subnetworkItem), nil
+}
+
+// FindByName returns the subnetworks named id in every region of the
+// project, sorted by region.
+func (s GoogleSubnetworkService) FindByName(projectId, id string) ([]Subnetwork, error) {
+	s.logger.Debug(googleSubnetworkServiceLogTag, "Finding Google Subnetworks '%s' in project '%s'", id, projectId)
+	filter := fmt.Sprintf("name eq %s", id)
+	subnetworkItems, err := s.computeService.Subnetworks.AggregatedList(s.projectService.Find(projectId)).Filter(filter).Do()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to find Google Subnetworks '%s' in project '%s'", id, s.projectService.Find(projectId))
+	}
+
+	var subnetworks []Subnetwork
+	for _, scopedList := range subnetworkItems.Items {
+		for _, subnetworkItem := range scopedList.Subnetworks {
+			if subnetworkItem.Name == id {
+				subnetworks = append(subnetworks, newSubnetwork(subnetworkItem))
+			}
+		}
+	}
+	sort.Slice(subnetworks, func(i, j int) bool { return subnetworks[i].Region < subnetworks[j].Region })
+
+	return subnetworks, nil
+}
+
+func newSubnetwork(subnetworkItem *compute.Subnetwork) Subnetwork {
+	return Subnetwork{
+		Name:        subnetworkItem.Name,
+		SelfLink:    subnetworkItem.SelfLink,
+		IPCidrRange: subnetworkItem.IpCidrRange,
+		Network:     subnetworkItem.Network,
+		Region:      util.ResourceSplitter(subnetworkItem.Region),
 	}
-	return subnetwork, nil
 }
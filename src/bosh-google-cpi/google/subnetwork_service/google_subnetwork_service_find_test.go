@@ -0,0 +1,69 @@
+package subnetwork_test
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+	"bosh-google-cpi/google/project_service"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/subnetwork_service"
+)
+
+var _ = Describe("GoogleSubnetworkService", func() {
+	var (
+		server            *clientfakes.FakeComputeServer
+		subnetworkService GoogleSubnetworkService
+	)
+
+	BeforeEach(func() {
+		server = clientfakes.NewFakeComputeServer()
+		server.Respond("GET", "/fake-project/regions/fake-region1/subnetworks/fake-subnetwork", `{"name": "fake-subnetwork", "selfLink": "fake-subnetwork-self-link", "network": "fake-network-self-link", "region": "https://fake/regions/fake-region1", "ipCidrRange": "10.0.0.0/24"}`)
+		server.Respond("GET", "/fake-project/aggregated/subnetworks", `{"items": {
+			"regions/fake-region2": {"subnetworks": [{"name": "fake-subnetwork", "region": "https://fake/regions/fake-region2"}]},
+			"regions/fake-region1": {"subnetworks": [{"name": "fake-subnetwork", "region": "https://fake/regions/fake-region1"}]},
+			"regions/fake-region3": {"warning": {"code": "NO_RESULTS_ON_PAGE"}}
+		}}`)
+
+		subnetworkService = NewGoogleSubnetworkService(
+			project.NewGoogleProjectService("fake-project"),
+			server.ComputeService(),
+			boshlog.NewLogger(boshlog.LevelNone),
+		)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Find", func() {
+		It("finds the subnetwork in the region", func() {
+			subnetwork, err := subnetworkService.Find("", "fake-subnetwork", "fake-region1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnetwork).To(Equal(Subnetwork{
+				Name:        "fake-subnetwork",
+				SelfLink:    "fake-subnetwork-self-link",
+				IPCidrRange: "10.0.0.0/24",
+				Network:     "fake-network-self-link",
+				Region:      "fake-region1",
+			}))
+		})
+
+		It("returns ErrSubnetNotFound if the subnetwork is not in the region", func() {
+			_, err := subnetworkService.Find("", "fake-subnetwork", "fake-region2")
+			Expect(err).To(Equal(ErrSubnetNotFound))
+		})
+	})
+
+	Describe("FindByName", func() {
+		It("finds the subnetworks in every region, sorted by region", func() {
+			subnetworks, err := subnetworkService.FindByName("", "fake-subnetwork")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(subnetworks).To(HaveLen(2))
+			Expect(subnetworks[0].Region).To(Equal("fake-region1"))
+			Expect(subnetworks[1].Region).To(Equal("fake-region2"))
+		})
+	})
+})
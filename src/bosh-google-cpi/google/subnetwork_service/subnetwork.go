@@ -1,6 +1,9 @@
 package subnetwork
 
 type Subnetwork struct {
-	Name     string
-	SelfLink string
+	Name        string
+	SelfLink    string
+	IPCidrRange string
+	Network     string
+	Region      string
 }
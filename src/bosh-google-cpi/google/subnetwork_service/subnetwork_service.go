@@ -2,4 +2,5 @@ package subnetwork
 
 type Service interface {
 	Find(projectId string, id string, region string) (Subnetwork, error)
+	FindByName(projectId string, id string) ([]Subnetwork, error)
 }
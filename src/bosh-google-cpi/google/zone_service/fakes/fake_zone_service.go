@@ -0,0 +1,18 @@
+package fakes
+
+import (
+	"bosh-google-cpi/google/zone_service"
+)
+
+type FakeZoneService struct {
+	FindByRegionCalled bool
+	FindByRegionRegion string
+	FindByRegionZones  []zone.Zone
+	FindByRegionErr    error
+}
+
+func (z *FakeZoneService) FindByRegion(region string) ([]zone.Zone, error) {
+	z.FindByRegionCalled = true
+	z.FindByRegionRegion = region
+	return z.FindByRegionZones, z.FindByRegionErr
+}
@@ -0,0 +1,28 @@
+package zone
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	"google.golang.org/api/compute/v1"
+)
+
+const googleZoneServiceLogTag = "GoogleZoneService"
+const googleZoneStatusUp = "UP"
+
+type GoogleZoneService struct {
+	project        string
+	computeService *compute.Service
+	logger         boshlog.Logger
+}
+
+func NewGoogleZoneService(
+	project string,
+	computeService *compute.Service,
+	logger boshlog.Logger,
+) GoogleZoneService {
+	return GoogleZoneService{
+		project:        project,
+		computeService: computeService,
+		logger:         logger,
+	}
+}
@@ -0,0 +1,38 @@
+package zone
+
+import (
+	"fmt"
+	"sort"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
+
+	"bosh-google-cpi/util"
+)
+
+// FindByRegion returns the zones of a region that are up, sorted by name.
+func (z GoogleZoneService) FindByRegion(region string) ([]Zone, error) {
+	z.logger.Debug(googleZoneServiceLogTag, "Finding Google Zones in region '%s'", region)
+	filter := fmt.Sprintf("region eq .*/regions/%s", region)
+	zoneList, err := z.computeService.Zones.List(z.project).Filter(filter).Do()
+	if err != nil {
+		return nil, bosherr.WrapErrorf(err, "Failed to find Google Zones in region '%s'", region)
+	}
+
+	var zones []Zone
+	for _, zoneItem := range zoneList.Items {
+		if util.ResourceSplitter(zoneItem.Region) != region || zoneItem.Status != googleZoneStatusUp {
+			continue
+		}
+
+		zones = append(zones, Zone{
+			Name:     zoneItem.Name,
+			Region:   region,
+			Status:   zoneItem.Status,
+			SelfLink: zoneItem.SelfLink,
+		})
+	}
+
+	sort.Slice(zones, func(i, j int) bool { return zones[i].Name < zones[j].Name })
+
+	return zones, nil
+}
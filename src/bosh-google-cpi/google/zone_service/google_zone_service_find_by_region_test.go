@@ -0,0 +1,72 @@
+package zone_test
+
+import (
+	"fmt"
+	"net/http"
+
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+
+	clientfakes "bosh-google-cpi/google/client/fakes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "bosh-google-cpi/google/zone_service"
+)
+
+var _ = Describe("GoogleZoneService", func() {
+	var (
+		server      *clientfakes.FakeComputeServer
+		filter      string
+		status      int
+		zoneService GoogleZoneService
+	)
+
+	BeforeEach(func() {
+		filter = ""
+		status = http.StatusOK
+		server = clientfakes.NewFakeComputeServer()
+		server.Handle("GET", "/fake-project/zones", func(w http.ResponseWriter, r *http.Request) {
+			filter = r.URL.Query().Get("filter")
+			if status != http.StatusOK {
+				clientfakes.WriteError(w, status, "fake-error")
+				return
+			}
+			fmt.Fprint(w, `{"items": [
+				{"name": "us-central1-f", "region": "https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central1", "status": "UP"},
+				{"name": "us-central1-b", "region": "https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central1", "status": "UP"},
+				{"name": "us-central1-c", "region": "https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central1", "status": "DOWN"},
+				{"name": "us-central10-a", "region": "https://www.googleapis.com/compute/v1/projects/fake-project/regions/us-central10", "status": "UP"}
+			]}`)
+		})
+
+		zoneService = NewGoogleZoneService("fake-project", server.ComputeService(), boshlog.NewLogger(boshlog.LevelNone))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("FindByRegion", func() {
+		It("returns the zones of the region that are up, sorted by name", func() {
+			zones, err := zoneService.FindByRegion("us-central1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(filter).To(Equal("region eq .*/regions/us-central1"))
+
+			var names []string
+			for _, z := range zones {
+				Expect(z.Region).To(Equal("us-central1"))
+				names = append(names, z.Name)
+			}
+			Expect(names).To(Equal([]string{"us-central1-b", "us-central1-f"}))
+		})
+
+		It("returns an error if the zones can not be listed", func() {
+			status = http.StatusForbidden
+
+			_, err := zoneService.FindByRegion("us-central1")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Failed to find Google Zones in region 'us-central1'"))
+		})
+	})
+})
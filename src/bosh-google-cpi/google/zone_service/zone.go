@@ -0,0 +1,8 @@
+package zone
+
+type Zone struct {
+	Name     string
+	Region   string
+	Status   string
+	SelfLink string
+}
@@ -0,0 +1,5 @@
+package zone
+
+type Service interface {
+	FindByRegion(region string) ([]Zone, error)
+}
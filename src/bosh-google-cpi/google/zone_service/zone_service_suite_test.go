@@ -0,0 +1,13 @@
+package zone_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestZoneService(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Zone Service Suite")
+}
@@ -0,0 +1,37 @@
+package registry
+
+import (
+	boshlog "github.com/cloudfoundry/bosh-utils/logger"
+)
+
+const dryRunClientLogTag = "RegistryDryRunClient"
+
+// DryRunClient represents a BOSH Registry Client that never stores anything.
+// It is used in dry run mode, where the instances it would be asked about
+// are never created.
+type DryRunClient struct {
+	logger boshlog.Logger
+}
+
+// NewDryRunClient creates a new dry run BOSH Registry Client.
+func NewDryRunClient(logger boshlog.Logger) DryRunClient {
+	return DryRunClient{logger: logger}
+}
+
+// Delete logs the instance settings that would have been deleted.
+func (c DryRunClient) Delete(instanceID string) error {
+	c.logger.Warn(dryRunClientLogTag, "Dry run, not deleting agent settings for instance '%s'", instanceID)
+	return nil
+}
+
+// Fetch returns empty agent settings for a given instance ID.
+func (c DryRunClient) Fetch(instanceID string) (AgentSettings, error) {
+	c.logger.Debug(dryRunClientLogTag, "Dry run, returning empty agent settings for instance '%s'", instanceID)
+	return AgentSettings{}, nil
+}
+
+// Update logs the instance settings that would have been stored.
+func (c DryRunClient) Update(instanceID string, agentSettings AgentSettings) error {
+	c.logger.Info(dryRunClientLogTag, "Dry run, not updating agent settings for instance '%s': %#v", instanceID, agentSettings)
+	return nil
+}
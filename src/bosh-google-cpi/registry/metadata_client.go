@@ -4,14 +4,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"math"
+	"net/http"
 	"time"
 
 	"bosh-google-cpi/google/client"
+	"bosh-google-cpi/google/instance_service"
 	opsvc "bosh-google-cpi/google/operation_service"
 	"bosh-google-cpi/util"
 	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 	boshlog "github.com/cloudfoundry/bosh-utils/logger"
 	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/googleapi"
 )
 
 const (
@@ -20,6 +23,7 @@ const (
 	opWaiterRetryMax     = 100
 	opMaxSleepExponent   = 3
 	opReadyStatus        = "DONE"
+	updateMaxAttempts    = 3
 )
 
 // MetadataClient represents a GCE metadata client.
@@ -52,7 +56,7 @@ func (i *instanceMetadata) computeMetadata() *compute.Metadata {
 		Fingerprint: i.fingerprint,
 	}
 
-	metadata.Items = make([]*compute.MetadataItems, len(i.items))
+	metadata.Items = make([]*compute.MetadataItems, 0, len(i.items))
 	for k := range i.items {
 		v := i.items[k]
 		metadata.Items = append(metadata.Items, &compute.MetadataItems{Key: k, Value: &v})
@@ -95,20 +99,32 @@ func (c MetadataClient) Update(instanceID string, agentSettings AgentSettings) e
 	}
 	c.logger.Debug(metadataClientLogTag, "Updating instance metadata for %q with agent settings %q", instanceID, settingsJSON)
 
-	currentMetadata, err := c.metadata(instanceID)
-	if err != nil {
-		return err
-	}
-	currentMetadata.items[c.options.GCEMetadataKey] = string(settingsJSON)
+	// Read-modify-write the instance metadata so items set by other tooling
+	// are preserved, retrying when the metadata changed in the meantime.
+	var op *compute.Operation
+	for attempt := 1; ; attempt++ {
+		currentMetadata, err := c.metadata(instanceID)
+		if err != nil {
+			return err
+		}
+		currentMetadata.items[c.options.GCEMetadataKey] = string(settingsJSON)
+		if err := instance.Metadata(currentMetadata.items).ValidateSize(); err != nil {
+			return bosherr.WrapErrorf(err, "Updating instance metadata for instance %q", instanceID)
+		}
 
-	c.logger.Debug(metadataClientLogTag, "Updating instance metadata to: %#v", currentMetadata.computeMetadata())
-	op, err := c.googleClient.ComputeService().Instances.SetMetadata(c.googleClient.Project(), currentMetadata.zone, instanceID, currentMetadata.computeMetadata()).Do()
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Updating instance metadata with SetMetadata call: %v, metadata value: %#v", err, currentMetadata.computeMetadata())
+		c.logger.Debug(metadataClientLogTag, "Updating instance metadata to: %#v", currentMetadata.computeMetadata())
+		op, err = c.googleClient.ComputeService().Instances.SetMetadata(c.googleClient.Project(), currentMetadata.zone, instanceID, currentMetadata.computeMetadata()).Do()
+		if err != nil {
+			if gerr, ok := err.(*googleapi.Error); ok && gerr.Code == http.StatusPreconditionFailed && attempt < updateMaxAttempts {
+				c.logger.Debug(metadataClientLogTag, "Metadata fingerprint for instance %q changed, retrying (%d/%d)", instanceID, attempt, updateMaxAttempts)
+				continue
+			}
+			return bosherr.WrapErrorf(err, "Updating instance metadata with SetMetadata call: %v, metadata value: %#v", err, currentMetadata.computeMetadata())
+		}
+		break
 	}
-	_, err = c.wait(op)
-	if err != nil {
-		return bosherr.WrapErrorf(err, "Updating instance metadata with SetMetadata call: %v, metadata value: %#v", err, currentMetadata.computeMetadata())
+	if _, err = c.wait(op); err != nil {
+		return bosherr.WrapErrorf(err, "Updating instance metadata for instance %q", instanceID)
 	}
 	return nil
 }
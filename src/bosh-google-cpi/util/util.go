@@ -1,11 +1,44 @@
 package util
 
 import (
+	"encoding/json"
+	"fmt"
 	"math"
 	"regexp"
+	"sort"
 	"strings"
+
+	bosherr "github.com/cloudfoundry/bosh-utils/errors"
 )
 
+// MaxDescriptionLength is the longest description Google Compute Engine
+// accepts on instances, disks and images.
+const MaxDescriptionLength = 2048
+
+// Cloud KMS keys are referenced by their resource name.
+var kmsKeyNameRe = regexp.MustCompile(`^projects/[^/]+/locations/[^/]+/keyRings/[^/]+/cryptoKeys/[^/]+$`)
+
+// IsKmsKeyName reports whether name is the resource name of a Cloud KMS key,
+// projects/<project>/locations/<location>/keyRings/<key-ring>/cryptoKeys/<key>.
+func IsKmsKeyName(name string) bool {
+	return kmsKeyNameRe.MatchString(name)
+}
+
+// ConvertResource copies a resource between the v1 and beta APIs, which
+// share their JSON representation.
+func ConvertResource(from interface{}, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return bosherr.WrapError(err, "Marshalling Google resource")
+	}
+	if err := json.Unmarshal(data, to); err != nil {
+		return bosherr.WrapError(err, "Unmarshalling Google resource")
+	}
+	return nil
+}
+
+// ConvertMib2Gib converts the MiB sizes BOSH uses to the whole GiB sizes GCE
+// provisions, rounding up so disks are never smaller than requested.
 func ConvertMib2Gib(size int) int {
 	sizeGb := float64(size) / float64(1024)
 	return int(math.Ceil(sizeGb))
@@ -17,16 +50,51 @@ func ResourceSplitter(resource string) string {
 	return splits[len(splits)-1]
 }
 
-var regionRe = regexp.MustCompile("(^[a-zA-Z-]+[1-9])")
+// LabelsFilter returns the list filter matching the resources carrying all
+// the labels.
+func LabelsFilter(labels map[string]string) string {
+	var keys []string
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
 
-// RegionFromZone extracts the region from a zone.
-// For example, us-central1-a produces us-central1
-func RegionFromZone(zone string) string {
-	s := regionRe.FindStringSubmatch(zone)
-	if len(s) == 2 {
-		return s[1]
+	var expressions []string
+	for _, k := range keys {
+		expressions = append(expressions, fmt.Sprintf("(labels.%s eq %s)", k, labels[k]))
 	}
-	return ""
+	return strings.Join(expressions, " ")
+}
+
+// HasLabels reports whether resourceLabels contain all the labels.
+func HasLabels(resourceLabels map[string]string, labels map[string]string) bool {
+	for k, v := range labels {
+		if resourceLabels[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+var regionRe = regexp.MustCompile("^[a-z]+(-[a-z]+)*[0-9]+$")
+var zoneSuffixRe = regexp.MustCompile("^[a-z]$")
+
+// RegionFromZone extracts the region from a zone, following the
+// <region>-<letter> naming convention of Google Compute Engine zones.
+// For example, us-central1-a produces us-central1. An error is returned
+// if the zone is malformed.
+func RegionFromZone(zone string) (string, error) {
+	i := strings.LastIndex(zone, "-")
+	if i == -1 {
+		return "", fmt.Errorf("Malformed zone '%s': expected '<region>-<letter>'", zone)
+	}
+
+	region, suffix := zone[:i], zone[i+1:]
+	if !regionRe.MatchString(region) || !zoneSuffixRe.MatchString(suffix) {
+		return "", fmt.Errorf("Malformed zone '%s': expected '<region>-<letter>'", zone)
+	}
+
+	return region, nil
 }
 
 var zoneRe = regexp.MustCompile("/zones/([a-zA-Z1-9-]+)/?")
@@ -42,6 +110,19 @@ func ZoneFromURL(url string) string {
 	return ""
 }
 
+var projectURLRe = regexp.MustCompile("/projects/([^/]+)/")
+
+// ProjectFromURL extracts and returns the project from the fully-qualified
+// URL of a Google Compute Engine resource. The zero value is returned if a
+// project can not be found.
+func ProjectFromURL(url string) string {
+	s := projectURLRe.FindStringSubmatch(url)
+	if len(s) == 2 {
+		return s[1]
+	}
+	return ""
+}
+
 var regionURLRe = regexp.MustCompile("/regions/([a-zA-Z1-9-]+)/?")
 
 // RegionFromURL extracts and returns the region from the fully-qualified
@@ -12,6 +12,56 @@ var _ = Describe("Util", func() {
 		It("converts Mib to Gib", func() {
 			Expect(ConvertMib2Gib(32768)).To(Equal(32))
 		})
+
+		It("rounds sizes up to the next Gib", func() {
+			Expect(ConvertMib2Gib(1)).To(Equal(1))
+			Expect(ConvertMib2Gib(500)).To(Equal(1))
+			Expect(ConvertMib2Gib(1025)).To(Equal(2))
+		})
+	})
+
+	Describe("ProjectFromURL", func() {
+		It("parses the project from a resource URL", func() {
+			Expect(ProjectFromURL("https://www.googleapis.com/compute/v1/projects/fake-image-project/global/operations/fake-operation")).To(Equal("fake-image-project"))
+		})
+
+		It("returns an empty project if the URL has none", func() {
+			Expect(ProjectFromURL("fake-operation")).To(Equal(""))
+		})
+	})
+
+	Describe("IsKmsKeyName", func() {
+		It("accepts Cloud KMS key resource names", func() {
+			Expect(IsKmsKeyName("projects/fake-project/locations/global/keyRings/fake-key-ring/cryptoKeys/fake-key")).To(BeTrue())
+		})
+
+		It("rejects other names", func() {
+			Expect(IsKmsKeyName("fake-key")).To(BeFalse())
+			Expect(IsKmsKeyName("projects/fake-project/locations/global/keyRings/fake-key-ring")).To(BeFalse())
+		})
+	})
+
+	Describe("ConvertResource", func() {
+		It("copies the fields shared by the JSON representations", func() {
+			type v1Resource struct {
+				Name string `json:"name"`
+			}
+			type betaResource struct {
+				Name        string `json:"name"`
+				NetworkTier string `json:"networkTier"`
+			}
+
+			var to betaResource
+			Expect(ConvertResource(v1Resource{Name: "fake-name"}, &to)).To(Succeed())
+			Expect(to).To(Equal(betaResource{Name: "fake-name"}))
+		})
+
+		It("returns an error if the resource can not be copied", func() {
+			var to string
+			err := ConvertResource(map[string]string{"name": "fake-name"}, &to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("Unmarshalling Google resource"))
+		})
 	})
 
 	Describe("ResourceSplitter", func() {
@@ -26,13 +76,30 @@ var _ = Describe("Util", func() {
 		})
 	})
 
+	Describe("LabelsFilter", func() {
+		It("matches all the labels, in a stable order", func() {
+			Expect(LabelsFilter(map[string]string{"director": "fake-director", "deployment": "fake-deployment"})).To(Equal("(labels.deployment eq fake-deployment) (labels.director eq fake-director)"))
+		})
+	})
+
 	Describe("RegionFromZone", func() {
 		It("successfully parses region from well-formed zone", func() {
-			Expect(RegionFromZone("us-west2-c")).To(Equal("us-west2"))
+			for zone, region := range map[string]string{
+				"us-west2-c":                "us-west2",
+				"us-east10-a":               "us-east10",
+				"europe-west1-b":            "europe-west1",
+				"northamerica-northeast1-a": "northamerica-northeast1",
+			} {
+				Expect(RegionFromZone(zone)).To(Equal(region))
+			}
 		})
 
 		It("fails to parse region from mal-formed zone", func() {
-			Expect(RegionFromZone("notAZone")).To(Equal(""))
+			for _, zone := range []string{"", "notAZone", "us-central1", "us-central1-", "us-central1-ab", "-a", "us-central-a", "US-CENTRAL1-A"} {
+				_, err := RegionFromZone(zone)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("Malformed zone '%s'", zone))
+			}
 		})
 	})
 